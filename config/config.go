@@ -1,27 +1,108 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const DefaultCbprPath = "cbpr"
 
+// Default review worker pool size and per-repository limit
+const (
+	DefaultReviewConcurrency     = 2
+	DefaultReviewRepoConcurrency = 1
+)
+
+// DefaultReviewTimeout is how long one review may run before its generator is killed
+const DefaultReviewTimeout = 5 * time.Minute
+
+// Default retry policy for failed reviews: the first retry waits DefaultReviewRetryBackoff, each
+// later one twice as long as the last, and the review is given up after DefaultReviewMaxAttempts
+const (
+	DefaultReviewMaxAttempts  = 5
+	DefaultReviewRetryBackoff = 5 * time.Minute
+)
+
+// DefaultArchiveRetention is how long closed and merged PRs are kept, with their reviews, before
+// they are deleted
+const DefaultArchiveRetention = 90 * 24 * time.Hour
+
+// Review generator backends selected with REVIEW_GENERATOR
+const (
+	ReviewGeneratorCbpr    = "cbpr"    // cbpr review (default)
+	ReviewGeneratorCommand = "command" // REVIEW_COMMAND with per-PR placeholders
+	ReviewGeneratorNone    = "none"    // Track PRs without generating reviews
+)
+
+// DefaultWebhookPollingInterval is the polling interval when webhooks are configured; polls then
+// only reconcile events that were missed or never delivered
+const DefaultWebhookPollingInterval = 10 * time.Minute
+
+// Account is one GitHub identity to poll, on github.com or a GitHub Enterprise Server host
+type Account struct {
+	Host       string // Hostname of WebURL, e.g. "github.com" or "ghe.example.com"
+	Token      string
+	Username   string
+	APIURL     string
+	GraphQLURL string
+	WebURL     string
+	// GitHub App installation auth, used instead of Token when AppID is set
+	AppID             int64
+	AppInstallationID int64
+	AppPrivateKeyPath string
+}
+
+// Name identifies the account in logs, the database and the dashboard
+func (a Account) Name() string {
+	return a.Username + "@" + a.Host
+}
+
+// UsesApp reports whether the account authenticates as a GitHub App installation
+func (a Account) UsesApp() bool {
+	return a.AppID != 0
+}
+
 type Config struct {
 	GitHubToken              string
 	GitHubUsername           string
+	GitHubAPIURL             string    // REST API base URL (e.g. https://ghe.example.com/api/v3/)
+	GitHubGraphQLURL         string    // GraphQL endpoint (e.g. https://ghe.example.com/api/graphql)
+	GitHubWebURL             string    // Web host used for PR links (e.g. https://ghe.example.com)
+	Accounts                 []Account // All accounts to poll; Accounts[0] is the primary account
 	PollingInterval          time.Duration
 	DBPath                   string
+	DatabaseURL              string // PostgreSQL connection URL; when set, used instead of the SQLite database at DBPath
 	ReviewsDir               string
 	ServerPort               string
 	CbprPath                 string
 	CbprEnabled              bool
+	ReviewGenerator          string        // One of the ReviewGenerator* backends
+	ReviewCommand            []string      // Argv template for the command backend
+	ReviewConcurrency        int           // Reviews generated at once across all repositories
+	ReviewRepoConcurrency    int           // Reviews generated at once within one repository
+	ReviewTimeout            time.Duration // Limit on one review's generation; 0 disables it
+	ReviewMaxAttempts        int           // Failed attempts at one commit before a review is marked failed
+	ReviewRetryBackoff       time.Duration // Wait before the first retry; doubles with each attempt
+	ArchiveRetention         time.Duration // How long closed and merged PRs are kept after closing; 0 keeps them forever
 	GeminiAPIKey             string
 	EnableVoiceNotifications bool
+	WebhookSecret            string // Secret for verifying X-Hub-Signature-256 on /api/webhooks/github; empty disables webhooks
+	accountsErr              error  // Deferred GITHUB_ACCOUNTS parse error, reported by Validate
 }
 
 func Load() *Config {
+	webhookSecret := os.Getenv("GITHUB_WEBHOOK_SECRET")
+
 	pollingInterval := 1 * time.Minute
+	if webhookSecret != "" {
+		pollingInterval = DefaultWebhookPollingInterval
+	}
 	if interval := os.Getenv("POLLING_INTERVAL"); interval != "" {
 		if d, err := time.ParseDuration(interval); err == nil {
 			pollingInterval = d
@@ -33,21 +114,234 @@ func Load() *Config {
 		cbprPath = DefaultCbprPath // assume it's in PATH
 	}
 
+	apiURL, graphqlURL, webURL := resolveGitHubURLs(os.Getenv("GITHUB_WEB_URL"), os.Getenv("GITHUB_API_URL"), os.Getenv("GITHUB_GRAPHQL_URL"))
+	accounts, accountsErr := loadAccounts(apiURL, graphqlURL, webURL)
+
 	// Enable voice notifications by default (can be disabled with ENABLE_VOICE_NOTIFICATIONS=false)
 	enableVoice := getEnvOrDefault("ENABLE_VOICE_NOTIFICATIONS", "true") == "true"
 
-	return &Config{
+	cfg := &Config{
 		GitHubToken:              os.Getenv("GITHUB_TOKEN"),
 		GitHubUsername:           os.Getenv("GITHUB_USERNAME"),
+		GitHubAPIURL:             apiURL,
+		GitHubGraphQLURL:         graphqlURL,
+		GitHubWebURL:             webURL,
 		PollingInterval:          pollingInterval,
 		DBPath:                   getEnvOrDefault("DB_PATH", "./data/pr-review.db"),
+		DatabaseURL:              os.Getenv("DATABASE_URL"),
 		ReviewsDir:               getEnvOrDefault("REVIEWS_DIR", "./reviews"),
 		ServerPort:               getEnvOrDefault("SERVER_PORT", "8080"),
 		CbprPath:                 cbprPath,
 		CbprEnabled:              false, // Will be set to true in main.go if cbpr is available
+		ReviewGenerator:          getEnvOrDefault("REVIEW_GENERATOR", ReviewGeneratorCbpr),
+		ReviewCommand:            strings.Fields(os.Getenv("REVIEW_COMMAND")),
+		ReviewConcurrency:        getEnvIntOrDefault("REVIEW_CONCURRENCY", DefaultReviewConcurrency),
+		ReviewRepoConcurrency:    getEnvIntOrDefault("REVIEW_REPO_CONCURRENCY", DefaultReviewRepoConcurrency),
+		ReviewTimeout:            getEnvDurationOrDefault("REVIEW_TIMEOUT", DefaultReviewTimeout),
+		ReviewMaxAttempts:        getEnvIntOrDefault("REVIEW_MAX_ATTEMPTS", DefaultReviewMaxAttempts),
+		ReviewRetryBackoff:       getEnvDurationOrDefault("REVIEW_RETRY_BACKOFF", DefaultReviewRetryBackoff),
+		ArchiveRetention:         getEnvDurationOrDefault("ARCHIVE_RETENTION", DefaultArchiveRetention),
 		GeminiAPIKey:             os.Getenv("GEMINI_API_KEY"),
 		EnableVoiceNotifications: enableVoice,
+		WebhookSecret:            webhookSecret,
+		Accounts:                 accounts,
+		accountsErr:              accountsErr,
+	}
+
+	// The primary account backs the single-account fields
+	if len(accounts) > 0 {
+		cfg.GitHubToken = accounts[0].Token
+		cfg.GitHubUsername = accounts[0].Username
+		cfg.GitHubAPIURL = accounts[0].APIURL
+		cfg.GitHubGraphQLURL = accounts[0].GraphQLURL
+		cfg.GitHubWebURL = accounts[0].WebURL
+	}
+
+	return cfg
+}
+
+// Validate reports configuration that prevents the server from starting
+func (c *Config) Validate() error {
+	if c.accountsErr != nil {
+		return c.accountsErr
+	}
+	switch c.ReviewGenerator {
+	case ReviewGeneratorCbpr, ReviewGeneratorNone:
+	case ReviewGeneratorCommand:
+		if len(c.ReviewCommand) == 0 {
+			return errors.New("REVIEW_GENERATOR=command requires REVIEW_COMMAND")
+		}
+	default:
+		return fmt.Errorf("unknown REVIEW_GENERATOR %q (expected cbpr, command or none)", c.ReviewGenerator)
+	}
+	if len(c.Accounts) == 0 {
+		return errors.New("GITHUB_TOKEN (or GitHub App credentials) and GITHUB_USERNAME (or GITHUB_ACCOUNTS) environment variables are required")
+	}
+	for i, account := range c.Accounts {
+		if account.UsesApp() {
+			if account.AppInstallationID == 0 || account.AppPrivateKeyPath == "" {
+				return fmt.Errorf("account %d (%s): GitHub App auth requires an app ID, installation ID and private key path", i, account.Name())
+			}
+		} else if account.Token == "" {
+			return fmt.Errorf("account %d (%s): token is required", i, account.Name())
+		}
+		// The username drives the review-requested: and author: searches, including with GitHub App auth
+		if account.Username == "" {
+			return fmt.Errorf("account %d (%s): username is required", i, account.Host)
+		}
+	}
+	return nil
+}
+
+// WebURLForHost returns the web URL of the account on host, falling back to the primary account
+func (c *Config) WebURLForHost(host string) string {
+	for _, account := range c.Accounts {
+		if account.Host == host {
+			return account.WebURL
+		}
+	}
+	return c.GitHubWebURL
+}
+
+// accountJSON is the shape of one entry in GITHUB_ACCOUNTS
+type accountJSON struct {
+	WebURL     string `json:"web_url"`
+	APIURL     string `json:"api_url"`
+	GraphQLURL string `json:"graphql_url"`
+	Token      string `json:"token"`
+	TokenEnv   string `json:"token_env"` // Name of an env var holding the token, to keep secrets out of the JSON
+	Username   string `json:"username"`
+	// GitHub App installation auth, instead of token/token_env
+	AppID             int64  `json:"app_id"`
+	AppInstallationID int64  `json:"app_installation_id"`
+	AppPrivateKeyPath string `json:"app_private_key_path"`
+}
+
+// loadAccounts parses GITHUB_ACCOUNTS, a JSON array of accounts, e.g.
+//
+//	[{"username":"me","token_env":"GITHUB_TOKEN"},
+//	 {"web_url":"https://ghe.example.com","username":"me-corp","token_env":"GHES_TOKEN"}]
+//
+// Without GITHUB_ACCOUNTS a single account is built from GITHUB_TOKEN (or the GITHUB_APP_* settings),
+// GITHUB_USERNAME and the URL settings.
+func loadAccounts(apiURL, graphqlURL, webURL string) ([]Account, error) {
+	raw := os.Getenv("GITHUB_ACCOUNTS")
+	if raw == "" {
+		token := os.Getenv("GITHUB_TOKEN")
+		username := os.Getenv("GITHUB_USERNAME")
+		appID, err := getEnvInt64("GITHUB_APP_ID")
+		if err != nil {
+			return nil, err
+		}
+		installationID, err := getEnvInt64("GITHUB_APP_INSTALLATION_ID")
+		if err != nil {
+			return nil, err
+		}
+		if token == "" && username == "" && appID == 0 {
+			return nil, nil
+		}
+		return []Account{{
+			Host:              hostOf(webURL),
+			Token:             token,
+			Username:          username,
+			APIURL:            apiURL,
+			GraphQLURL:        graphqlURL,
+			WebURL:            webURL,
+			AppID:             appID,
+			AppInstallationID: installationID,
+			AppPrivateKeyPath: os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"),
+		}}, nil
+	}
+
+	var entries []accountJSON
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_ACCOUNTS: %w", err)
+	}
+
+	accounts := make([]Account, 0, len(entries))
+	for _, entry := range entries {
+		token := entry.Token
+		if token == "" && entry.TokenEnv != "" {
+			token = os.Getenv(entry.TokenEnv)
+		}
+		entryAPIURL, entryGraphQLURL, entryWebURL := resolveGitHubURLs(entry.WebURL, entry.APIURL, entry.GraphQLURL)
+		accounts = append(accounts, Account{
+			Host:              hostOf(entryWebURL),
+			Token:             token,
+			Username:          entry.Username,
+			APIURL:            entryAPIURL,
+			GraphQLURL:        entryGraphQLURL,
+			WebURL:            entryWebURL,
+			AppID:             entry.AppID,
+			AppInstallationID: entry.AppInstallationID,
+			AppPrivateKeyPath: entry.AppPrivateKeyPath,
+		})
+	}
+	return accounts, nil
+}
+
+// hostOf returns the hostname of a web URL, e.g. "github.com"
+func hostOf(webURL string) string {
+	u, err := url.Parse(webURL)
+	if err != nil || u.Host == "" {
+		return webURL
+	}
+	return u.Host
+}
+
+// resolveGitHubURLs fills in defaults for the GitHub endpoints. Setting only the web URL to a GitHub
+// Enterprise Server host derives the standard GHES API paths (<host>/api/v3/ and <host>/api/graphql).
+func resolveGitHubURLs(webURL, apiURL, graphqlURL string) (string, string, string) {
+	if webURL == "" {
+		webURL = "https://github.com"
+	}
+	webURL = strings.TrimSuffix(webURL, "/")
+
+	defaultAPIURL := "https://api.github.com/"
+	defaultGraphQLURL := "https://api.github.com/graphql"
+	if webURL != "https://github.com" {
+		defaultAPIURL = webURL + "/api/v3/"
+		defaultGraphQLURL = webURL + "/api/graphql"
+	}
+
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	if graphqlURL == "" {
+		graphqlURL = defaultGraphQLURL
+	}
+	return apiURL, graphqlURL, webURL
+}
+
+// getEnvInt64 parses an optional integer env var, returning 0 when unset
+func getEnvInt64(key string) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvIntOrDefault parses a positive integer env var, falling back to defaultValue when unset or invalid
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n < 1 {
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvDurationOrDefault parses a non-negative duration env var such as "10m", falling back to defaultValue when unset or invalid
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d < 0 {
+		return defaultValue
 	}
+	return d
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
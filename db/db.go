@@ -12,13 +12,15 @@ import (
 
 type PR struct {
 	ID              int
+	Host            string // GitHub host the PR lives on, e.g. "github.com" or a GHES hostname
+	Account         string // Account that discovered the PR ("username@host")
 	RepoOwner       string
 	RepoName        string
 	PRNumber        int
 	LastCommitSHA   string
 	LastReviewedAt  *time.Time
 	ReviewHTMLPath  string
-	Status          string // "pending", "generating", "completed", "error"
+	Status          string // "pending", "generating", "completed", "error" (will be retried), "failed" (out of attempts), "cancelled"
 	GeneratingSince *time.Time
 	IsMine          bool      // true if this is my PR (authored by me)
 	Title           string    // PR title from GitHub
@@ -30,31 +32,127 @@ type PR struct {
 	Notes           string     // User notes (max 15 chars)
 	CIState         string     // CI status: "success", "failure", "pending", "unknown"
 	CIFailedChecks  string     // JSON array of failed check names
+	ReviewAttempts  int        // Failed review attempts at LastCommitSHA
+	LastError       string     // Why the last attempt failed
+	NextRetryAt     *time.Time // When an errored review is retried; nil once it has failed for good
+	ClosedAt        *time.Time // When the PR was closed or merged on GitHub; set once it is archived
+	MergedAt        *time.Time // When the PR was merged; nil if it was closed without merging
 }
 
+// Archived reports whether the PR has been closed or merged and moved out of the open list
+func (pr *PR) Archived() bool {
+	return pr.ClosedAt != nil
+}
+
+// DB is the PR store, backed by SQLite or by PostgreSQL. Queries are written for SQLite, with ?
+// placeholders, and rewritten for PostgreSQL as they run.
 type DB struct {
-	conn *sql.DB
+	conn     *sql.DB // Reads
+	writer   *sql.DB // Writes and transactions; on SQLite a single connection, so writers queue up instead of failing with "database is locked"
+	postgres bool
 }
 
+// sqliteOptions are set on every SQLite connection: WAL lets reads run alongside the writer, busy_timeout
+// waits out locks held by other processes (such as the migrate command), and immediate transactions
+// take the write lock up front rather than failing when a read upgrades to a write.
+const sqliteOptions = "_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on&_txlock=immediate"
+
+// sqliteMaxReaders limits the connections used for reads
+const sqliteMaxReaders = 8
+
+// New opens the database at dbPath and migrates its schema to the latest version
 func New(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+	db, err := Open(dbPath)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := db.MigrateTo(LatestSchemaVersion); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
 
-	if err := conn.Ping(); err != nil {
+// Open opens the database at dbPath without migrating it, for inspecting or migrating it by hand
+func Open(dbPath string) (*DB, error) {
+	dsn := dbPath + "?" + sqliteOptions
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
 		return nil, err
 	}
+	conn.SetMaxOpenConns(sqliteMaxReaders)
 
-	db := &DB{conn: conn}
-	if err := db.initSchema(); err != nil {
+	writer, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		conn.Close()
 		return nil, err
 	}
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
 
-	return db, nil
+	// The writer connects first so it is the one to switch a new database to WAL
+	for _, pool := range []*sql.DB{writer, conn} {
+		if err := pool.Ping(); err != nil {
+			conn.Close()
+			writer.Close()
+			return nil, err
+		}
+	}
+
+	return &DB{conn: conn, writer: writer}, nil
+}
+
+func (db *DB) exec(query string, args ...any) (sql.Result, error) {
+	return db.writer.Exec(db.rebind(query), args...)
+}
+
+func (db *DB) query(query string, args ...any) (*sql.Rows, error) {
+	return db.conn.Query(db.rebind(query), args...)
+}
+
+func (db *DB) queryRow(query string, args ...any) *sql.Row {
+	return db.conn.QueryRow(db.rebind(query), args...)
+}
+
+// tx is a transaction whose queries are rewritten for the database like DB's own
+type tx struct {
+	*sql.Tx
+	db *DB
+}
+
+func (db *DB) begin() (*tx, error) {
+	t, err := db.writer.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, db: db}, nil
+}
+
+func (t *tx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.Tx.Exec(t.db.rebind(query), args...)
+}
+
+func (t *tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.Tx.Query(t.db.rebind(query), args...)
+}
+
+func (t *tx) QueryRow(query string, args ...any) *sql.Row {
+	return t.Tx.QueryRow(t.db.rebind(query), args...)
 }
 
-func (db *DB) initSchema() error {
+// rebind rewrites a query's ? placeholders for the database
+func (db *DB) rebind(query string) string {
+	if !db.postgres {
+		return query
+	}
+	return rebindPostgres(query)
+}
+
+// upgradeLegacySchema brings a database created before schema versioning up to the baseline schema
+// (migration 1). Older releases applied ALTER TABLE statements on every start and ignored duplicate
+// column errors, so a legacy database can be at any point in that list. The list is frozen: schema
+// changes are now numbered migrations.
+func (db *DB) upgradeLegacySchema() error {
 	schema := `
 	CREATE TABLE IF NOT EXISTS prs (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -67,12 +165,25 @@ func (db *DB) initSchema() error {
 		status TEXT DEFAULT 'pending',
 		UNIQUE(repo_owner, repo_name, pr_number)
 	);
+
+	CREATE TABLE IF NOT EXISTS reviews (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pr_id INTEGER NOT NULL REFERENCES prs(id),
+		commit_sha TEXT NOT NULL,
+		generator TEXT DEFAULT '',
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP,
+		status TEXT NOT NULL DEFAULT 'generating',
+		artifact_path TEXT DEFAULT '',
+		stderr_excerpt TEXT DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_reviews_pr_id ON reviews(pr_id);
 	`
-	if _, err := db.conn.Exec(schema); err != nil {
+	if _, err := db.exec(schema); err != nil {
 		return err
 	}
 
-	// Run migrations for additional columns (safe to run multiple times)
+	// Add columns (safe to run multiple times)
 	// Duplicate column errors are ignored, but other errors will fail fast
 	// Wrap all migrations in a transaction for atomicity
 	migrations := []string{
@@ -88,9 +199,16 @@ func (db *DB) initSchema() error {
 		`ALTER TABLE prs ADD COLUMN notes TEXT DEFAULT ''`,
 		`ALTER TABLE prs ADD COLUMN ci_state TEXT DEFAULT 'unknown'`,
 		`ALTER TABLE prs ADD COLUMN ci_failed_checks TEXT DEFAULT '[]'`,
+		`ALTER TABLE prs ADD COLUMN host TEXT DEFAULT 'github.com'`,
+		`ALTER TABLE prs ADD COLUMN account TEXT DEFAULT ''`,
+		`ALTER TABLE prs ADD COLUMN review_attempts INTEGER DEFAULT 0`,
+		`ALTER TABLE prs ADD COLUMN last_error TEXT DEFAULT ''`,
+		`ALTER TABLE prs ADD COLUMN next_retry_at TIMESTAMP`,
+		`ALTER TABLE reviews ADD COLUMN exit_code INTEGER`,
+		`ALTER TABLE reviews ADD COLUMN log_path TEXT DEFAULT ''`,
 	}
 
-	tx, err := db.conn.Begin()
+	tx, err := db.begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin migration transaction: %w", err)
 	}
@@ -119,11 +237,79 @@ func (db *DB) initSchema() error {
 		return fmt.Errorf("failed to commit migrations: %w", err)
 	}
 
+	return db.migrateHostUniqueKey()
+}
+
+// migrateHostUniqueKey rebuilds the prs table so PRs are unique per host as well as owner/repo/number.
+// SQLite cannot alter a UNIQUE constraint in place, so the table is copied into a new one.
+func (db *DB) migrateHostUniqueKey() error {
+	var tableSQL string
+	if err := db.queryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'prs'`).Scan(&tableSQL); err != nil {
+		return fmt.Errorf("failed to read prs schema: %w", err)
+	}
+	if strings.Contains(tableSQL, "UNIQUE(host, repo_owner, repo_name, pr_number)") {
+		return nil // Already migrated
+	}
+
+	// Dropping prs would trip the reviews foreign key. The pragma only takes effect outside a
+	// transaction, and applies to the writer's single connection, which the copy below runs on.
+	if _, err := db.exec(`PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+	defer db.exec(`PRAGMA foreign_keys = ON`)
+
+	columns := `id, host, account, repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, status, generating_since, is_mine, title, author, approval_count, my_review_status, created_at, draft, notes, ci_state, ci_failed_checks, review_attempts, last_error, next_retry_at`
+
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin host key migration: %w", err)
+	}
+	statements := []string{
+		`CREATE TABLE prs_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			host TEXT NOT NULL DEFAULT 'github.com',
+			account TEXT DEFAULT '',
+			repo_owner TEXT NOT NULL,
+			repo_name TEXT NOT NULL,
+			pr_number INTEGER NOT NULL,
+			last_commit_sha TEXT NOT NULL,
+			last_reviewed_at TIMESTAMP,
+			review_html_path TEXT,
+			status TEXT DEFAULT 'pending',
+			generating_since TIMESTAMP,
+			is_mine INTEGER DEFAULT 0,
+			title TEXT DEFAULT '',
+			author TEXT DEFAULT '',
+			approval_count INTEGER DEFAULT 0,
+			my_review_status TEXT DEFAULT '',
+			created_at TIMESTAMP,
+			draft INTEGER DEFAULT 0,
+			notes TEXT DEFAULT '',
+			ci_state TEXT DEFAULT 'unknown',
+			ci_failed_checks TEXT DEFAULT '[]',
+			review_attempts INTEGER DEFAULT 0,
+			last_error TEXT DEFAULT '',
+			next_retry_at TIMESTAMP,
+			UNIQUE(host, repo_owner, repo_name, pr_number)
+		)`,
+		`INSERT INTO prs_new (` + columns + `) SELECT id, COALESCE(host, 'github.com'), COALESCE(account, ''), repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, status, generating_since, is_mine, title, author, approval_count, my_review_status, created_at, draft, notes, ci_state, ci_failed_checks, review_attempts, last_error, next_retry_at FROM prs`,
+		`DROP TABLE prs`,
+		`ALTER TABLE prs_new RENAME TO prs`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("host key migration failed: %w\nSQL: %s", err, stmt)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit host key migration: %w", err)
+	}
 	return nil
 }
 
 // scanPRRow scans a database row into a PR struct, handling nullable fields
-func scanPRRow(pr *PR, reviewedAt, generatingSince, createdAt sql.NullTime, htmlPath sql.NullString, isMine, draft int, title, author, myReviewStatus, notes, ciState, ciFailedChecks sql.NullString) {
+func scanPRRow(pr *PR, reviewedAt, generatingSince, createdAt, nextRetryAt, closedAt, mergedAt sql.NullTime, htmlPath sql.NullString, isMine, draft int, title, author, myReviewStatus, notes, ciState, ciFailedChecks sql.NullString) {
 	if reviewedAt.Valid {
 		pr.LastReviewedAt = &reviewedAt.Time
 	}
@@ -136,6 +322,15 @@ func scanPRRow(pr *PR, reviewedAt, generatingSince, createdAt sql.NullTime, html
 	if createdAt.Valid {
 		pr.CreatedAt = &createdAt.Time
 	}
+	if nextRetryAt.Valid {
+		pr.NextRetryAt = &nextRetryAt.Time
+	}
+	if closedAt.Valid {
+		pr.ClosedAt = &closedAt.Time
+	}
+	if mergedAt.Valid {
+		pr.MergedAt = &mergedAt.Time
+	}
 	pr.IsMine = isMine == 1
 	pr.Draft = draft == 1
 	if title.Valid {
@@ -158,20 +353,29 @@ func scanPRRow(pr *PR, reviewedAt, generatingSince, createdAt sql.NullTime, html
 	}
 }
 
-func (db *DB) GetPR(owner, repo string, prNumber int) (*PR, error) {
+func (db *DB) GetPR(host, owner, repo string, prNumber int) (*PR, error) {
+	return db.getPR(`WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?`, host, owner, repo, prNumber)
+}
+
+// GetPRByID returns the PR with database ID id, or nil if there is none
+func (db *DB) GetPRByID(id int) (*PR, error) {
+	return db.getPR(`WHERE id = ?`, id)
+}
+
+// getPR returns the PR picked out by filter, a WHERE clause, or nil if there is none
+func (db *DB) getPR(filter string, args ...any) (*PR, error) {
 	pr := &PR{}
 	var reviewedAt sql.NullTime
 	var htmlPath sql.NullString
 	var generatingSince sql.NullTime
-	var createdAt sql.NullTime
+	var createdAt, nextRetryAt, closedAt, mergedAt sql.NullTime
 	var isMine, draft int
 	var title, author, myReviewStatus, notes, ciState, ciFailedChecks sql.NullString
-	err := db.conn.QueryRow(`
-		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, COALESCE(status, 'pending'), generating_since, COALESCE(is_mine, 0), COALESCE(title, ''), COALESCE(author, ''), COALESCE(approval_count, 0), COALESCE(my_review_status, ''), created_at, COALESCE(draft, 0), COALESCE(notes, ''), COALESCE(ci_state, 'unknown'), COALESCE(ci_failed_checks, '[]')
-		FROM prs WHERE repo_owner = ? AND repo_name = ? AND pr_number = ?
-	`, owner, repo, prNumber).Scan(
+	err := db.queryRow(`
+		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, COALESCE(status, 'pending'), generating_since, COALESCE(is_mine, 0), COALESCE(title, ''), COALESCE(author, ''), COALESCE(approval_count, 0), COALESCE(my_review_status, ''), created_at, COALESCE(draft, 0), COALESCE(notes, ''), COALESCE(ci_state, 'unknown'), COALESCE(ci_failed_checks, '[]'), COALESCE(host, 'github.com'), COALESCE(account, ''), COALESCE(review_attempts, 0), COALESCE(last_error, ''), next_retry_at, closed_at, merged_at
+		FROM prs `+filter, args...).Scan(
 		&pr.ID, &pr.RepoOwner, &pr.RepoName, &pr.PRNumber,
-		&pr.LastCommitSHA, &reviewedAt, &htmlPath, &pr.Status, &generatingSince, &isMine, &title, &author, &pr.ApprovalCount, &myReviewStatus, &createdAt, &draft, &notes, &ciState, &ciFailedChecks,
+		&pr.LastCommitSHA, &reviewedAt, &htmlPath, &pr.Status, &generatingSince, &isMine, &title, &author, &pr.ApprovalCount, &myReviewStatus, &createdAt, &draft, &notes, &ciState, &ciFailedChecks, &pr.Host, &pr.Account, &pr.ReviewAttempts, &pr.LastError, &nextRetryAt, &closedAt, &mergedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -179,130 +383,257 @@ func (db *DB) GetPR(owner, repo string, prNumber int) (*PR, error) {
 	if err != nil {
 		return nil, err
 	}
-	scanPRRow(pr, reviewedAt, generatingSince, createdAt, htmlPath, isMine, draft, title, author, myReviewStatus, notes, ciState, ciFailedChecks)
+	scanPRRow(pr, reviewedAt, generatingSince, createdAt, nextRetryAt, closedAt, mergedAt, htmlPath, isMine, draft, title, author, myReviewStatus, notes, ciState, ciFailedChecks)
 	return pr, nil
 }
 
-func (db *DB) UpsertPR(pr *PR) error {
-	isMineInt := 0
-	if pr.IsMine {
-		isMineInt = 1
+func (db *DB) UpdatePRStatus(host, owner, repo string, prNumber int, status string) error {
+	_, err := db.exec(`
+		UPDATE prs SET status = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?
+	`, status, host, owner, repo, prNumber)
+	return err
+}
+
+// SyncPR tracks a PR the poller found, in a transaction. A new PR is inserted as pending with
+// all its fields. For a tracked, open PR only the columns the poller owns are written: account (if
+// set), is_mine and the commit, where a new commit starts again from zero review attempts. With
+// pending, the PR is also set back to pending, dropping its review if the commit changed. Title,
+// author, draft and created_at go through UpdateGitHubMetadata and UpdatePRCreatedAt, or
+// SyncPRWithMetadata, and an archived PR is only brought back by ReopenPR. Discovering and new
+// commits go in the PR's events.
+func (db *DB) SyncPR(pr *PR, pending bool) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := syncPR(tx, pr, pending); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SyncPRWithMetadata is SyncPR followed by UpdateGitHubMetadata and, if pr.CreatedAt is set,
+// UpdatePRCreatedAt, in one transaction, for PRs fresh from GitHub
+func (db *DB) SyncPRWithMetadata(pr *PR, pending bool) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tracked, err := syncPR(tx, pr, pending)
+	if err != nil {
+		return err
+	}
+	if !tracked {
+		return tx.Commit()
 	}
 	draftInt := 0
 	if pr.Draft {
 		draftInt = 1
 	}
+	var createdAt interface{}
+	if pr.CreatedAt != nil {
+		createdAt = *pr.CreatedAt
+	}
+	if _, err := tx.Exec(`
+		UPDATE prs SET title = ?, author = ?, draft = ?, created_at = COALESCE(?, created_at)
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, pr.Title, pr.Author, draftInt, createdAt, prHost(pr), pr.RepoOwner, pr.RepoName, pr.PRNumber); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	// Use the provided LastReviewedAt, or NULL if not set
-	var lastReviewedAt interface{}
-	if pr.LastReviewedAt != nil {
-		lastReviewedAt = *pr.LastReviewedAt
+// prHost returns the PR's host, defaulting to github.com
+func prHost(pr *PR) string {
+	if pr.Host == "" {
+		return "github.com"
 	}
+	return pr.Host
+}
 
-	// Use the provided CreatedAt, or NULL if not set
+// syncPR does the work of SyncPR in t. It reports whether pr was already tracked and open, so its
+// poller-owned columns were updated; it reports false for a new PR (inserted with all its fields)
+// and for an archived one (left alone).
+func syncPR(t *tx, pr *PR, pending bool) (bool, error) {
+	host := prHost(pr)
+	isMineInt := 0
+	if pr.IsMine {
+		isMineInt = 1
+	}
+	draftInt := 0
+	if pr.Draft {
+		draftInt = 1
+	}
 	var createdAt interface{}
 	if pr.CreatedAt != nil {
 		createdAt = *pr.CreatedAt
 	}
 
-	// Use NULL for generating_since in UpsertPR (it's only set via SetPRGenerating)
-	var generatingSince interface{}
-
-	// Build UPDATE clause dynamically: only update created_at if provided (not nil)
-	updateClause := `
-		last_commit_sha = ?,
-		last_reviewed_at = COALESCE(?, last_reviewed_at),
-		review_html_path = ?,
-		status = ?,
-		generating_since = NULL,
-		is_mine = ?,
-		title = ?,
-		author = ?,
-		approval_count = ?,
-		my_review_status = ?,`
-
-	updateParams := []interface{}{
-		pr.LastCommitSHA, lastReviewedAt, pr.ReviewHTMLPath, pr.Status, isMineInt, pr.Title, pr.Author, pr.ApprovalCount, pr.MyReviewStatus,
+	result, err := t.Exec(`
+		INSERT INTO prs (host, account, repo_owner, repo_name, pr_number, last_commit_sha, status, is_mine, title, author, created_at, draft)
+		VALUES (?, ?, ?, ?, ?, ?, 'pending', ?, ?, ?, ?, ?)
+		ON CONFLICT(host, repo_owner, repo_name, pr_number) DO NOTHING
+	`, host, pr.Account, pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.LastCommitSHA, isMineInt, pr.Title, pr.Author, createdAt, draftInt)
+	if err != nil {
+		return false, err
+	}
+	if inserted, _ := result.RowsAffected(); inserted > 0 {
+		eventType := EventReviewRequested
+		if pr.IsMine {
+			eventType = EventDiscovered
+		}
+		return false, recordEvent(t, host, pr.RepoOwner, pr.RepoName, pr.PRNumber, eventType, pr.LastCommitSHA, pr.Title)
 	}
 
-	// Only update created_at if we have a value (not nil), otherwise preserve database value
-	if pr.CreatedAt != nil {
-		updateClause += `
-		created_at = ?,`
-		updateParams = append(updateParams, createdAt)
+	var oldSHA string
+	if err := t.QueryRow(`
+		SELECT last_commit_sha FROM prs WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, host, pr.RepoOwner, pr.RepoName, pr.PRNumber).Scan(&oldSHA); err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
 	}
 
-	updateClause += `
-		draft = ?,
-		notes = ?,
-		ci_state = ?,
-		ci_failed_checks = ?`
-	updateParams = append(updateParams, draftInt, pr.Notes, pr.CIState, pr.CIFailedChecks)
+	setClause := `
+		review_attempts = CASE WHEN last_commit_sha = ? THEN review_attempts ELSE 0 END,
+		last_error = CASE WHEN last_commit_sha = ? THEN last_error ELSE '' END,
+		next_retry_at = CASE WHEN last_commit_sha = ? THEN next_retry_at ELSE NULL END,`
+	params := []interface{}{pr.LastCommitSHA, pr.LastCommitSHA, pr.LastCommitSHA}
+	if pending {
+		setClause += `
+		review_html_path = CASE WHEN last_commit_sha = ? THEN review_html_path ELSE NULL END,
+		status = 'pending',
+		generating_since = NULL,`
+		params = append(params, pr.LastCommitSHA)
+	}
+	setClause += `
+		last_commit_sha = ?,
+		account = COALESCE(NULLIF(?, ''), account),
+		is_mine = ?`
+	params = append(params, pr.LastCommitSHA, pr.Account, isMineInt, host, pr.RepoOwner, pr.RepoName, pr.PRNumber)
 
-	query := `
-		INSERT INTO prs (repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, status, generating_since, is_mine, title, author, approval_count, my_review_status, created_at, draft, notes, ci_state, ci_failed_checks)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(repo_owner, repo_name, pr_number)
-		DO UPDATE SET` + updateClause
+	if _, err := t.Exec(`UPDATE prs SET`+setClause+`
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL`, params...); err != nil {
+		return false, err
+	}
+	if oldSHA != pr.LastCommitSHA {
+		if err := recordEvent(t, host, pr.RepoOwner, pr.RepoName, pr.PRNumber, EventCommitPushed, pr.LastCommitSHA, "from "+shortSHA(oldSHA)); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
 
-	insertParams := []interface{}{pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.LastCommitSHA, lastReviewedAt, pr.ReviewHTMLPath, pr.Status, generatingSince, isMineInt, pr.Title, pr.Author, pr.ApprovalCount, pr.MyReviewStatus, createdAt, draftInt, pr.Notes, pr.CIState, pr.CIFailedChecks}
-	allParams := append(insertParams, updateParams...)
+// CompletePRReview marks a PR's review at commitSHA as completed, with its HTML at htmlPath. It
+// reports false if the PR is gone, archived or has moved to another commit, so a review that
+// finishes late can't overwrite a newer state.
+func (db *DB) CompletePRReview(host, owner, repo string, prNumber int, commitSHA, htmlPath string) (bool, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
 
-	_, err := db.conn.Exec(query, allParams...)
-	return err
+	result, err := tx.Exec(`
+		UPDATE prs
+		SET status = 'completed',
+		    review_html_path = ?,
+		    last_reviewed_at = ?,
+		    generating_since = NULL,
+		    review_attempts = 0,
+		    last_error = '',
+		    next_retry_at = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND last_commit_sha = ? AND closed_at IS NULL
+	`, htmlPath, time.Now().UTC(), host, owner, repo, prNumber, commitSHA)
+	if err != nil {
+		return false, err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return false, nil
+	}
+	if err := recordEvent(tx, host, owner, repo, prNumber, EventCompleted, commitSHA, htmlPath); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
-func (db *DB) UpdatePRStatus(owner, repo string, prNumber int, status string) error {
-	_, err := db.conn.Exec(`
-		UPDATE prs SET status = ? WHERE repo_owner = ? AND repo_name = ? AND pr_number = ?
-	`, status, owner, repo, prNumber)
-	return err
-}
+// ResetPRToOutdated resets a PR to pending status with new commit SHA and clears old review data.
+// Archived PRs are left alone.
+func (db *DB) ResetPRToOutdated(host, owner, repo string, prNumber int, newCommitSHA string) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldSHA string
+	err = tx.QueryRow(`
+		SELECT last_commit_sha FROM prs WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, host, owner, repo, prNumber).Scan(&oldSHA)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-// ResetPRToOutdated resets a PR to pending status with new commit SHA and clears old review data
-func (db *DB) ResetPRToOutdated(owner, repo string, prNumber int, newCommitSHA string) error {
-	_, err := db.conn.Exec(`
+	if _, err := tx.Exec(`
 		UPDATE prs
 		SET status = 'pending',
 		    last_commit_sha = ?,
 		    review_html_path = NULL,
 		    last_reviewed_at = NULL,
-		    generating_since = NULL
-		WHERE repo_owner = ? AND repo_name = ? AND pr_number = ?
-	`, newCommitSHA, owner, repo, prNumber)
-	return err
-}
-
-func (db *DB) SetPRGenerating(owner, repo string, prNumber int, commitSHA, title, author string, isMine bool, createdAt *time.Time, draft bool) error {
-	now := time.Now().UTC()
-	isMineInt := 0
-	if isMine {
-		isMineInt = 1
+		    generating_since = NULL,
+		    review_attempts = 0,
+		    last_error = '',
+		    next_retry_at = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, newCommitSHA, host, owner, repo, prNumber); err != nil {
+		return err
 	}
-	draftInt := 0
-	if draft {
-		draftInt = 1
+	if oldSHA != newCommitSHA {
+		if err := recordEvent(tx, host, owner, repo, prNumber, EventCommitPushed, newCommitSHA, "from "+shortSHA(oldSHA)); err != nil {
+			return err
+		}
 	}
+	return tx.Commit()
+}
 
-	// Use the provided CreatedAt, or NULL if not set
-	var createdAtVal interface{}
-	if createdAt != nil {
-		createdAtVal = *createdAt
+// SetPRGenerating marks a pending PR as having its review generated for commitSHA. It reports
+// false, changing nothing, unless the PR is open, pending and still at commitSHA, so a PR that was
+// cancelled, regenerated or moved to a new commit since it was queued isn't claimed.
+func (db *DB) SetPRGenerating(host, owner, repo string, prNumber int, commitSHA string) (bool, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return false, err
 	}
+	defer tx.Rollback()
 
-	_, err := db.conn.Exec(`
-		INSERT INTO prs (repo_owner, repo_name, pr_number, last_commit_sha, status, generating_since, is_mine, title, author, review_html_path, created_at, draft)
-		VALUES (?, ?, ?, ?, 'generating', ?, ?, ?, ?, NULL, ?, ?)
-		ON CONFLICT(repo_owner, repo_name, pr_number)
-		DO UPDATE SET last_commit_sha = ?, status = 'generating', generating_since = ?, is_mine = ?, title = ?, author = ?, review_html_path = NULL, created_at = ?, draft = ?
-	`, owner, repo, prNumber, commitSHA, now, isMineInt, title, author, createdAtVal, draftInt, commitSHA, now, isMineInt, title, author, createdAtVal, draftInt)
-	return err
+	result, err := tx.Exec(`
+		UPDATE prs SET status = 'generating', generating_since = ?, review_html_path = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?
+		AND status = 'pending' AND last_commit_sha = ? AND closed_at IS NULL
+	`, time.Now().UTC(), host, owner, repo, prNumber, commitSHA)
+	if err != nil {
+		return false, err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return false, nil
+	}
+	if err := recordEvent(tx, host, owner, repo, prNumber, EventGenerating, commitSHA, ""); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
+// GetAllPRs returns the open PRs, in dashboard order
 func (db *DB) GetAllPRs() ([]PR, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, COALESCE(status, 'pending'), generating_since, COALESCE(is_mine, 0), COALESCE(title, ''), COALESCE(author, ''), COALESCE(approval_count, 0), COALESCE(my_review_status, ''), created_at, COALESCE(draft, 0), COALESCE(notes, ''), COALESCE(ci_state, 'unknown'), COALESCE(ci_failed_checks, '[]')
-		FROM prs
+	return db.listPRs(`
+		WHERE closed_at IS NULL
 		ORDER BY
 			is_mine ASC,
 			created_at DESC NULLS LAST,
@@ -313,6 +644,29 @@ func (db *DB) GetAllPRs() ([]PR, error) {
 				ELSE 4
 			END
 	`)
+}
+
+// GetArchivedPRs returns the closed and merged PRs, most recently closed first
+func (db *DB) GetArchivedPRs() ([]PR, error) {
+	return db.listPRs(`
+		WHERE closed_at IS NOT NULL
+		ORDER BY closed_at DESC, id DESC
+	`)
+}
+
+// GetArchivedPRsClosedBefore returns the archived PRs closed before cutoff
+func (db *DB) GetArchivedPRsClosedBefore(cutoff time.Time) ([]PR, error) {
+	return db.listPRs(`
+		WHERE closed_at IS NOT NULL AND closed_at < ?
+		ORDER BY closed_at ASC
+	`, cutoff.UTC())
+}
+
+// listPRs returns the PRs picked out and ordered by filter, a WHERE and ORDER BY clause
+func (db *DB) listPRs(filter string, args ...any) ([]PR, error) {
+	rows, err := db.query(`
+		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, COALESCE(status, 'pending'), generating_since, COALESCE(is_mine, 0), COALESCE(title, ''), COALESCE(author, ''), COALESCE(approval_count, 0), COALESCE(my_review_status, ''), created_at, COALESCE(draft, 0), COALESCE(notes, ''), COALESCE(ci_state, 'unknown'), COALESCE(ci_failed_checks, '[]'), COALESCE(host, 'github.com'), COALESCE(account, ''), COALESCE(review_attempts, 0), COALESCE(last_error, ''), next_retry_at, closed_at, merged_at
+		FROM prs`+filter, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -324,51 +678,164 @@ func (db *DB) GetAllPRs() ([]PR, error) {
 		var reviewedAt sql.NullTime
 		var htmlPath sql.NullString
 		var generatingSince sql.NullTime
-		var createdAt sql.NullTime
+		var createdAt, nextRetryAt, closedAt, mergedAt sql.NullTime
 		var isMine, draft int
 		var title, author, myReviewStatus, notes, ciState, ciFailedChecks sql.NullString
 		if err := rows.Scan(&pr.ID, &pr.RepoOwner, &pr.RepoName, &pr.PRNumber,
-			&pr.LastCommitSHA, &reviewedAt, &htmlPath, &pr.Status, &generatingSince, &isMine, &title, &author, &pr.ApprovalCount, &myReviewStatus, &createdAt, &draft, &notes, &ciState, &ciFailedChecks); err != nil {
+			&pr.LastCommitSHA, &reviewedAt, &htmlPath, &pr.Status, &generatingSince, &isMine, &title, &author, &pr.ApprovalCount, &myReviewStatus, &createdAt, &draft, &notes, &ciState, &ciFailedChecks, &pr.Host, &pr.Account, &pr.ReviewAttempts, &pr.LastError, &nextRetryAt, &closedAt, &mergedAt); err != nil {
 			return nil, err
 		}
-		scanPRRow(&pr, reviewedAt, generatingSince, createdAt, htmlPath, isMine, draft, title, author, myReviewStatus, notes, ciState, ciFailedChecks)
+		scanPRRow(&pr, reviewedAt, generatingSince, createdAt, nextRetryAt, closedAt, mergedAt, htmlPath, isMine, draft, title, author, myReviewStatus, notes, ciState, ciFailedChecks)
 		prs = append(prs, pr)
 	}
 	return prs, rows.Err()
 }
 
-func (db *DB) DeletePR(owner, repo string, prNumber int) error {
-	_, err := db.conn.Exec(`
-		DELETE FROM prs WHERE repo_owner = ? AND repo_name = ? AND pr_number = ?
-	`, owner, repo, prNumber)
-	return err
+// DeletePR deletes a PR, its review history and its events
+func (db *DB) DeletePR(host, owner, repo string, prNumber int) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"reviews", "pr_events"} {
+		if _, err := tx.Exec(`
+			DELETE FROM `+table+` WHERE pr_id IN (SELECT id FROM prs WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?)
+		`, host, owner, repo, prNumber); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM prs WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?
+	`, host, owner, repo, prNumber); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (db *DB) ResetStaleGeneratingPRs(timeoutMinutes int) (int, error) {
-	cutoff := time.Now().UTC().Add(-time.Duration(timeoutMinutes) * time.Minute)
-	result, err := db.conn.Exec(`
+// ArchivePR moves a closed or merged PR out of the open list, keeping its review and history.
+// mergedAt is nil if the PR was closed without merging. A review that was generating goes back to
+// pending, to be picked up again if the PR is reopened. It reports false if the PR isn't tracked or
+// is already archived.
+func (db *DB) ArchivePR(host, owner, repo string, prNumber int, closedAt time.Time, mergedAt *time.Time) (bool, error) {
+	var mergedAtVal interface{}
+	detail := "closed without merging"
+	if mergedAt != nil {
+		mergedAtVal = mergedAt.UTC()
+		detail = "merged"
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE prs
+		SET closed_at = ?,
+		    merged_at = ?,
+		    status = CASE WHEN status = 'generating' THEN 'pending' ELSE status END,
+		    generating_since = NULL,
+		    next_retry_at = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, closedAt.UTC(), mergedAtVal, host, owner, repo, prNumber)
+	if err != nil {
+		return false, err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return false, nil
+	}
+	if err := recordEvent(tx, host, owner, repo, prNumber, EventClosed, "", detail); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ReopenPR brings an archived PR back into the open list, after GitHub reports it open again. It
+// reports false if the PR isn't tracked or isn't archived.
+func (db *DB) ReopenPR(host, owner, repo string, prNumber int) (bool, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE prs SET closed_at = NULL, merged_at = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NOT NULL
+	`, host, owner, repo, prNumber)
+	if err != nil {
+		return false, err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return false, nil
+	}
+	if err := recordEvent(tx, host, owner, repo, prNumber, EventReopened, "", ""); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ResetGeneratingPR resets a PR from "generating" back to "pending". It reports false if the PR
+// wasn't generating, e.g. because its review finished in the meantime.
+func (db *DB) ResetGeneratingPR(host, owner, repo string, prNumber int) (bool, error) {
+	result, err := db.exec(`
 		UPDATE prs
 		SET status = 'pending', generating_since = NULL
-		WHERE status = 'generating'
-		AND (generating_since IS NULL OR generating_since < ?)
-	`, cutoff)
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND status = 'generating'
+	`, host, owner, repo, prNumber)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 	count, _ := result.RowsAffected()
-	return int(count), nil
+	return count > 0, nil
+}
+
+// RecordReviewFailure marks a PR's review at commitSHA as errored after a failed attempt.
+// status is "error" with nextRetryAt set when it will be retried, or "failed" with nextRetryAt nil
+// when it has run out of attempts. Nothing is updated if the PR is archived or has moved to another
+// commit.
+func (db *DB) RecordReviewFailure(host, owner, repo string, prNumber int, commitSHA, status, lastError string, attempts int, nextRetryAt *time.Time) error {
+	var retryAt interface{}
+	detail := fmt.Sprintf("attempt %d, giving up: %s", attempts, lastError)
+	if nextRetryAt != nil {
+		retryAt = nextRetryAt.UTC()
+		detail = fmt.Sprintf("attempt %d: %s", attempts, lastError)
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE prs
+		SET status = ?, review_attempts = ?, last_error = ?, next_retry_at = ?, generating_since = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND last_commit_sha = ? AND closed_at IS NULL
+	`, status, attempts, lastError, retryAt, host, owner, repo, prNumber, commitSHA)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return nil
+	}
+	if err := recordEvent(tx, host, owner, repo, prNumber, EventError, commitSHA, detail); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (db *DB) ResetErrorPRs(maxAgeMinutes int) (int, error) {
-	// Reset PRs that have been in error state for more than maxAgeMinutes
-	// This allows them to be retried on the next poll
-	cutoff := time.Now().UTC().Add(-time.Duration(maxAgeMinutes) * time.Minute)
-	result, err := db.conn.Exec(`
+// ResetDueErrorPRs resets errored PRs whose retry time has come to pending so they are reviewed
+// again. PRs that have "failed" are left alone until a new commit or a manual retry.
+func (db *DB) ResetDueErrorPRs(now time.Time) (int, error) {
+	result, err := db.exec(`
 		UPDATE prs
 		SET status = 'pending'
 		WHERE status = 'error'
-		AND (last_reviewed_at IS NULL OR last_reviewed_at < ?)
-	`, cutoff)
+		AND (next_retry_at IS NULL OR next_retry_at <= ?)
+		AND closed_at IS NULL
+	`, now.UTC())
 	if err != nil {
 		return 0, err
 	}
@@ -376,16 +843,59 @@ func (db *DB) ResetErrorPRs(maxAgeMinutes int) (int, error) {
 	return int(count), nil
 }
 
+// CancelPRReview marks a PR's queued or generating review as cancelled. It reports false if no
+// review was queued or generating, or the PR is archived.
+func (db *DB) CancelPRReview(host, owner, repo string, prNumber int) (bool, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE prs SET status = 'cancelled', generating_since = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND status IN ('pending', 'generating') AND closed_at IS NULL
+	`, host, owner, repo, prNumber)
+	if err != nil {
+		return false, err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return false, nil
+	}
+	if err := recordEvent(tx, host, owner, repo, prNumber, EventCancelled, "", ""); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RetryPRNow resets an errored or failed PR to pending without waiting for its next retry. It
+// reports false if the PR wasn't errored or failed, or is archived.
+func (db *DB) RetryPRNow(host, owner, repo string, prNumber int) (bool, error) {
+	result, err := db.exec(`
+		UPDATE prs SET status = 'pending', next_retry_at = NULL
+		WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND status IN ('error', 'failed') AND closed_at IS NULL
+	`, host, owner, repo, prNumber)
+	if err != nil {
+		return false, err
+	}
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
+
 func (db *DB) Close() error {
+	if db.writer != db.conn {
+		db.writer.Close()
+	}
 	return db.conn.Close()
 }
 
-// GetPRsWithMissingMetadata returns PRs that don't have title or author set
+// GetPRsWithMissingMetadata returns open PRs that don't have title or author set
 func (db *DB) GetPRsWithMissingMetadata() ([]PR, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, COALESCE(status, 'pending'), generating_since, COALESCE(is_mine, 0), COALESCE(title, ''), COALESCE(author, '')
+	rows, err := db.query(`
+		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, COALESCE(status, 'pending'), generating_since, COALESCE(is_mine, 0), COALESCE(title, ''), COALESCE(author, ''), COALESCE(host, 'github.com'), COALESCE(account, '')
 		FROM prs
-		WHERE (title IS NULL OR title = '') OR (author IS NULL OR author = '')
+		WHERE ((title IS NULL OR title = '') OR (author IS NULL OR author = ''))
+		AND closed_at IS NULL
 	`)
 	if err != nil {
 		return nil, err
@@ -401,7 +911,7 @@ func (db *DB) GetPRsWithMissingMetadata() ([]PR, error) {
 		var isMine int
 		var title, author sql.NullString
 		if err := rows.Scan(&pr.ID, &pr.RepoOwner, &pr.RepoName, &pr.PRNumber,
-			&pr.LastCommitSHA, &reviewedAt, &htmlPath, &pr.Status, &generatingSince, &isMine, &title, &author); err != nil {
+			&pr.LastCommitSHA, &reviewedAt, &htmlPath, &pr.Status, &generatingSince, &isMine, &title, &author, &pr.Host, &pr.Account); err != nil {
 			return nil, err
 		}
 		if reviewedAt.Valid {
@@ -426,31 +936,123 @@ func (db *DB) GetPRsWithMissingMetadata() ([]PR, error) {
 }
 
 // UpdatePRMetadata updates only the title and author for a PR
-func (db *DB) UpdatePRMetadata(owner, repo string, prNumber int, title, author string) error {
-	_, err := db.conn.Exec(`
-		UPDATE prs SET title = ?, author = ? WHERE repo_owner = ? AND repo_name = ? AND pr_number = ?
-	`, title, author, owner, repo, prNumber)
+func (db *DB) UpdatePRMetadata(host, owner, repo string, prNumber int, title, author string) error {
+	_, err := db.exec(`
+		UPDATE prs SET title = ?, author = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?
+	`, title, author, host, owner, repo, prNumber)
 	return err
 }
 
 // UpdatePRNotes updates only the notes field for a PR
-func (db *DB) UpdatePRNotes(owner, repo string, prNumber int, notes string) error {
+func (db *DB) UpdatePRNotes(host, owner, repo string, prNumber int, notes string) error {
 	// Truncate to 15 chars as defensive measure
 	if len(notes) > 15 {
 		notes = notes[:15]
 	}
-	_, err := db.conn.Exec(`
-		UPDATE prs SET notes = ? WHERE repo_owner = ? AND repo_name = ? AND pr_number = ?
-	`, notes, owner, repo, prNumber)
+	_, err := db.exec(`
+		UPDATE prs SET notes = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?
+	`, notes, host, owner, repo, prNumber)
+	return err
+}
+
+// UpdatePRDraft updates only the draft flag for an open PR
+func (db *DB) UpdatePRDraft(host, owner, repo string, prNumber int, draft bool) error {
+	draftInt := 0
+	if draft {
+		draftInt = 1
+	}
+	_, err := db.exec(`
+		UPDATE prs SET draft = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, draftInt, host, owner, repo, prNumber)
+	return err
+}
+
+// UpdateGitHubMetadata updates only the title, author and draft flag for an open PR
+func (db *DB) UpdateGitHubMetadata(host, owner, repo string, prNumber int, title, author string, draft bool) error {
+	draftInt := 0
+	if draft {
+		draftInt = 1
+	}
+	_, err := db.exec(`
+		UPDATE prs SET title = ?, author = ?, draft = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, title, author, draftInt, host, owner, repo, prNumber)
 	return err
 }
 
-// GetPRsWithMissingCreatedAt returns PRs that don't have created_at set
+// UpdateReviewData updates only the approval count and my review status for an open PR. A rise in
+// approvals goes in the PR's events.
+func (db *DB) UpdateReviewData(host, owner, repo string, prNumber int, approvalCount int, myReviewStatus string) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldCount int
+	var sha string
+	err = tx.QueryRow(`
+		SELECT COALESCE(approval_count, 0), last_commit_sha FROM prs WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, host, owner, repo, prNumber).Scan(&oldCount, &sha)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE prs SET approval_count = ?, my_review_status = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, approvalCount, myReviewStatus, host, owner, repo, prNumber); err != nil {
+		return err
+	}
+	if approvalCount > oldCount {
+		if err := recordEvent(tx, host, owner, repo, prNumber, EventApproved, sha, approvalsDetail(approvalCount)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateCIStatus updates only the CI state and failed checks (JSON array) for an open PR. A change
+// of state goes in the PR's events.
+func (db *DB) UpdateCIStatus(host, owner, repo string, prNumber int, state, failedChecks string) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldState, sha string
+	err = tx.QueryRow(`
+		SELECT COALESCE(ci_state, 'unknown'), last_commit_sha FROM prs WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, host, owner, repo, prNumber).Scan(&oldState, &sha)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE prs SET ci_state = ?, ci_failed_checks = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, state, failedChecks, host, owner, repo, prNumber); err != nil {
+		return err
+	}
+	if state != oldState {
+		if err := recordEvent(tx, host, owner, repo, prNumber, EventCIChanged, sha, oldState+" → "+state); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPRsWithMissingCreatedAt returns open PRs that don't have created_at set
 func (db *DB) GetPRsWithMissingCreatedAt() ([]PR, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha
+	rows, err := db.query(`
+		SELECT id, repo_owner, repo_name, pr_number, last_commit_sha, COALESCE(host, 'github.com'), COALESCE(account, '')
 		FROM prs
 		WHERE created_at IS NULL
+		AND closed_at IS NULL
 	`)
 	if err != nil {
 		return nil, err
@@ -460,7 +1062,7 @@ func (db *DB) GetPRsWithMissingCreatedAt() ([]PR, error) {
 	var prs []PR
 	for rows.Next() {
 		pr := PR{}
-		if err := rows.Scan(&pr.ID, &pr.RepoOwner, &pr.RepoName, &pr.PRNumber, &pr.LastCommitSHA); err != nil {
+		if err := rows.Scan(&pr.ID, &pr.RepoOwner, &pr.RepoName, &pr.PRNumber, &pr.LastCommitSHA, &pr.Host, &pr.Account); err != nil {
 			return nil, err
 		}
 		prs = append(prs, pr)
@@ -468,10 +1070,10 @@ func (db *DB) GetPRsWithMissingCreatedAt() ([]PR, error) {
 	return prs, rows.Err()
 }
 
-// UpdatePRCreatedAt updates only the created_at field for a PR
-func (db *DB) UpdatePRCreatedAt(owner, repo string, prNumber int, createdAt time.Time) error {
-	_, err := db.conn.Exec(`
-		UPDATE prs SET created_at = ? WHERE repo_owner = ? AND repo_name = ? AND pr_number = ?
-	`, createdAt, owner, repo, prNumber)
+// UpdatePRCreatedAt updates only the created_at field for an open PR
+func (db *DB) UpdatePRCreatedAt(host, owner, repo string, prNumber int, createdAt time.Time) error {
+	_, err := db.exec(`
+		UPDATE prs SET created_at = ? WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ? AND closed_at IS NULL
+	`, createdAt, host, owner, repo, prNumber)
 	return err
 }
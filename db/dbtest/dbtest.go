@@ -0,0 +1,55 @@
+// Package dbtest seeds a db.Store for tests through the same calls the poller and server make, so
+// fixtures go through SyncPRWithMetadata and the field-level setters instead of writing rows directly.
+package dbtest
+
+import (
+	"testing"
+
+	"pr-review-server/db"
+)
+
+// AddPR tracks pr and brings it to pr.Status, then sets its notes, review data and CI state if
+// given. The host defaults to github.com. Completing a review stamps last_reviewed_at with the
+// current time, so pr.LastReviewedAt is ignored.
+func AddPR(t testing.TB, s db.Store, pr db.PR) {
+	t.Helper()
+	if pr.Host == "" {
+		pr.Host = "github.com"
+	}
+	host, owner, repo, number, sha := pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.LastCommitSHA
+
+	must := func(what string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Failed to seed %s/%s#%d: %s: %v", owner, repo, number, what, err)
+		}
+	}
+	must("SyncPRWithMetadata", s.SyncPRWithMetadata(&pr, true))
+
+	switch pr.Status {
+	case "", "pending":
+	case "generating":
+		_, err := s.SetPRGenerating(host, owner, repo, number, sha)
+		must("SetPRGenerating", err)
+	case "completed":
+		_, err := s.CompletePRReview(host, owner, repo, number, sha, pr.ReviewHTMLPath)
+		must("CompletePRReview", err)
+	case "error", "failed":
+		must("RecordReviewFailure", s.RecordReviewFailure(host, owner, repo, number, sha, pr.Status, pr.LastError, pr.ReviewAttempts, pr.NextRetryAt))
+	case "cancelled":
+		_, err := s.CancelPRReview(host, owner, repo, number)
+		must("CancelPRReview", err)
+	default:
+		must("UpdatePRStatus", s.UpdatePRStatus(host, owner, repo, number, pr.Status))
+	}
+
+	if pr.Notes != "" {
+		must("UpdatePRNotes", s.UpdatePRNotes(host, owner, repo, number, pr.Notes))
+	}
+	if pr.ApprovalCount != 0 || pr.MyReviewStatus != "" {
+		must("UpdateReviewData", s.UpdateReviewData(host, owner, repo, number, pr.ApprovalCount, pr.MyReviewStatus))
+	}
+	if pr.CIState != "" {
+		must("UpdateCIStatus", s.UpdateCIStatus(host, owner, repo, number, pr.CIState, pr.CIFailedChecks))
+	}
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event types in a PR's timeline
+const (
+	EventDiscovered      = "discovered"       // Started tracking one of my PRs
+	EventReviewRequested = "review_requested" // Started tracking a PR requesting my review
+	EventCommitPushed    = "commit_pushed"    // Moved to a new head commit; Detail has the previous one
+	EventGenerating      = "generating"       // Review generation started
+	EventCompleted       = "completed"        // Review generated; Detail is the review's HTML file
+	EventError           = "error"            // Review generation failed; Detail says why
+	EventCancelled       = "cancelled"        // Queued or running review cancelled
+	EventCIChanged       = "ci_changed"       // CI state changed; Detail is "old → new"
+	EventApproved        = "approved"         // Approvals went up; Detail is the new count
+	EventClosed          = "closed"           // Closed or merged on GitHub and archived
+	EventReopened        = "reopened"         // Reopened after being archived
+)
+
+// Event is one state transition of a PR
+type Event struct {
+	ID        int
+	PRID      int
+	Host      string
+	RepoOwner string
+	RepoName  string
+	PRNumber  int
+	Type      string // One of the Event* types
+	CommitSHA string // Head commit when it happened, empty if not known
+	Detail    string
+	CreatedAt time.Time
+}
+
+// recordEvent adds an event to a PR's timeline, in the transaction making the transition. Nothing is
+// recorded if the PR isn't tracked.
+func recordEvent(t *tx, host, owner, repo string, prNumber int, eventType, commitSHA, detail string) error {
+	_, err := t.Exec(`
+		INSERT INTO pr_events (pr_id, event_type, commit_sha, detail, created_at)
+		SELECT id, ?, ?, ?, ? FROM prs WHERE host = ? AND repo_owner = ? AND repo_name = ? AND pr_number = ?
+	`, eventType, commitSHA, detail, time.Now().UTC(), host, owner, repo, prNumber)
+	return err
+}
+
+// GetPREvents returns a PR's timeline, oldest first
+func (db *DB) GetPREvents(prID int) ([]Event, error) {
+	return db.listEvents(`
+		WHERE e.pr_id = ?
+		ORDER BY e.created_at ASC, e.id ASC
+	`, prID)
+}
+
+// GetEventsSince returns up to limit events of every PR recorded at or after since, oldest first.
+// Events at since itself are only returned if their ID is above afterID, so paging from the last
+// event's time and ID neither repeats it nor skips others recorded at the same time.
+func (db *DB) GetEventsSince(since time.Time, afterID int, limit int) ([]Event, error) {
+	return db.listEvents(`
+		WHERE e.created_at > ? OR (e.created_at = ? AND e.id > ?)
+		ORDER BY e.created_at ASC, e.id ASC
+		LIMIT ?
+	`, since.UTC(), since.UTC(), afterID, limit)
+}
+
+// listEvents returns the events picked out and ordered by filter, with the PR each belongs to
+func (db *DB) listEvents(filter string, args ...any) ([]Event, error) {
+	rows, err := db.query(`
+		SELECT e.id, e.pr_id, COALESCE(p.host, 'github.com'), p.repo_owner, p.repo_name, p.pr_number, e.event_type, COALESCE(e.commit_sha, ''), COALESCE(e.detail, ''), e.created_at
+		FROM pr_events e JOIN prs p ON p.id = e.pr_id`+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.PRID, &e.Host, &e.RepoOwner, &e.RepoName, &e.PRNumber, &e.Type, &e.CommitSHA, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// shortSHA abbreviates a commit SHA for event details
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// approvalsDetail describes an approval count for an EventApproved
+func approvalsDetail(count int) string {
+	if count == 1 {
+		return "1 approval"
+	}
+	return fmt.Sprintf("%d approvals", count)
+}
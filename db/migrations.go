@@ -0,0 +1,303 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// migration is one numbered schema change. Each runs in a transaction together with its
+// schema_migrations row, so a failed migration leaves the database at the previous version.
+type migration struct {
+	version int
+	name    string
+	up      []string
+	down    []string // Reverts up; nil if the migration can't be reverted
+}
+
+// migrations is the schema history, in order. Never edit or renumber a released migration; add a
+// new one instead.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "baseline",
+		up: []string{
+			`CREATE TABLE prs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				host TEXT NOT NULL DEFAULT 'github.com',
+				account TEXT DEFAULT '',
+				repo_owner TEXT NOT NULL,
+				repo_name TEXT NOT NULL,
+				pr_number INTEGER NOT NULL,
+				last_commit_sha TEXT NOT NULL,
+				last_reviewed_at TIMESTAMP,
+				review_html_path TEXT,
+				status TEXT DEFAULT 'pending',
+				generating_since TIMESTAMP,
+				is_mine INTEGER DEFAULT 0,
+				title TEXT DEFAULT '',
+				author TEXT DEFAULT '',
+				approval_count INTEGER DEFAULT 0,
+				my_review_status TEXT DEFAULT '',
+				created_at TIMESTAMP,
+				draft INTEGER DEFAULT 0,
+				notes TEXT DEFAULT '',
+				ci_state TEXT DEFAULT 'unknown',
+				ci_failed_checks TEXT DEFAULT '[]',
+				review_attempts INTEGER DEFAULT 0,
+				last_error TEXT DEFAULT '',
+				next_retry_at TIMESTAMP,
+				UNIQUE(host, repo_owner, repo_name, pr_number)
+			)`,
+			`CREATE TABLE reviews (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				pr_id INTEGER NOT NULL REFERENCES prs(id),
+				commit_sha TEXT NOT NULL,
+				generator TEXT DEFAULT '',
+				started_at TIMESTAMP NOT NULL,
+				finished_at TIMESTAMP,
+				status TEXT NOT NULL DEFAULT 'generating',
+				artifact_path TEXT DEFAULT '',
+				stderr_excerpt TEXT DEFAULT '',
+				exit_code INTEGER,
+				log_path TEXT DEFAULT ''
+			)`,
+			`CREATE INDEX idx_reviews_pr_id ON reviews(pr_id)`,
+		},
+		// Reverting the baseline would mean dropping every table
+	},
+	{
+		version: 2,
+		name:    "index status and is_mine",
+		up: []string{
+			`CREATE INDEX idx_prs_status ON prs(status)`,
+			`CREATE INDEX idx_prs_is_mine ON prs(is_mine)`,
+		},
+		down: []string{
+			`DROP INDEX idx_prs_is_mine`,
+			`DROP INDEX idx_prs_status`,
+		},
+	},
+	{
+		version: 3,
+		name:    "archive closed PRs",
+		up: []string{
+			`ALTER TABLE prs ADD COLUMN closed_at TIMESTAMP`,
+			`ALTER TABLE prs ADD COLUMN merged_at TIMESTAMP`,
+			`CREATE INDEX idx_prs_closed_at ON prs(closed_at)`,
+		},
+		down: []string{
+			`DROP INDEX idx_prs_closed_at`,
+			`ALTER TABLE prs DROP COLUMN merged_at`,
+			`ALTER TABLE prs DROP COLUMN closed_at`,
+		},
+	},
+	{
+		version: 4,
+		name:    "pr events",
+		up: []string{
+			`CREATE TABLE pr_events (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				pr_id INTEGER NOT NULL REFERENCES prs(id),
+				event_type TEXT NOT NULL,
+				commit_sha TEXT DEFAULT '',
+				detail TEXT DEFAULT '',
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX idx_pr_events_pr_id ON pr_events(pr_id)`,
+			`CREATE INDEX idx_pr_events_created_at ON pr_events(created_at)`,
+		},
+		down: []string{
+			`DROP TABLE pr_events`,
+		},
+	},
+}
+
+// LatestSchemaVersion is the schema version this build migrates databases to
+var LatestSchemaVersion = migrations[len(migrations)-1].version
+
+// MigrationStatus describes one migration and whether it has been applied
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	AppliedAt  *time.Time // nil if not applied
+	Reversible bool       // Has a down migration
+}
+
+// SchemaVersion returns the version of the latest migration applied, 0 for an empty or unversioned
+// database
+func (db *DB) SchemaVersion() (int, error) {
+	if versioned, err := db.hasTable("schema_migrations"); err != nil || !versioned {
+		return 0, err
+	}
+	var version int
+	err := db.queryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// MigrationStatus lists every known migration with when it was applied
+func (db *DB) MigrationStatus() ([]MigrationStatus, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.version, Name: m.name, Reversible: m.down != nil}
+		if appliedAt, ok := applied[m.version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// appliedMigrations returns when each applied migration ran, by version
+func (db *DB) appliedMigrations() (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+	if versioned, err := db.hasTable("schema_migrations"); err != nil || !versioned {
+		return applied, err
+	}
+	rows, err := db.query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// MigrateTo applies or reverts migrations until the schema is at version target, returning how many
+// ran. Databases from before schema versioning are first brought up to the baseline.
+func (db *DB) MigrateTo(target int) (int, error) {
+	return db.migrateTo(migrations, target)
+}
+
+func (db *DB) migrateTo(history []migration, target int) (int, error) {
+	if target < 0 || target > history[len(history)-1].version {
+		return 0, fmt.Errorf("unknown schema version %d (latest is %d)", target, history[len(history)-1].version)
+	}
+	ran := 0
+	if adopted, err := db.adoptLegacySchema(history); err != nil {
+		return 0, err
+	} else if adopted {
+		ran++
+	}
+	if err := db.ensureMigrationsTable(); err != nil {
+		return 0, err
+	}
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+	if current > history[len(history)-1].version {
+		return 0, fmt.Errorf("database schema version %d is newer than this build supports (%d)", current, history[len(history)-1].version)
+	}
+
+	for _, m := range history {
+		if m.version > current && m.version <= target {
+			if err := db.runMigration(m.version, m.name, m.up, true); err != nil {
+				return ran, err
+			}
+			ran++
+		}
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		m := history[i]
+		if m.version <= current && m.version > target {
+			if m.down == nil {
+				return ran, fmt.Errorf("migration %d (%s) can't be reverted", m.version, m.name)
+			}
+			if err := db.runMigration(m.version, m.name, m.down, false); err != nil {
+				return ran, err
+			}
+			ran++
+		}
+	}
+	return ran, nil
+}
+
+// runMigration runs one migration's statements and records (up) or forgets (down) its version
+func (db *DB) runMigration(version int, name string, statements []string, up bool) error {
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", version, err)
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(db.ddl(stmt)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s) %s failed: %w\nSQL: %s", version, name, direction, err, stmt)
+		}
+	}
+	if up {
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`, version, name, time.Now().UTC())
+	} else {
+		_, err = tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %d: %w", version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", version, err)
+	}
+	return nil
+}
+
+func (db *DB) ensureMigrationsTable() error {
+	_, err := db.exec(db.ddl(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`))
+	return err
+}
+
+// adoptLegacySchema records the baseline as applied for a database that has tables but no
+// schema_migrations, after upgrading it to the baseline schema. It reports whether it did.
+// PostgreSQL support came after schema versioning, so there are no legacy PostgreSQL databases.
+func (db *DB) adoptLegacySchema(history []migration) (bool, error) {
+	if db.postgres {
+		return false, nil
+	}
+	versioned, err := db.hasTable("schema_migrations")
+	if err != nil || versioned {
+		return false, err
+	}
+	legacy, err := db.hasTable("prs")
+	if err != nil || !legacy {
+		return false, err
+	}
+
+	if err := db.upgradeLegacySchema(); err != nil {
+		return false, fmt.Errorf("failed to upgrade unversioned database: %w", err)
+	}
+	if err := db.ensureMigrationsTable(); err != nil {
+		return false, err
+	}
+	baseline := history[0]
+	return true, db.runMigration(baseline.version, baseline.name, nil, true)
+}
+
+func (db *DB) hasTable(name string) (bool, error) {
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
+	if db.postgres {
+		query = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?`
+	}
+	var count int
+	err := db.queryRow(query, name).Scan(&count)
+	return count > 0, err
+}
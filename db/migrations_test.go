@@ -0,0 +1,199 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// openFixture creates a database from a SQL script in testdata, without migrating it
+func openFixture(t *testing.T, script string) (*DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.db")
+	sqlText, err := os.ReadFile(filepath.Join("testdata", script))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to create fixture database: %v", err)
+	}
+	if _, err := conn.Exec(string(sqlText)); err != nil {
+		t.Fatalf("Failed to load fixture %s: %v", script, err)
+	}
+	conn.Close()
+
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open fixture database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database, path
+}
+
+// schemaOf describes every table's columns and the indexes, for comparing schemas built different ways
+func schemaOf(t *testing.T, database *DB) map[string][]string {
+	t.Helper()
+	rows, err := database.conn.Query(`SELECT type, name FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' AND name != 'schema_migrations' ORDER BY name`)
+	if err != nil {
+		t.Fatalf("Failed to list tables: %v", err)
+	}
+	var tables []string
+	schema := make(map[string][]string)
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			t.Fatalf("Failed to scan table: %v", err)
+		}
+		if kind == "table" {
+			tables = append(tables, name)
+		} else {
+			schema[kind+" "+name] = nil
+		}
+	}
+	rows.Close()
+
+	for _, table := range tables {
+		cols, err := database.conn.Query(fmt.Sprintf(`SELECT name, type, "notnull", COALESCE(dflt_value, ''), pk FROM pragma_table_info('%s') ORDER BY name`, table))
+		if err != nil {
+			t.Fatalf("Failed to read columns of %s: %v", table, err)
+		}
+		for cols.Next() {
+			var name, colType, dflt string
+			var notNull, pk int
+			if err := cols.Scan(&name, &colType, &notNull, &dflt, &pk); err != nil {
+				t.Fatalf("Failed to scan column: %v", err)
+			}
+			schema[table] = append(schema[table], fmt.Sprintf("%s %s notnull=%d default=%s pk=%d", name, colType, notNull, dflt, pk))
+		}
+		cols.Close()
+	}
+	return schema
+}
+
+// TestMigrate_LegacyFixtures tests that databases from before schema versioning are migrated to
+// the same schema as a new database, keeping their data
+func TestMigrate_LegacyFixtures(t *testing.T) {
+	fresh, err := New(filepath.Join(t.TempDir(), "fresh.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer fresh.Close()
+	want := schemaOf(t, fresh)
+
+	tests := []struct {
+		fixture string
+		check   func(t *testing.T, database *DB)
+	}{
+		{"legacy_v0.sql", func(t *testing.T, database *DB) {
+			pr, err := database.GetPR("github.com", "acme", "api", 1)
+			if err != nil || pr == nil {
+				t.Fatalf("Expected PR 1 to survive migration: %v", err)
+			}
+			if pr.Status != "completed" || pr.ReviewHTMLPath != "acme_api_1.html" || pr.LastReviewedAt == nil || pr.CIState != "unknown" {
+				t.Errorf("Unexpected PR after migration: %+v", pr)
+			}
+			if pr, _ := database.GetPR("github.com", "acme", "web", 2); pr == nil || pr.Status != "error" {
+				t.Errorf("Expected errored PR 2 to survive migration, got %+v", pr)
+			}
+		}},
+		{"legacy_notes.sql", func(t *testing.T, database *DB) {
+			pr, err := database.GetPR("github.com", "acme", "api", 7)
+			if err != nil || pr == nil {
+				t.Fatalf("Expected PR 7 to survive migration: %v", err)
+			}
+			if pr.Notes != "ship it" || !pr.IsMine || pr.ApprovalCount != 2 || pr.Title != "Fix login" {
+				t.Errorf("Unexpected PR after migration: %+v", pr)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			database, path := openFixture(t, tt.fixture)
+			if version, _ := database.SchemaVersion(); version != 0 {
+				t.Fatalf("Expected unversioned fixture, got version %d", version)
+			}
+			if _, err := database.MigrateTo(LatestSchemaVersion); err != nil {
+				t.Fatalf("Migration failed: %v", err)
+			}
+			if version, _ := database.SchemaVersion(); version != LatestSchemaVersion {
+				t.Errorf("Expected schema version %d, got %d", LatestSchemaVersion, version)
+			}
+			if got := schemaOf(t, database); !reflect.DeepEqual(got, want) {
+				t.Errorf("Migrated schema differs from a new database:\ngot  %v\nwant %v", got, want)
+			}
+			tt.check(t, database)
+
+			// Opening again is a no-op
+			database.Close()
+			reopened, err := New(path)
+			if err != nil {
+				t.Fatalf("Failed to reopen migrated database: %v", err)
+			}
+			reopened.Close()
+		})
+	}
+}
+
+// TestMigrateTo_UpAndDown tests applying and reverting migrations one version at a time
+func TestMigrateTo_UpAndDown(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	history := []migration{
+		{version: 1, name: "baseline", up: []string{`CREATE TABLE prs (id INTEGER PRIMARY KEY, title TEXT)`}},
+		{version: 2, name: "add notes", up: []string{`ALTER TABLE prs ADD COLUMN notes TEXT DEFAULT ''`}, down: []string{`ALTER TABLE prs DROP COLUMN notes`}},
+		{version: 3, name: "index title", up: []string{`CREATE INDEX idx_prs_title ON prs(title)`}, down: []string{`DROP INDEX idx_prs_title`}},
+	}
+	version := func() int {
+		t.Helper()
+		v, err := database.SchemaVersion()
+		if err != nil {
+			t.Fatalf("SchemaVersion failed: %v", err)
+		}
+		return v
+	}
+
+	if ran, err := database.migrateTo(history, 3); err != nil || ran != 3 || version() != 3 {
+		t.Fatalf("Expected 3 migrations up to version 3, got %d at %d (%v)", ran, version(), err)
+	}
+	if _, err := database.conn.Exec(`INSERT INTO prs (title, notes) VALUES ('Fix', 'later')`); err != nil {
+		t.Fatalf("Expected notes column after migrating up: %v", err)
+	}
+
+	if ran, err := database.migrateTo(history, 1); err != nil || ran != 2 || version() != 1 {
+		t.Fatalf("Expected 2 migrations down to version 1, got %d at %d (%v)", ran, version(), err)
+	}
+	if _, err := database.conn.Exec(`SELECT notes FROM prs`); err == nil {
+		t.Error("Expected notes column to be dropped")
+	}
+	var title string
+	if err := database.conn.QueryRow(`SELECT title FROM prs`).Scan(&title); err != nil || title != "Fix" {
+		t.Errorf("Expected data to survive migrating down, got %q (%v)", title, err)
+	}
+
+	if _, err := database.migrateTo(history, 0); err == nil || version() != 1 {
+		t.Errorf("Expected the baseline not to be reverted, got version %d (%v)", version(), err)
+	}
+	if _, err := database.migrateTo(history, 4); err == nil {
+		t.Error("Expected an unknown version to be rejected")
+	}
+
+	// A failing migration is rolled back along with its version
+	broken := append(history[:1:1], migration{version: 2, name: "broken", up: []string{`ALTER TABLE prs ADD COLUMN notes TEXT`, `ALTER TABLE nope ADD COLUMN x TEXT`}})
+	if _, err := database.migrateTo(broken, 2); err == nil {
+		t.Fatal("Expected the broken migration to fail")
+	}
+	if version() != 1 {
+		t.Errorf("Expected version 1 after a failed migration, got %d", version())
+	}
+	if _, err := database.conn.Exec(`SELECT notes FROM prs`); err == nil {
+		t.Error("Expected the failed migration's changes to be rolled back")
+	}
+}
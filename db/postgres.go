@@ -0,0 +1,79 @@
+package db
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// NewPostgres connects to the PostgreSQL database at databaseURL and migrates its schema to the
+// latest version
+func NewPostgres(databaseURL string) (*DB, error) {
+	db, err := OpenPostgres(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.MigrateTo(LatestSchemaVersion); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenPostgres connects to the PostgreSQL database at databaseURL without migrating it
+func OpenPostgres(databaseURL string) (*DB, error) {
+	conn, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &DB{conn: conn, writer: conn, postgres: true}, nil
+}
+
+// rebindPostgres numbers a query's ? placeholders $1, $2, ... Queries never contain a literal ?.
+func rebindPostgres(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// postgresTypes maps the SQLite column types used in migrations to their PostgreSQL equivalents
+var postgresTypes = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`INTEGER PRIMARY KEY AUTOINCREMENT`), `SERIAL PRIMARY KEY`},
+	{regexp.MustCompile(`\bBLOB\b`), `BYTEA`},
+	{regexp.MustCompile(`\bTIMESTAMP\b`), `TIMESTAMPTZ`},
+}
+
+// ddl rewrites a migration statement, written for SQLite, for the database
+func (db *DB) ddl(stmt string) string {
+	if !db.postgres {
+		return stmt
+	}
+	for _, t := range postgresTypes {
+		stmt = t.pattern.ReplaceAllString(stmt, t.replace)
+	}
+	return stmt
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Review is one review generation run for a PR at a specific commit
+type Review struct {
+	ID            int
+	PRID          int
+	CommitSHA     string
+	Generator     string // Name of the ReviewGenerator that ran, e.g. "cbpr"
+	StartedAt     time.Time
+	FinishedAt    *time.Time
+	Status        string // "generating", "completed", "error", "cancelled"
+	ArtifactPath  string // Review HTML filename relative to the reviews directory, empty if none was produced
+	StderrExcerpt string // Tail of the generator's output, kept for diagnosing failures
+	ExitCode      *int   // Exit code of the generator process, nil if none ran or it hasn't finished
+	LogPath       string // Full output log relative to the reviews directory, empty if none was written
+}
+
+// StartReview records that review generation has started for a PR and returns the review's ID
+func (db *DB) StartReview(prID int, commitSHA, generator, logPath string) (int, error) {
+	// RETURNING rather than LastInsertId, which the PostgreSQL driver doesn't support. It's a write,
+	// so it goes to the writer like exec.
+	var id int
+	err := db.writer.QueryRow(db.rebind(`
+		INSERT INTO reviews (pr_id, commit_sha, generator, started_at, status, log_path)
+		VALUES (?, ?, ?, ?, 'generating', ?)
+		RETURNING id
+	`), prID, commitSHA, generator, time.Now().UTC(), logPath).Scan(&id)
+	return id, err
+}
+
+// FinishReview records the outcome of a review started with StartReview
+func (db *DB) FinishReview(id int, status, artifactPath, stderrExcerpt string, exitCode *int) error {
+	_, err := db.exec(`
+		UPDATE reviews SET finished_at = ?, status = ?, artifact_path = ?, stderr_excerpt = ?, exit_code = ? WHERE id = ?
+	`, time.Now().UTC(), status, artifactPath, stderrExcerpt, exitCode, id)
+	return err
+}
+
+// CancelUnfinishedReviews marks reviews still generating as cancelled. Called at startup, when no
+// review from a previous run can still be in progress.
+func (db *DB) CancelUnfinishedReviews() (int, error) {
+	result, err := db.exec(`
+		UPDATE reviews SET status = 'cancelled', finished_at = ? WHERE status = 'generating'
+	`, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
+
+// GetReviews returns a PR's review history, newest first
+func (db *DB) GetReviews(prID int) ([]Review, error) {
+	rows, err := db.query(`
+		SELECT id, pr_id, commit_sha, COALESCE(generator, ''), started_at, finished_at, status, COALESCE(artifact_path, ''), COALESCE(stderr_excerpt, ''), exit_code, COALESCE(log_path, '')
+		FROM reviews WHERE pr_id = ?
+		ORDER BY started_at DESC, id DESC
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, rows.Err()
+}
+
+// GetReview returns a single review by ID, or nil if it doesn't exist
+func (db *DB) GetReview(id int) (*Review, error) {
+	review, err := scanReview(db.queryRow(`
+		SELECT id, pr_id, commit_sha, COALESCE(generator, ''), started_at, finished_at, status, COALESCE(artifact_path, ''), COALESCE(stderr_excerpt, ''), exit_code, COALESCE(log_path, '')
+		FROM reviews WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return review, err
+}
+
+func scanReview(row interface{ Scan(...any) error }) (*Review, error) {
+	review := &Review{}
+	var finishedAt sql.NullTime
+	var exitCode sql.NullInt64
+	if err := row.Scan(&review.ID, &review.PRID, &review.CommitSHA, &review.Generator, &review.StartedAt, &finishedAt, &review.Status, &review.ArtifactPath, &review.StderrExcerpt, &exitCode, &review.LogPath); err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		review.FinishedAt = &finishedAt.Time
+	}
+	if exitCode.Valid {
+		code := int(exitCode.Int64)
+		review.ExitCode = &code
+	}
+	return review, nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import "time"
+
+// Store is the storage the server depends on. *DB implements it on SQLite (New) and PostgreSQL
+// (NewPostgres).
+type Store interface {
+	// PRs
+	GetPR(host, owner, repo string, prNumber int) (*PR, error)
+	GetPRByID(id int) (*PR, error)
+	GetAllPRs() ([]PR, error)
+	GetPRsWithMissingMetadata() ([]PR, error)
+	GetPRsWithMissingCreatedAt() ([]PR, error)
+	SyncPR(pr *PR, pending bool) error
+	SyncPRWithMetadata(pr *PR, pending bool) error
+	DeletePR(host, owner, repo string, prNumber int) error
+	SetPRGenerating(host, owner, repo string, prNumber int, commitSHA string) (bool, error)
+	UpdatePRStatus(host, owner, repo string, prNumber int, status string) error
+	UpdatePRMetadata(host, owner, repo string, prNumber int, title, author string) error
+	UpdatePRNotes(host, owner, repo string, prNumber int, notes string) error
+	UpdatePRDraft(host, owner, repo string, prNumber int, draft bool) error
+	UpdateGitHubMetadata(host, owner, repo string, prNumber int, title, author string, draft bool) error
+	UpdateReviewData(host, owner, repo string, prNumber int, approvalCount int, myReviewStatus string) error
+	UpdateCIStatus(host, owner, repo string, prNumber int, state, failedChecks string) error
+	UpdatePRCreatedAt(host, owner, repo string, prNumber int, createdAt time.Time) error
+	ResetPRToOutdated(host, owner, repo string, prNumber int, newCommitSHA string) error
+	ResetGeneratingPR(host, owner, repo string, prNumber int) (bool, error)
+	CompletePRReview(host, owner, repo string, prNumber int, commitSHA, htmlPath string) (bool, error)
+	RecordReviewFailure(host, owner, repo string, prNumber int, commitSHA, status, lastError string, attempts int, nextRetryAt *time.Time) error
+	ResetDueErrorPRs(now time.Time) (int, error)
+	CancelPRReview(host, owner, repo string, prNumber int) (bool, error)
+	RetryPRNow(host, owner, repo string, prNumber int) (bool, error)
+
+	// Archive of closed and merged PRs
+	ArchivePR(host, owner, repo string, prNumber int, closedAt time.Time, mergedAt *time.Time) (bool, error)
+	ReopenPR(host, owner, repo string, prNumber int) (bool, error)
+	GetArchivedPRs() ([]PR, error)
+	GetArchivedPRsClosedBefore(cutoff time.Time) ([]PR, error)
+
+	// PR events
+	GetPREvents(prID int) ([]Event, error)
+	GetEventsSince(since time.Time, afterID int, limit int) ([]Event, error)
+
+	// Review history
+	StartReview(prID int, commitSHA, generator, logPath string) (int, error)
+	FinishReview(id int, status, artifactPath, stderrExcerpt string, exitCode *int) error
+	CancelUnfinishedReviews() (int, error)
+	GetReviews(prID int) ([]Review, error)
+	GetReview(id int) (*Review, error)
+
+	// Schema
+	SchemaVersion() (int, error)
+	MigrationStatus() ([]MigrationStatus, error)
+	MigrateTo(target int) (int, error)
+
+	Close() error
+}
+
+var _ Store = (*DB)(nil)
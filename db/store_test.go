@@ -0,0 +1,945 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStore_SQLite runs the storage conformance suite against SQLite
+func TestStore_SQLite(t *testing.T) {
+	runStoreConformance(t, func(t *testing.T) *DB {
+		database, err := New(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("Failed to create database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+		return database
+	})
+}
+
+// TestStore_Postgres runs the storage conformance suite against PostgreSQL: the server at
+// TEST_DATABASE_URL if set, otherwise a throwaway cluster started with initdb and pg_ctl from PATH
+// or PG_BIN. Each test gets its own schema. It is skipped without PostgreSQL unless REQUIRE_POSTGRES
+// is set, as it is in CI.
+func TestStore_Postgres(t *testing.T) {
+	baseURL := postgresTestURL(t)
+	runStoreConformance(t, func(t *testing.T) *DB {
+		database, err := NewPostgres(postgresSchemaURL(t, baseURL))
+		if err != nil {
+			t.Fatalf("Failed to create database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+		return database
+	})
+}
+
+func runStoreConformance(t *testing.T, open func(t *testing.T) *DB) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s Store)
+	}{
+		{"SchemaVersion", testStoreSchemaVersion},
+		{"AddAndGetPR", testStoreAddAndGetPR},
+		{"SyncKeepsStateForSameCommit", testStoreSyncKeepsStateForSameCommit},
+		{"GetAllPRsOrder", testStoreGetAllPRsOrder},
+		{"FieldUpdates", testStoreFieldUpdates},
+		{"SyncPR", testStoreSyncPR},
+		{"SyncPRWithMetadata", testStoreSyncPRWithMetadata},
+		{"CompletePRReview", testStoreCompletePRReview},
+		{"GeneratingLifecycle", testStoreGeneratingLifecycle},
+		{"RetryState", testStoreRetryState},
+		{"Reviews", testStoreReviews},
+		{"ArchivePR", testStoreArchivePR},
+		{"Events", testStoreEvents},
+		{"DeletePR", testStoreDeletePR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+// storeTime is a timestamp both databases store exactly
+func storeTime(s string) time.Time {
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return ts.UTC()
+}
+
+func mustGetPR(t *testing.T, s Store, number int) *PR {
+	t.Helper()
+	pr, err := s.GetPR("github.com", "acme", "api", number)
+	if err != nil {
+		t.Fatalf("GetPR failed: %v", err)
+	}
+	if pr == nil {
+		t.Fatalf("Expected PR %d to exist", number)
+	}
+	return pr
+}
+
+// mustAddPR seeds acme/api PRs like dbtest.AddPR, which this package can't import: SyncPR, then
+// the review state and the setters for notes, review data and CI
+func mustAddPR(t *testing.T, s Store, pr PR) {
+	t.Helper()
+	if pr.Host == "" {
+		pr.Host = "github.com"
+	}
+	if pr.RepoOwner == "" {
+		pr.RepoOwner, pr.RepoName = "acme", "api"
+	}
+	if err := s.SyncPR(&pr, true); err != nil {
+		t.Fatalf("SyncPR failed: %v", err)
+	}
+	var err error
+	switch pr.Status {
+	case "generating":
+		_, err = s.SetPRGenerating(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.LastCommitSHA)
+	case "completed":
+		_, err = s.CompletePRReview(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.LastCommitSHA, pr.ReviewHTMLPath)
+	}
+	if err == nil && pr.Notes != "" {
+		err = s.UpdatePRNotes(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.Notes)
+	}
+	if err == nil && (pr.ApprovalCount != 0 || pr.MyReviewStatus != "") {
+		err = s.UpdateReviewData(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.ApprovalCount, pr.MyReviewStatus)
+	}
+	if err == nil && pr.CIState != "" {
+		err = s.UpdateCIStatus(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.CIState, pr.CIFailedChecks)
+	}
+	if err != nil {
+		t.Fatalf("Failed to seed PR %d: %v", pr.PRNumber, err)
+	}
+}
+
+func testStoreSchemaVersion(t *testing.T, s Store) {
+	if version, err := s.SchemaVersion(); err != nil || version != LatestSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d (%v)", LatestSchemaVersion, version, err)
+	}
+	statuses, err := s.MigrationStatus()
+	if err != nil || len(statuses) != len(migrations) {
+		t.Fatalf("Expected %d migrations, got %d (%v)", len(migrations), len(statuses), err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("Expected migration %d to be applied", status.Version)
+		}
+	}
+	if ran, err := s.MigrateTo(LatestSchemaVersion); err != nil || ran != 0 {
+		t.Errorf("Expected migrating again to be a no-op, ran %d (%v)", ran, err)
+	}
+}
+
+func testStoreAddAndGetPR(t *testing.T, s Store) {
+	created := storeTime("2024-03-01T10:00:00Z")
+	before := time.Now().Add(-time.Second)
+	mustAddPR(t, s, PR{
+		Account: "me@github.com", PRNumber: 1, LastCommitSHA: "aaaaaaa1", Status: "completed",
+		ReviewHTMLPath: "acme_api_1.html", IsMine: true, Title: "Add feature",
+		Author: "alice", ApprovalCount: 2, MyReviewStatus: "APPROVED", CreatedAt: &created, Draft: true,
+		Notes: "later", CIState: "failure", CIFailedChecks: `["lint"]`,
+	})
+
+	pr := mustGetPR(t, s, 1)
+	if pr.ID == 0 || pr.Host != "github.com" || pr.Account != "me@github.com" || pr.LastCommitSHA != "aaaaaaa1" ||
+		pr.Status != "completed" || pr.ReviewHTMLPath != "acme_api_1.html" || !pr.IsMine || pr.Title != "Add feature" ||
+		pr.Author != "alice" || pr.ApprovalCount != 2 || pr.MyReviewStatus != "APPROVED" || !pr.Draft ||
+		pr.Notes != "later" || pr.CIState != "failure" || pr.CIFailedChecks != `["lint"]` {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+	if pr.CreatedAt == nil || !pr.CreatedAt.Equal(created) {
+		t.Errorf("Expected created_at %v, got %v", created, pr.CreatedAt)
+	}
+	if pr.LastReviewedAt == nil || pr.LastReviewedAt.Before(before) {
+		t.Errorf("Expected last_reviewed_at to be set on completion, got %v", pr.LastReviewedAt)
+	}
+
+	if pr, err := s.GetPR("github.com", "acme", "api", 2); err != nil || pr != nil {
+		t.Errorf("Expected no PR 2, got %+v (%v)", pr, err)
+	}
+	if pr, err := s.GetPR("ghe.example.com", "acme", "api", 1); err != nil || pr != nil {
+		t.Errorf("Expected PRs to be keyed by host, got %+v (%v)", pr, err)
+	}
+}
+
+func testStoreSyncKeepsStateForSameCommit(t *testing.T, s Store) {
+	created := storeTime("2024-03-01T10:00:00Z")
+	mustAddPR(t, s, PR{Account: "me@github.com", PRNumber: 1, LastCommitSHA: "aaaaaaa1", CreatedAt: &created, Status: "completed"})
+	retryAt := storeTime("2024-03-03T00:00:00Z")
+	if err := s.RecordReviewFailure("github.com", "acme", "api", 1, "aaaaaaa1", "error", "boom", 2, &retryAt); err != nil {
+		t.Fatalf("RecordReviewFailure failed: %v", err)
+	}
+
+	// Same commit, no account or created_at: those are kept, as are the review time and retry state
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "aaaaaaa1"})
+	pr := mustGetPR(t, s, 1)
+	if pr.Account != "me@github.com" || pr.CreatedAt == nil || !pr.CreatedAt.Equal(created) || pr.LastReviewedAt == nil {
+		t.Errorf("Expected account, created_at and last_reviewed_at to be kept, got %+v", pr)
+	}
+	if pr.ReviewAttempts != 2 || pr.LastError != "boom" || pr.NextRetryAt == nil || !pr.NextRetryAt.Equal(retryAt) {
+		t.Errorf("Expected retry state to be kept at the same commit, got %d %q %v", pr.ReviewAttempts, pr.LastError, pr.NextRetryAt)
+	}
+
+	// A new commit starts again from zero attempts
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "bbbbbbb2"})
+	pr = mustGetPR(t, s, 1)
+	if pr.ReviewAttempts != 0 || pr.LastError != "" || pr.NextRetryAt != nil {
+		t.Errorf("Expected retry state to be reset for a new commit, got %d %q %v", pr.ReviewAttempts, pr.LastError, pr.NextRetryAt)
+	}
+}
+
+func testStoreGetAllPRsOrder(t *testing.T, s Store) {
+	older := storeTime("2024-03-01T10:00:00Z")
+	newer := storeTime("2024-03-05T10:00:00Z")
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "a1", CreatedAt: &older})
+	mustAddPR(t, s, PR{PRNumber: 2, LastCommitSHA: "a2"})
+	mustAddPR(t, s, PR{PRNumber: 3, LastCommitSHA: "a3", CreatedAt: &newer})
+	mustAddPR(t, s, PR{PRNumber: 4, LastCommitSHA: "a4", CreatedAt: &newer, IsMine: true})
+
+	prs, err := s.GetAllPRs()
+	if err != nil {
+		t.Fatalf("GetAllPRs failed: %v", err)
+	}
+	var got []int
+	for _, pr := range prs {
+		got = append(got, pr.PRNumber)
+	}
+	// Others' PRs first, newest first with unknown creation times last, then mine
+	if want := []int{3, 1, 2, 4}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected order %v, got %v", want, got)
+	}
+}
+
+func testStoreFieldUpdates(t *testing.T, s Store) {
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "a1"})
+	missing, err := s.GetPRsWithMissingMetadata()
+	if err != nil || len(missing) != 1 {
+		t.Fatalf("Expected 1 PR with missing metadata, got %d (%v)", len(missing), err)
+	}
+	if missing, err := s.GetPRsWithMissingCreatedAt(); err != nil || len(missing) != 1 {
+		t.Fatalf("Expected 1 PR with missing created_at, got %d (%v)", len(missing), err)
+	}
+
+	created := storeTime("2024-03-01T10:00:00Z")
+	steps := []struct {
+		name string
+		err  error
+	}{
+		{"UpdatePRMetadata", s.UpdatePRMetadata("github.com", "acme", "api", 1, "Fix login", "bob")},
+		{"UpdatePRNotes", s.UpdatePRNotes("github.com", "acme", "api", 1, "much too long for notes")},
+		{"UpdatePRDraft", s.UpdatePRDraft("github.com", "acme", "api", 1, true)},
+		{"UpdateCIStatus", s.UpdateCIStatus("github.com", "acme", "api", 1, "failure", `["test"]`)},
+		{"UpdateReviewData", s.UpdateReviewData("github.com", "acme", "api", 1, 3, "CHANGES_REQUESTED")},
+		{"UpdatePRCreatedAt", s.UpdatePRCreatedAt("github.com", "acme", "api", 1, created)},
+		{"UpdatePRStatus", s.UpdatePRStatus("github.com", "acme", "api", 1, "completed")},
+	}
+	for _, step := range steps {
+		if step.err != nil {
+			t.Fatalf("%s failed: %v", step.name, step.err)
+		}
+	}
+
+	pr := mustGetPR(t, s, 1)
+	if pr.Title != "Fix login" || pr.Author != "bob" || pr.Notes != "much too long f" || !pr.Draft ||
+		pr.CIState != "failure" || pr.CIFailedChecks != `["test"]` || pr.Status != "completed" ||
+		pr.CreatedAt == nil || !pr.CreatedAt.Equal(created) || pr.ApprovalCount != 3 || pr.MyReviewStatus != "CHANGES_REQUESTED" {
+		t.Errorf("Unexpected PR after updates: %+v", pr)
+	}
+	if missing, _ := s.GetPRsWithMissingMetadata(); len(missing) != 0 {
+		t.Errorf("Expected no PRs with missing metadata, got %d", len(missing))
+	}
+	if missing, _ := s.GetPRsWithMissingCreatedAt(); len(missing) != 0 {
+		t.Errorf("Expected no PRs with missing created_at, got %d", len(missing))
+	}
+
+	if err := s.UpdateGitHubMetadata("github.com", "acme", "api", 1, "Fix logout", "carol", false); err != nil {
+		t.Fatalf("UpdateGitHubMetadata failed: %v", err)
+	}
+	pr = mustGetPR(t, s, 1)
+	if pr.Title != "Fix logout" || pr.Author != "carol" || pr.Draft || pr.Notes != "much too long f" || pr.ApprovalCount != 3 || pr.CIState != "failure" {
+		t.Errorf("Expected only title, author and draft to change, got %+v", pr)
+	}
+}
+
+func testStoreSyncPR(t *testing.T, s Store) {
+	created := storeTime("2024-03-01T10:00:00Z")
+	sync := func(sha, title string, pending bool) {
+		t.Helper()
+		pr := &PR{Host: "github.com", Account: "me@github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: sha, Title: title, Author: "alice", IsMine: true, Draft: true}
+		if title == "Add feature" {
+			pr.CreatedAt = &created
+		}
+		if err := s.SyncPR(pr, pending); err != nil {
+			t.Fatalf("SyncPR failed: %v", err)
+		}
+	}
+
+	sync("a1", "Add feature", false)
+	pr := mustGetPR(t, s, 1)
+	if pr.Status != "pending" || pr.Title != "Add feature" || !pr.IsMine || !pr.Draft || pr.CreatedAt == nil || pr.Account != "me@github.com" {
+		t.Fatalf("Expected a new pending PR, got %+v", pr)
+	}
+
+	// State owned by other phases survives a sync at the same commit
+	if err := s.UpdatePRNotes("github.com", "acme", "api", 1, "later"); err != nil {
+		t.Fatalf("UpdatePRNotes failed: %v", err)
+	}
+	if err := s.UpdateReviewData("github.com", "acme", "api", 1, 2, "APPROVED"); err != nil {
+		t.Fatalf("UpdateReviewData failed: %v", err)
+	}
+	if err := s.UpdateCIStatus("github.com", "acme", "api", 1, "success", "[]"); err != nil {
+		t.Fatalf("UpdateCIStatus failed: %v", err)
+	}
+	if _, err := s.CompletePRReview("github.com", "acme", "api", 1, "a1", "acme_api_1.html"); err != nil {
+		t.Fatalf("CompletePRReview failed: %v", err)
+	}
+	sync("a1", "Add feature (v2)", false)
+	pr = mustGetPR(t, s, 1)
+	if pr.Notes != "later" || pr.ApprovalCount != 2 || pr.MyReviewStatus != "APPROVED" ||
+		pr.CIState != "success" || pr.Status != "completed" || pr.ReviewHTMLPath != "acme_api_1.html" || pr.CreatedAt == nil {
+		t.Errorf("Expected a sync to keep notes, approvals, CI and the review, got %+v", pr)
+	}
+	// Title, author, draft and created_at are UpdateGitHubMetadata's and UpdatePRCreatedAt's
+	if pr.Title != "Add feature" || !pr.Draft || !pr.CreatedAt.Equal(created) {
+		t.Errorf("Expected a sync of a tracked PR to leave its metadata alone, got %+v", pr)
+	}
+
+	// Marking pending at the same commit keeps the review file until it's regenerated
+	sync("a1", "Add feature (v2)", true)
+	if pr := mustGetPR(t, s, 1); pr.Status != "pending" || pr.ReviewHTMLPath != "acme_api_1.html" {
+		t.Errorf("Expected pending with the review kept, got %s %q", pr.Status, pr.ReviewHTMLPath)
+	}
+
+	// A new commit drops the review and retry state
+	retryAt := storeTime("2024-03-03T00:00:00Z")
+	if err := s.RecordReviewFailure("github.com", "acme", "api", 1, "a1", "error", "boom", 2, &retryAt); err != nil {
+		t.Fatalf("RecordReviewFailure failed: %v", err)
+	}
+	sync("b2", "Add feature (v2)", true)
+	pr = mustGetPR(t, s, 1)
+	if pr.Status != "pending" || pr.LastCommitSHA != "b2" || pr.ReviewHTMLPath != "" || pr.ReviewAttempts != 0 || pr.LastError != "" || pr.NextRetryAt != nil {
+		t.Errorf("Expected a pending PR at the new commit with no review or retry state, got %+v", pr)
+	}
+	if pr.Notes != "later" || pr.ApprovalCount != 2 {
+		t.Errorf("Expected notes and approvals to survive a new commit, got %+v", pr)
+	}
+
+	// An archived PR is left archived and untouched; only ReopenPR brings it back
+	closed := storeTime("2024-03-02T10:00:00Z")
+	if _, err := s.ArchivePR("github.com", "acme", "api", 1, closed, nil); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+	sync("c3", "Add feature (v3)", true)
+	if pr := mustGetPR(t, s, 1); !pr.Archived() || pr.LastCommitSHA != "b2" {
+		t.Errorf("Expected a sync to leave the archived PR alone, got %+v", pr)
+	}
+}
+
+func testStoreSyncPRWithMetadata(t *testing.T, s Store) {
+	created := storeTime("2024-03-01T10:00:00Z")
+	pr := PR{Host: "github.com", Account: "me@github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "a1", Title: "Add feature", Author: "alice", Draft: true, CreatedAt: &created}
+	if err := s.SyncPRWithMetadata(&pr, true); err != nil {
+		t.Fatalf("SyncPRWithMetadata failed: %v", err)
+	}
+	if got := mustGetPR(t, s, 1); got.Status != "pending" || got.Title != "Add feature" || !got.Draft || got.CreatedAt == nil || !got.CreatedAt.Equal(created) {
+		t.Fatalf("Expected a new pending PR with its metadata, got %+v", got)
+	}
+	if _, err := s.CompletePRReview("github.com", "acme", "api", 1, "a1", "acme_api_1.html"); err != nil {
+		t.Fatalf("CompletePRReview failed: %v", err)
+	}
+
+	// A tracked PR gets the new commit and metadata together; created_at is kept when not given
+	pr = PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "b2", Title: "Add feature (v2)", Author: "bob"}
+	if err := s.SyncPRWithMetadata(&pr, true); err != nil {
+		t.Fatalf("SyncPRWithMetadata failed: %v", err)
+	}
+	got := mustGetPR(t, s, 1)
+	if got.Status != "pending" || got.LastCommitSHA != "b2" || got.ReviewHTMLPath != "" || got.Account != "me@github.com" {
+		t.Errorf("Expected a pending PR at the new commit, got %+v", got)
+	}
+	if got.Title != "Add feature (v2)" || got.Author != "bob" || got.Draft || got.CreatedAt == nil || !got.CreatedAt.Equal(created) {
+		t.Errorf("Expected the new title, author and draft state with created_at kept, got %+v", got)
+	}
+
+	// An archived PR is left alone
+	if _, err := s.ArchivePR("github.com", "acme", "api", 1, created, nil); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+	pr = PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "c3", Title: "Renamed"}
+	if err := s.SyncPRWithMetadata(&pr, true); err != nil {
+		t.Fatalf("SyncPRWithMetadata failed: %v", err)
+	}
+	if got := mustGetPR(t, s, 1); !got.Archived() || got.LastCommitSHA != "b2" || got.Title != "Add feature (v2)" {
+		t.Errorf("Expected the archived PR to be left alone, got %+v", got)
+	}
+}
+
+func testStoreCompletePRReview(t *testing.T, s Store) {
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "a1", Status: "generating", Notes: "later"})
+	retryAt := storeTime("2024-03-03T00:00:00Z")
+	if err := s.RecordReviewFailure("github.com", "acme", "api", 1, "a1", "generating", "boom", 1, &retryAt); err != nil {
+		t.Fatalf("RecordReviewFailure failed: %v", err)
+	}
+
+	if completed, err := s.CompletePRReview("github.com", "acme", "api", 1, "old", "stale.html"); err != nil || completed {
+		t.Errorf("Expected a review of an old commit not to complete the PR, got %v (%v)", completed, err)
+	}
+	if completed, err := s.CompletePRReview("github.com", "acme", "api", 1, "a1", "acme_api_1.html"); err != nil || !completed {
+		t.Fatalf("Expected the review to complete the PR, got %v (%v)", completed, err)
+	}
+	pr := mustGetPR(t, s, 1)
+	if pr.Status != "completed" || pr.ReviewHTMLPath != "acme_api_1.html" || pr.LastReviewedAt == nil || pr.Notes != "later" {
+		t.Errorf("Unexpected completed PR: %+v", pr)
+	}
+	if pr.ReviewAttempts != 0 || pr.LastError != "" || pr.NextRetryAt != nil {
+		t.Errorf("Expected retry state to be cleared, got %d %q %v", pr.ReviewAttempts, pr.LastError, pr.NextRetryAt)
+	}
+	if completed, _ := s.CompletePRReview("github.com", "acme", "api", 2, "a1", "x.html"); completed {
+		t.Error("Expected an untracked PR not to be completed")
+	}
+}
+
+func testStoreGeneratingLifecycle(t *testing.T, s Store) {
+	if started, err := s.SetPRGenerating("github.com", "acme", "api", 1, "a1"); err != nil || started {
+		t.Fatalf("Expected an untracked PR not to start generating, got %v (%v)", started, err)
+	}
+	if err := s.SyncPR(&PR{Host: "github.com", Account: "me@github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "a1", Title: "Add feature", Author: "alice", IsMine: true, Draft: true}, true); err != nil {
+		t.Fatalf("SyncPR failed: %v", err)
+	}
+	if err := s.UpdatePRNotes("github.com", "acme", "api", 1, "later"); err != nil {
+		t.Fatalf("UpdatePRNotes failed: %v", err)
+	}
+	if started, err := s.SetPRGenerating("github.com", "acme", "api", 1, "a1"); err != nil || !started {
+		t.Fatalf("SetPRGenerating = %v, %v; want true", started, err)
+	}
+	pr := mustGetPR(t, s, 1)
+	if pr.Status != "generating" || pr.GeneratingSince == nil {
+		t.Errorf("Expected a generating PR, got %+v", pr)
+	}
+	if pr.Title != "Add feature" || pr.Author != "alice" || !pr.IsMine || !pr.Draft || pr.Account != "me@github.com" || pr.Notes != "later" {
+		t.Errorf("Expected SetPRGenerating to leave the PR's other columns alone, got %+v", pr)
+	}
+
+	if reset, err := s.ResetGeneratingPR("github.com", "acme", "api", 1); err != nil || !reset {
+		t.Errorf("Expected the generating PR to be reset, got %v (%v)", reset, err)
+	}
+	if reset, err := s.ResetGeneratingPR("github.com", "acme", "api", 1); err != nil || reset {
+		t.Errorf("Expected nothing to reset, got %v (%v)", reset, err)
+	}
+	if pr := mustGetPR(t, s, 1); pr.Status != "pending" || pr.GeneratingSince != nil {
+		t.Errorf("Expected a pending PR, got %s since %v", pr.Status, pr.GeneratingSince)
+	}
+
+	if cancelled, err := s.CancelPRReview("github.com", "acme", "api", 1); err != nil || !cancelled {
+		t.Errorf("Expected the pending review to be cancelled, got %v (%v)", cancelled, err)
+	}
+	if cancelled, err := s.CancelPRReview("github.com", "acme", "api", 1); err != nil || cancelled {
+		t.Errorf("Expected nothing to cancel, got %v (%v)", cancelled, err)
+	}
+	if started, err := s.SetPRGenerating("github.com", "acme", "api", 1, "a1"); err != nil || started {
+		t.Errorf("Expected a cancelled PR not to start generating, got %v (%v)", started, err)
+	}
+
+	if err := s.ResetPRToOutdated("github.com", "acme", "api", 1, "b2"); err != nil {
+		t.Fatalf("ResetPRToOutdated failed: %v", err)
+	}
+	if pr := mustGetPR(t, s, 1); pr.Status != "pending" || pr.LastCommitSHA != "b2" || pr.ReviewHTMLPath != "" {
+		t.Errorf("Expected a pending PR at the new commit, got %+v", pr)
+	}
+	if started, err := s.SetPRGenerating("github.com", "acme", "api", 1, "a1"); err != nil || started {
+		t.Errorf("Expected a PR at a new commit not to start generating for the old one, got %v (%v)", started, err)
+	}
+	if pr := mustGetPR(t, s, 1); pr.Status != "pending" || pr.LastCommitSHA != "b2" {
+		t.Errorf("Expected the PR to stay pending at the new commit, got %s at %s", pr.Status, pr.LastCommitSHA)
+	}
+}
+
+func testStoreRetryState(t *testing.T, s Store) {
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "a1"})
+	mustAddPR(t, s, PR{PRNumber: 2, LastCommitSHA: "a2"})
+	now := storeTime("2024-03-01T10:00:00Z")
+	due, later := now.Add(-time.Minute), now.Add(time.Hour)
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("RecordReviewFailure failed: %v", err)
+		}
+	}
+	must(s.RecordReviewFailure("github.com", "acme", "api", 1, "a1", "error", "timeout", 1, &due))
+	must(s.RecordReviewFailure("github.com", "acme", "api", 2, "a2", "error", "timeout", 1, &later))
+	// A failure for a commit the PR has moved past is ignored
+	must(s.RecordReviewFailure("github.com", "acme", "api", 2, "old", "failed", "stale", 9, nil))
+
+	if reset, err := s.ResetDueErrorPRs(now); err != nil || reset != 1 {
+		t.Fatalf("Expected 1 due PR to be reset, got %d (%v)", reset, err)
+	}
+	if pr := mustGetPR(t, s, 1); pr.Status != "pending" || pr.ReviewAttempts != 1 || pr.LastError != "timeout" {
+		t.Errorf("Expected PR 1 pending with its attempt kept, got %+v", pr)
+	}
+	if pr := mustGetPR(t, s, 2); pr.Status != "error" || pr.ReviewAttempts != 1 {
+		t.Errorf("Expected PR 2 still waiting to retry, got %+v", pr)
+	}
+
+	if retried, err := s.RetryPRNow("github.com", "acme", "api", 2); err != nil || !retried {
+		t.Errorf("Expected PR 2 to be retried, got %v (%v)", retried, err)
+	}
+	if retried, err := s.RetryPRNow("github.com", "acme", "api", 2); err != nil || retried {
+		t.Errorf("Expected a pending PR not to be retried, got %v (%v)", retried, err)
+	}
+	if pr := mustGetPR(t, s, 2); pr.Status != "pending" || pr.NextRetryAt != nil {
+		t.Errorf("Expected PR 2 pending with no retry time, got %+v", pr)
+	}
+}
+
+func testStoreReviews(t *testing.T, s Store) {
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "a1"})
+	pr := mustGetPR(t, s, 1)
+
+	first, err := s.StartReview(pr.ID, "a1", "command", "logs/1.log")
+	if err != nil {
+		t.Fatalf("StartReview failed: %v", err)
+	}
+	exitCode := 1
+	if err := s.FinishReview(first, "error", "", "boom", &exitCode); err != nil {
+		t.Fatalf("FinishReview failed: %v", err)
+	}
+	second, err := s.StartReview(pr.ID, "a1", "command", "")
+	if err != nil || second == first {
+		t.Fatalf("Expected a second review ID, got %d after %d (%v)", second, first, err)
+	}
+
+	reviews, err := s.GetReviews(pr.ID)
+	if err != nil || len(reviews) != 2 {
+		t.Fatalf("Expected 2 reviews, got %d (%v)", len(reviews), err)
+	}
+	if reviews[0].ID != second || reviews[1].ID != first {
+		t.Errorf("Expected newest review first, got %d then %d", reviews[0].ID, reviews[1].ID)
+	}
+	failed := reviews[1]
+	if failed.Status != "error" || failed.StderrExcerpt != "boom" || failed.ExitCode == nil || *failed.ExitCode != 1 ||
+		failed.FinishedAt == nil || failed.LogPath != "logs/1.log" || failed.Generator != "command" {
+		t.Errorf("Unexpected finished review: %+v", failed)
+	}
+
+	if cancelled, err := s.CancelUnfinishedReviews(); err != nil || cancelled != 1 {
+		t.Errorf("Expected 1 unfinished review to be cancelled, got %d (%v)", cancelled, err)
+	}
+	review, err := s.GetReview(second)
+	if err != nil || review == nil || review.Status != "cancelled" || review.FinishedAt == nil || review.ExitCode != nil {
+		t.Errorf("Expected a cancelled review, got %+v (%v)", review, err)
+	}
+	if review, err := s.GetReview(second + 100); err != nil || review != nil {
+		t.Errorf("Expected no review, got %+v (%v)", review, err)
+	}
+}
+
+func testStoreArchivePR(t *testing.T, s Store) {
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "a1", Status: "completed", ReviewHTMLPath: "acme_api_1.html"})
+	mustAddPR(t, s, PR{PRNumber: 2, LastCommitSHA: "a2"})
+	mustAddPR(t, s, PR{PRNumber: 3, LastCommitSHA: "a3"})
+	if _, err := s.SetPRGenerating("github.com", "acme", "api", 2, "a2"); err != nil {
+		t.Fatalf("SetPRGenerating failed: %v", err)
+	}
+	id, err := s.StartReview(mustGetPR(t, s, 1).ID, "a1", "command", "")
+	if err != nil {
+		t.Fatalf("StartReview failed: %v", err)
+	}
+
+	merged := storeTime("2024-03-02T10:00:00Z")
+	closed := storeTime("2024-03-03T10:00:00Z")
+	if ok, err := s.ArchivePR("github.com", "acme", "api", 1, merged, &merged); err != nil || !ok {
+		t.Fatalf("ArchivePR = %v, %v; want true", ok, err)
+	}
+	if ok, err := s.ArchivePR("github.com", "acme", "api", 2, closed, nil); err != nil || !ok {
+		t.Fatalf("ArchivePR = %v, %v; want true", ok, err)
+	}
+	if ok, _ := s.ArchivePR("github.com", "acme", "api", 2, closed, nil); ok {
+		t.Error("Expected archiving an archived PR to report false")
+	}
+	if ok, _ := s.ArchivePR("github.com", "acme", "api", 9, closed, nil); ok {
+		t.Error("Expected archiving an unknown PR to report false")
+	}
+
+	pr := mustGetPR(t, s, 1)
+	if !pr.Archived() || !pr.ClosedAt.Equal(merged) || pr.MergedAt == nil || !pr.MergedAt.Equal(merged) {
+		t.Errorf("Expected PR 1 archived as merged, got closed %v merged %v", pr.ClosedAt, pr.MergedAt)
+	}
+	if pr.Status != "completed" || pr.ReviewHTMLPath != "acme_api_1.html" {
+		t.Errorf("Expected the review to be kept, got %s %q", pr.Status, pr.ReviewHTMLPath)
+	}
+	if review, _ := s.GetReview(id); review == nil {
+		t.Error("Expected the review history to be kept")
+	}
+	if pr := mustGetPR(t, s, 2); pr.Status != "pending" || pr.GeneratingSince != nil || pr.MergedAt != nil {
+		t.Errorf("Expected PR 2's generating review dropped back to pending and no merge time, got %+v", pr)
+	}
+
+	// A review still running when the PR was archived can't record its result
+	if ok, err := s.CompletePRReview("github.com", "acme", "api", 2, "a2", "late.html"); err != nil || ok {
+		t.Errorf("Expected completing an archived PR's review to report false, got %v (%v)", ok, err)
+	}
+	if err := s.RecordReviewFailure("github.com", "acme", "api", 2, "a2", "error", "killed", 1, &closed); err != nil {
+		t.Fatalf("RecordReviewFailure failed: %v", err)
+	}
+	if ok, err := s.SetPRGenerating("github.com", "acme", "api", 2, "a2"); err != nil || ok {
+		t.Errorf("Expected an archived PR not to start generating, got %v (%v)", ok, err)
+	}
+	if pr := mustGetPR(t, s, 2); pr.Status != "pending" || pr.ReviewHTMLPath != "" || pr.ReviewAttempts != 0 {
+		t.Errorf("Expected the archived PR to be left alone, got %+v", pr)
+	}
+
+	open, err := s.GetAllPRs()
+	if err != nil || len(open) != 1 || open[0].PRNumber != 3 {
+		t.Errorf("Expected only PR 3 to be open, got %+v (%v)", open, err)
+	}
+	archived, err := s.GetArchivedPRs()
+	if err != nil || len(archived) != 2 || archived[0].PRNumber != 2 || archived[1].PRNumber != 1 {
+		t.Errorf("Expected PRs 2 and 1 archived, most recently closed first, got %+v (%v)", archived, err)
+	}
+	expired, err := s.GetArchivedPRsClosedBefore(storeTime("2024-03-03T00:00:00Z"))
+	if err != nil || len(expired) != 1 || expired[0].PRNumber != 1 {
+		t.Errorf("Expected only PR 1 closed before the cutoff, got %+v (%v)", expired, err)
+	}
+
+	// Archived PRs can't be retried or cancelled
+	if ok, _ := s.CancelPRReview("github.com", "acme", "api", 2); ok {
+		t.Error("Expected cancelling an archived PR to report false")
+	}
+	if err := s.RecordReviewFailure("github.com", "acme", "api", 1, "a1", "error", "boom", 1, nil); err != nil {
+		t.Fatalf("RecordReviewFailure failed: %v", err)
+	}
+	if ok, _ := s.RetryPRNow("github.com", "acme", "api", 1); ok {
+		t.Error("Expected retrying an archived PR to report false")
+	}
+	if count, _ := s.ResetDueErrorPRs(time.Now()); count != 0 {
+		t.Errorf("Expected archived errors not to be retried, reset %d", count)
+	}
+
+	// Late GitHub data doesn't change archived PRs
+	before := mustGetPR(t, s, 1)
+	events, _ := s.GetPREvents(before.ID)
+	for what, err := range map[string]error{
+		"UpdateGitHubMetadata": s.UpdateGitHubMetadata("github.com", "acme", "api", 1, "Renamed", "bob", true),
+		"UpdatePRDraft":        s.UpdatePRDraft("github.com", "acme", "api", 1, true),
+		"UpdatePRCreatedAt":    s.UpdatePRCreatedAt("github.com", "acme", "api", 1, closed),
+		"UpdateReviewData":     s.UpdateReviewData("github.com", "acme", "api", 1, 3, "APPROVED"),
+		"UpdateCIStatus":       s.UpdateCIStatus("github.com", "acme", "api", 1, "failure", `["lint"]`),
+		"ResetPRToOutdated":    s.ResetPRToOutdated("github.com", "acme", "api", 1, "b1"),
+	} {
+		if err != nil {
+			t.Fatalf("%s failed: %v", what, err)
+		}
+	}
+	if after := mustGetPR(t, s, 1); !reflect.DeepEqual(after, before) {
+		t.Errorf("Expected the archived PR to be left alone:\ngot  %+v\nwant %+v", after, before)
+	}
+	if after, _ := s.GetPREvents(before.ID); len(after) != len(events) {
+		t.Errorf("Expected no events for the archived PR, got %d more", len(after)-len(events))
+	}
+
+	// A reopened PR is open again
+	if reopened, err := s.ReopenPR("github.com", "acme", "api", 2); err != nil || !reopened {
+		t.Fatalf("ReopenPR = %v, %v; want true", reopened, err)
+	}
+	if pr := mustGetPR(t, s, 2); pr.Archived() || pr.MergedAt != nil {
+		t.Errorf("Expected PR 2 to be unarchived, got closed %v", pr.ClosedAt)
+	}
+	if reopened, err := s.ReopenPR("github.com", "acme", "api", 2); err != nil || reopened {
+		t.Errorf("Expected reopening an open PR to report false, got %v (%v)", reopened, err)
+	}
+}
+
+func testStoreEvents(t *testing.T, s Store) {
+	since := time.Now().Add(-time.Minute)
+	sync := func(pr PR) {
+		t.Helper()
+		pr.Host, pr.RepoOwner, pr.RepoName = "github.com", "acme", "api"
+		if err := s.SyncPR(&pr, true); err != nil {
+			t.Fatalf("SyncPR failed: %v", err)
+		}
+	}
+	sync(PR{PRNumber: 1, LastCommitSHA: "a1", Title: "Add widgets", IsMine: true})
+	sync(PR{PRNumber: 2, LastCommitSHA: "b1", Title: "Fix gadgets"})
+	sync(PR{PRNumber: 1, LastCommitSHA: "a1", Title: "Add widgets", IsMine: true})
+
+	// PR 2 goes through a failed attempt, a new commit, a review, CI and approvals, then is merged
+	if _, err := s.SetPRGenerating("github.com", "acme", "api", 2, "b1"); err != nil {
+		t.Fatalf("SetPRGenerating failed: %v", err)
+	}
+	if err := s.RecordReviewFailure("github.com", "acme", "api", 2, "b1", "error", "boom", 1, &since); err != nil {
+		t.Fatalf("RecordReviewFailure failed: %v", err)
+	}
+	sync(PR{PRNumber: 2, LastCommitSHA: "b2222222222", Title: "Fix gadgets"})
+	if _, err := s.SetPRGenerating("github.com", "acme", "api", 2, "b2222222222"); err != nil {
+		t.Fatalf("SetPRGenerating failed: %v", err)
+	}
+	if ok, err := s.CompletePRReview("github.com", "acme", "api", 2, "b1", "stale.html"); err != nil || ok {
+		t.Fatalf("CompletePRReview for an old commit = %v, %v; want false", ok, err)
+	}
+	if ok, err := s.CompletePRReview("github.com", "acme", "api", 2, "b2222222222", "acme_api_2.html"); err != nil || !ok {
+		t.Fatalf("CompletePRReview = %v, %v; want true", ok, err)
+	}
+	for _, state := range []string{"pending", "pending", "success"} {
+		if err := s.UpdateCIStatus("github.com", "acme", "api", 2, state, "[]"); err != nil {
+			t.Fatalf("UpdateCIStatus failed: %v", err)
+		}
+	}
+	for _, count := range []int{1, 1, 0, 2} {
+		if err := s.UpdateReviewData("github.com", "acme", "api", 2, count, ""); err != nil {
+			t.Fatalf("UpdateReviewData failed: %v", err)
+		}
+	}
+	merged := storeTime("2024-03-02T10:00:00Z")
+	if _, err := s.ArchivePR("github.com", "acme", "api", 2, merged, &merged); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+	if _, err := s.ReopenPR("github.com", "acme", "api", 2); err != nil {
+		t.Fatalf("ReopenPR failed: %v", err)
+	}
+	sync(PR{PRNumber: 2, LastCommitSHA: "b2222222222", Title: "Fix gadgets"})
+	if _, err := s.CancelPRReview("github.com", "acme", "api", 2); err != nil {
+		t.Fatalf("CancelPRReview failed: %v", err)
+	}
+
+	type step struct{ eventType, sha, detail string }
+	want := []step{
+		{EventReviewRequested, "b1", "Fix gadgets"},
+		{EventGenerating, "b1", ""},
+		{EventError, "b1", "attempt 1: boom"},
+		{EventCommitPushed, "b2222222222", "from b1"},
+		{EventGenerating, "b2222222222", ""},
+		{EventCompleted, "b2222222222", "acme_api_2.html"},
+		{EventCIChanged, "b2222222222", "unknown → pending"},
+		{EventCIChanged, "b2222222222", "pending → success"},
+		{EventApproved, "b2222222222", "1 approval"},
+		{EventApproved, "b2222222222", "2 approvals"},
+		{EventClosed, "", "merged"},
+		{EventReopened, "", ""},
+		{EventCancelled, "", ""},
+	}
+	pr := mustGetPR(t, s, 2)
+	events, err := s.GetPREvents(pr.ID)
+	if err != nil {
+		t.Fatalf("GetPREvents failed: %v", err)
+	}
+	var got []step
+	for _, e := range events {
+		got = append(got, step{e.Type, e.CommitSHA, e.Detail})
+		if e.PRID != pr.ID || e.PRNumber != 2 || e.Host != "github.com" || e.RepoOwner != "acme" || e.CreatedAt.Before(since) {
+			t.Errorf("Expected event %d to belong to PR 2 and be recent, got %+v", e.ID, e)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected PR 2's timeline\n%v\ngot\n%v", want, got)
+	}
+
+	all, err := s.GetEventsSince(since, 0, 100)
+	if err != nil || len(all) != len(want)+1 || all[0].Type != EventDiscovered || all[0].PRNumber != 1 || all[0].Detail != "Add widgets" {
+		t.Fatalf("Expected PR 1's discovery then PR 2's timeline, got %+v (%v)", all, err)
+	}
+	if later, err := s.GetEventsSince(all[3].CreatedAt, all[3].ID, 100); err != nil || len(later) != len(all)-4 {
+		t.Errorf("Expected the events after the fourth, got %d (%v)", len(later), err)
+	}
+	if limited, _ := s.GetEventsSince(since, 0, 2); len(limited) != 2 {
+		t.Errorf("Expected 2 events with a limit of 2, got %d", len(limited))
+	}
+
+	// Paging from the last event's time and ID crosses pages whose boundary falls between events
+	// recorded at the same time
+	tied := storeTime("2024-03-04T10:00:00Z")
+	if _, err := s.(*DB).exec(`UPDATE pr_events SET created_at = ?`, tied); err != nil {
+		t.Fatalf("Failed to tie event times: %v", err)
+	}
+	var paged []int
+	cursor, afterID := tied.Add(-time.Second), 0
+	for page := 0; page < len(all); page++ {
+		events, err := s.GetEventsSince(cursor, afterID, 3)
+		if err != nil {
+			t.Fatalf("GetEventsSince failed: %v", err)
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, e := range events {
+			paged = append(paged, e.ID)
+		}
+		last := events[len(events)-1]
+		cursor, afterID = last.CreatedAt, last.ID
+	}
+	var ids []int
+	for _, e := range all {
+		ids = append(ids, e.ID)
+	}
+	if !reflect.DeepEqual(paged, ids) {
+		t.Errorf("Expected pages of 3 to return every event once\n%v\ngot\n%v", ids, paged)
+	}
+	if byID, err := s.GetPRByID(pr.ID); err != nil || byID == nil || byID.PRNumber != 2 {
+		t.Errorf("Expected GetPRByID to find PR 2, got %+v (%v)", byID, err)
+	}
+	if missing, err := s.GetPRByID(pr.ID + 100); err != nil || missing != nil {
+		t.Errorf("Expected no PR for an unknown ID, got %+v (%v)", missing, err)
+	}
+}
+
+func testStoreDeletePR(t *testing.T, s Store) {
+	mustAddPR(t, s, PR{PRNumber: 1, LastCommitSHA: "a1"})
+	mustAddPR(t, s, PR{PRNumber: 2, LastCommitSHA: "a2"})
+	pr := mustGetPR(t, s, 1)
+	id, err := s.StartReview(pr.ID, "a1", "command", "")
+	if err != nil {
+		t.Fatalf("StartReview failed: %v", err)
+	}
+
+	if err := s.UpdateCIStatus("github.com", "acme", "api", 1, "failure", `["lint"]`); err != nil {
+		t.Fatalf("UpdateCIStatus failed: %v", err)
+	}
+
+	if err := s.DeletePR("github.com", "acme", "api", 1); err != nil {
+		t.Fatalf("DeletePR failed: %v", err)
+	}
+	if pr, _ := s.GetPR("github.com", "acme", "api", 1); pr != nil {
+		t.Errorf("Expected PR 1 to be deleted, got %+v", pr)
+	}
+	if review, _ := s.GetReview(id); review != nil {
+		t.Errorf("Expected the PR's reviews to be deleted, got %+v", review)
+	}
+	if events, _ := s.GetPREvents(pr.ID); len(events) != 0 {
+		t.Errorf("Expected the PR's events to be deleted, got %+v", events)
+	}
+	mustGetPR(t, s, 2)
+}
+
+// TestPostgresRewrites tests how queries and migrations written for SQLite are rewritten for PostgreSQL
+func TestPostgresRewrites(t *testing.T) {
+	pg := &DB{postgres: true}
+	if got, want := pg.rebind(`UPDATE prs SET notes = ? WHERE id = ? AND status IN ('a', 'b')`), `UPDATE prs SET notes = $1 WHERE id = $2 AND status IN ('a', 'b')`; got != want {
+		t.Errorf("rebind:\ngot  %s\nwant %s", got, want)
+	}
+	if got, want := pg.ddl(`CREATE TABLE t (id INTEGER PRIMARY KEY AUTOINCREMENT, body BLOB, at TIMESTAMP NOT NULL, at_text TEXT)`), `CREATE TABLE t (id SERIAL PRIMARY KEY, body BYTEA, at TIMESTAMPTZ NOT NULL, at_text TEXT)`; got != want {
+		t.Errorf("ddl:\ngot  %s\nwant %s", got, want)
+	}
+
+	sqlite := &DB{}
+	if query := `SELECT ? FROM t`; sqlite.rebind(query) != query || sqlite.ddl(query) != query {
+		t.Error("Expected SQLite queries to be left alone")
+	}
+}
+
+var (
+	postgresOnce    sync.Once
+	postgresURL     string
+	postgresErr     error
+	postgresCleanup func()
+	postgresSchemas atomic.Int64
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if postgresCleanup != nil {
+		postgresCleanup()
+	}
+	os.Exit(code)
+}
+
+// postgresTestURL returns the URL of a PostgreSQL server for tests, skipping the test if there is none
+func postgresTestURL(t *testing.T) string {
+	t.Helper()
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		return url
+	}
+	postgresOnce.Do(func() { postgresURL, postgresErr = startPostgres() })
+	if postgresErr != nil {
+		if os.Getenv("REQUIRE_POSTGRES") != "" {
+			t.Fatalf("PostgreSQL required but unavailable (set TEST_DATABASE_URL or put initdb and pg_ctl on PATH or PG_BIN): %v", postgresErr)
+		}
+		t.Skipf("PostgreSQL unavailable (set TEST_DATABASE_URL or put initdb and pg_ctl on PATH or PG_BIN): %v", postgresErr)
+	}
+	return postgresURL
+}
+
+// startPostgres starts a throwaway PostgreSQL cluster on a free local port
+func startPostgres() (string, error) {
+	initdb, err := postgresBinary("initdb")
+	if err != nil {
+		return "", err
+	}
+	pgCtl, err := postgresBinary("pg_ctl")
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "pr-review-pg")
+	if err != nil {
+		return "", err
+	}
+	dataDir := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-locale").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("initdb failed: %v\n%s", err, out)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	options := fmt.Sprintf("-p %d -c listen_addresses=127.0.0.1 -k %s -c fsync=off", port, dir)
+	if out, err := exec.Command(pgCtl, "-D", dataDir, "-o", options, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("pg_ctl start failed: %v\n%s", err, out)
+	}
+	postgresCleanup = func() {
+		exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "-w", "stop").Run()
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port), nil
+}
+
+func postgresBinary(name string) (string, error) {
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		return filepath.Join(dir, name), nil
+	}
+	return exec.LookPath(name)
+}
+
+// postgresSchemaURL creates a schema for one test, dropped when it finishes, and returns a URL
+// that uses it
+func postgresSchemaURL(t *testing.T, baseURL string) string {
+	t.Helper()
+	admin, err := sql.Open("postgres", baseURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d_%d", os.Getpid(), postgresSchemas.Add(1))
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL must be a URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentAccess runs poll-phase writes, review workers and dashboard reads against one SQLite
+// database at once, plus writes from a second handle on the file like the migrate command's. None of
+// them should fail with "database is locked".
+func TestConcurrentAccess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	path := filepath.Join(t.TempDir(), "stress.db")
+	database, err := New(path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+	other, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open a second handle: %v", err)
+	}
+	defer other.Close()
+
+	const prs, rounds = 20, 30
+	for n := 1; n <= prs; n++ {
+		mustAddPR(t, database, PR{PRNumber: n, LastCommitSHA: "a1"})
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 1024)
+	run := func(name string, fn func(round, n int) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				for n := 1; n <= prs; n++ {
+					if err := fn(round, n); err != nil {
+						errs <- fmt.Errorf("%s (round %d, PR %d): %w", name, round, n, err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	// Poll phases
+	run("sync", func(round, n int) error {
+		return database.SyncPR(&PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: n, LastCommitSHA: "a1", Title: fmt.Sprintf("PR %d.%d", n, round)}, false)
+	})
+	run("review data", func(round, n int) error {
+		return database.UpdateReviewData("github.com", "acme", "api", n, round, "COMMENTED")
+	})
+	run("CI status", func(round, n int) error {
+		return database.UpdateCIStatus("github.com", "acme", "api", n, "pending", "[]")
+	})
+
+	// Review workers
+	run("review", func(round, n int) error {
+		pr, err := database.GetPR("github.com", "acme", "api", n)
+		if err != nil {
+			return err
+		}
+		id, err := database.StartReview(pr.ID, "a1", "command", "")
+		if err != nil {
+			return err
+		}
+		if err := database.FinishReview(id, "completed", "review.html", "", nil); err != nil {
+			return err
+		}
+		_, err = database.CompletePRReview("github.com", "acme", "api", n, "a1", "review.html")
+		return err
+	})
+
+	// Notes saved from the dashboard, through another connection to the file
+	run("notes", func(round, n int) error {
+		return other.UpdatePRNotes("github.com", "acme", "api", n, fmt.Sprintf("note %d", round))
+	})
+
+	// Dashboard reads
+	for i := 0; i < 4; i++ {
+		run("read", func(round, n int) error {
+			if _, err := database.GetAllPRs(); err != nil {
+				return err
+			}
+			pr, err := database.GetPR("github.com", "acme", "api", n)
+			if err != nil {
+				return err
+			}
+			_, err = database.GetReviews(pr.ID)
+			return err
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	pr := mustGetPR(t, database, 1)
+	if pr.Status != "completed" || pr.ApprovalCount != rounds-1 || pr.Notes != fmt.Sprintf("note %d", rounds-1) {
+		t.Errorf("Expected every phase's last write to stick, got %+v", pr)
+	}
+	if reviews, _ := database.GetReviews(pr.ID); len(reviews) != rounds {
+		t.Errorf("Expected %d reviews, got %d", rounds, len(reviews))
+	}
+}
@@ -0,0 +1,27 @@
+-- A database from before multi-host support: most prs columns had been added with ALTER TABLE,
+-- and the HTTP cache existed, but there was no host column or reviews table
+CREATE TABLE prs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	repo_owner TEXT NOT NULL,
+	repo_name TEXT NOT NULL,
+	pr_number INTEGER NOT NULL,
+	last_commit_sha TEXT NOT NULL,
+	last_reviewed_at TIMESTAMP,
+	review_html_path TEXT,
+	status TEXT DEFAULT 'pending',
+	UNIQUE(repo_owner, repo_name, pr_number)
+);
+ALTER TABLE prs ADD COLUMN generating_since TIMESTAMP;
+ALTER TABLE prs ADD COLUMN is_mine INTEGER DEFAULT 0;
+ALTER TABLE prs ADD COLUMN title TEXT DEFAULT '';
+ALTER TABLE prs ADD COLUMN author TEXT DEFAULT '';
+ALTER TABLE prs ADD COLUMN approval_count INTEGER DEFAULT 0;
+ALTER TABLE prs ADD COLUMN my_review_status TEXT DEFAULT '';
+ALTER TABLE prs ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE prs ADD COLUMN draft INTEGER DEFAULT 0;
+ALTER TABLE prs ADD COLUMN notes TEXT DEFAULT '';
+ALTER TABLE prs ADD COLUMN ci_state TEXT DEFAULT 'unknown';
+ALTER TABLE prs ADD COLUMN ci_failed_checks TEXT DEFAULT '[]';
+
+INSERT INTO prs (repo_owner, repo_name, pr_number, last_commit_sha, status, is_mine, title, author, approval_count, notes, ci_state)
+VALUES ('acme', 'api', 7, 'ccccccc1', 'completed', 1, 'Fix login', 'me', 2, 'ship it', 'success');
@@ -0,0 +1,17 @@
+-- A database from the first release: prs had only the original columns and no host
+CREATE TABLE prs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	repo_owner TEXT NOT NULL,
+	repo_name TEXT NOT NULL,
+	pr_number INTEGER NOT NULL,
+	last_commit_sha TEXT NOT NULL,
+	last_reviewed_at TIMESTAMP,
+	review_html_path TEXT,
+	status TEXT DEFAULT 'pending',
+	UNIQUE(repo_owner, repo_name, pr_number)
+);
+
+INSERT INTO prs (repo_owner, repo_name, pr_number, last_commit_sha, last_reviewed_at, review_html_path, status)
+VALUES ('acme', 'api', 1, 'aaaaaaa1', '2024-01-02 03:04:05', 'acme_api_1.html', 'completed');
+INSERT INTO prs (repo_owner, repo_name, pr_number, last_commit_sha, status)
+VALUES ('acme', 'web', 2, 'bbbbbbb1', 'error');
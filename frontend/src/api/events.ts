@@ -0,0 +1,6 @@
+import { apiGet } from './client';
+import type { Timeline } from '@/types/event';
+
+export async function fetchTimeline(prId: number): Promise<Timeline> {
+  return apiGet<Timeline>(`/api/prs/${prId}/timeline`);
+}
@@ -5,7 +5,12 @@ export async function fetchPRs(): Promise<PR[]> {
   return apiGet<PR[]>('/api/prs');
 }
 
+export async function fetchArchivedPRs(): Promise<PR[]> {
+  return apiGet<PR[]>('/api/prs?state=archived');
+}
+
 export interface DeletePRParams {
+  host: string;
   owner: string;
   repo: string;
   number: number;
@@ -15,7 +20,20 @@ export async function deletePR(params: DeletePRParams): Promise<{ status: string
   return apiPost<{ status: string }>('/api/prs/delete', params);
 }
 
+export async function retryPR(params: DeletePRParams): Promise<{ status: string }> {
+  return apiPost<{ status: string }>('/api/prs/retry', params);
+}
+
+export async function regeneratePR(params: DeletePRParams): Promise<{ status: string }> {
+  return apiPost<{ status: string }>('/api/prs/regenerate', params);
+}
+
+export async function cancelPR(params: DeletePRParams): Promise<{ status: string }> {
+  return apiPost<{ status: string }>('/api/prs/cancel', params);
+}
+
 export interface UpdatePRNotesParams {
+  host: string;
   owner: string;
   repo: string;
   number: number;
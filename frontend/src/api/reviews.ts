@@ -0,0 +1,33 @@
+import { apiGet } from './client';
+import type { Review, ReviewDiff, ReviewLog } from '@/types/review';
+
+export interface ReviewHistoryParams {
+  host: string;
+  owner: string;
+  repo: string;
+  number: number;
+}
+
+export async function fetchReviewHistory(params: ReviewHistoryParams): Promise<Review[]> {
+  const query = new URLSearchParams({
+    host: params.host,
+    owner: params.owner,
+    repo: params.repo,
+    number: String(params.number),
+  });
+  return apiGet<Review[]>(`/api/prs/reviews?${query}`);
+}
+
+export async function fetchReviewDiff(from: number, to: number): Promise<ReviewDiff> {
+  return apiGet<ReviewDiff>(`/api/prs/reviews/diff?from=${from}&to=${to}`);
+}
+
+// Returns the latest run's output unless reviewId picks an earlier run
+export async function fetchReviewLog(params: ReviewHistoryParams, reviewId?: number): Promise<ReviewLog> {
+  const query = new URLSearchParams({ host: params.host });
+  if (reviewId !== undefined) {
+    query.set('review', String(reviewId));
+  }
+  const path = [params.owner, params.repo, String(params.number)].map(encodeURIComponent).join('/');
+  return apiGet<ReviewLog>(`/api/prs/${path}/logs?${query}`);
+}
@@ -1,9 +1,10 @@
 import { Component, ErrorInfo, ReactNode } from 'react';
 import { QueryClient, QueryClientProvider } from '@tanstack/react-query';
 import { ReactQueryDevtools } from '@tanstack/react-query-devtools';
-import { Header, StatusBar } from '@/components/layout';
+import { Header, StatusBar, Tabs } from '@/components/layout';
 import { PrioritySection } from '@/components/priority';
-import { MyPRsSection, ReviewPRsSection } from '@/components/prs';
+import { HistorySection, MyPRsSection, ReviewPRsSection } from '@/components/prs';
+import { useUIStore } from '@/store';
 import '@/styles/main.scss';
 
 // Create query client
@@ -52,15 +53,24 @@ class ErrorBoundary extends Component<
 }
 
 function App() {
+  const { activeTab } = useUIStore();
+
   return (
     <ErrorBoundary>
       <QueryClientProvider client={queryClient}>
         <div className="app-container">
           <Header />
           <StatusBar />
-          <PrioritySection />
-          <ReviewPRsSection />
-          <MyPRsSection />
+          <Tabs />
+          {activeTab === 'history' ? (
+            <HistorySection />
+          ) : (
+            <>
+              <PrioritySection />
+              <ReviewPRsSection />
+              <MyPRsSection />
+            </>
+          )}
         </div>
         <ReactQueryDevtools initialIsOpen={false} />
       </QueryClientProvider>
@@ -1,12 +1,11 @@
 interface CommitShaProps {
   sha: string;
-  owner: string;
-  repo: string;
+  prUrl: string; // PR link on the configured GitHub host (github.com or GHES)
 }
 
-export function CommitSha({ sha, owner, repo }: CommitShaProps) {
+export function CommitSha({ sha, prUrl }: CommitShaProps) {
   const shortSha = sha.substring(0, 7);
-  const commitUrl = `https://github.com/${owner}/${repo}/commit/${sha}`;
+  const commitUrl = `${prUrl}/commits/${sha}`;
 
   return (
     <a
@@ -4,9 +4,13 @@ import type { PR } from '@/types/pr';
 interface StatusBadgeProps {
   status: PR['status'];
   generatingSince?: string | null;
+  queuePosition?: number | null;
+  reviewAttempts?: number;
+  lastError?: string;
+  nextRetryAt?: string | null;
 }
 
-export function StatusBadge({ status, generatingSince }: StatusBadgeProps) {
+export function StatusBadge({ status, generatingSince, queuePosition, reviewAttempts, lastError, nextRetryAt }: StatusBadgeProps) {
   const elapsedTime = useMemo(() => {
     if (status !== 'generating' || !generatingSince) return null;
 
@@ -22,20 +26,33 @@ export function StatusBadge({ status, generatingSince }: StatusBadgeProps) {
   const statusText = useMemo(() => {
     switch (status) {
       case 'pending':
-        return 'Pending';
+        return queuePosition ? `Queued (#${queuePosition})` : 'Pending';
       case 'generating':
         return elapsedTime ? `Generating (${elapsedTime})` : 'Generating';
       case 'completed':
         return 'Completed';
       case 'error':
-        return 'Error';
+        return reviewAttempts ? `Error (${reviewAttempts})` : 'Error';
+      case 'failed':
+        return 'Failed';
+      case 'cancelled':
+        return 'Cancelled';
       default:
         return status;
     }
-  }, [status, elapsedTime]);
+  }, [status, elapsedTime, queuePosition, reviewAttempts]);
+
+  const title = useMemo(() => {
+    if (status !== 'error' && status !== 'failed') return undefined;
+    const attempts = `${reviewAttempts ?? 0} failed attempt${reviewAttempts === 1 ? '' : 's'}`;
+    const retry = nextRetryAt
+      ? `retrying at ${new Date(nextRetryAt).toLocaleTimeString()}`
+      : 'not retrying until a new commit';
+    return lastError ? `${attempts}, ${retry}\n${lastError}` : `${attempts}, ${retry}`;
+  }, [status, reviewAttempts, lastError, nextRetryAt]);
 
   return (
-    <span className={`status-badge status-badge--${status}`}>
+    <span className={`status-badge status-badge--${status}`} title={title}>
       {statusText}
     </span>
   );
@@ -29,7 +29,7 @@ export function StatusBar() {
 
   if (!status) return null;
 
-  const { counts, uptime_seconds, rate_limit, cbpr_running, seconds_until_next_poll } = status;
+  const { counts, uptime_seconds, rate_limit, rate_limits, search_stats, search_truncated, deferred_accounts, cbpr_running, review_generator, running_reviews, queued_reviews, seconds_until_next_poll } = status;
 
   return (
     <div className="status-bar status-bar--running">
@@ -45,13 +45,19 @@ export function StatusBar() {
         <span className="status-bar__value">
           {counts.completed} completed, {counts.generating} generating, {counts.pending} pending
           {counts.error > 0 && <span className="status-bar__error">, {counts.error} errors</span>}
+          {counts.failed > 0 && <span className="status-bar__error">, {counts.failed} failed</span>}
         </span>
       </div>
 
       {cbpr_running && (
         <div className="status-bar__item">
-          <span className="status-bar__label">CBPR:</span>
-          <span className="status-bar__value">🔄 Running</span>
+          <span className="status-bar__label">{review_generator === 'command' ? 'Review command' : 'CBPR'}:</span>
+          <span
+            className="status-bar__value"
+            title={running_reviews?.map((r) => `${r.owner}/${r.repo}#${r.number} (${r.duration_seconds}s)`).join('\n')}
+          >
+            🔄 {running_reviews?.length ?? 1} running{queued_reviews > 0 && `, ${queued_reviews} queued`}
+          </span>
         </div>
       )}
 
@@ -69,15 +75,46 @@ export function StatusBar() {
         </div>
       )}
 
-      {rate_limit && (
-        <div className="status-bar__item">
-          <span className="status-bar__label">Rate Limit:</span>
+      {search_truncated && search_stats && (
+        <div className="status-bar__item" title="GitHub search returns at most 1000 results">
+          <span className="status-bar__label">⚠️ Search truncated:</span>
+          <span className="status-bar__value">
+            {search_stats
+              .filter((stat) => stat.truncated)
+              .map((stat) => `${stat.kind === 'authored' ? 'my PRs' : 'review requests'} ${stat.fetched}/${stat.total}`)
+              .join(', ')}
+          </span>
+        </div>
+      )}
+
+      {deferred_accounts && deferred_accounts.length > 0 && (
+        <div className="status-bar__item" title="Polling is paused for these accounts until their rate limit resets">
+          <span className="status-bar__label">⏸️ Rate limited:</span>
           <span className="status-bar__value">
-            {rate_limit.remaining}/{rate_limit.limit}
-            {rate_limit.reset_at && ` (resets ${formatTime(rate_limit.reset_at)})`}
+            {deferred_accounts.map((deferred) => `${deferred.account} until ${formatTime(deferred.until)}`).join(', ')}
           </span>
         </div>
       )}
+
+      {rate_limits && rate_limits.length > 1
+        ? rate_limits.map((limit) => (
+            <div className="status-bar__item" key={limit.account}>
+              <span className="status-bar__label">Rate Limit ({limit.account}):</span>
+              <span className="status-bar__value">
+                {limit.remaining}/{limit.limit}
+                {limit.reset_at && ` (resets ${formatTime(limit.reset_at)})`}
+              </span>
+            </div>
+          ))
+        : rate_limit && (
+            <div className="status-bar__item">
+              <span className="status-bar__label">Rate Limit:</span>
+              <span className="status-bar__value">
+                {rate_limit.remaining}/{rate_limit.limit}
+                {rate_limit.reset_at && ` (resets ${formatTime(rate_limit.reset_at)})`}
+              </span>
+            </div>
+          )}
     </div>
   );
 }
@@ -0,0 +1,28 @@
+import { usePRs } from '@/hooks/usePRs';
+import { useUIStore, type DashboardTab } from '@/store';
+
+const TABS: { id: DashboardTab; label: string; title: string }[] = [
+  { id: 'open', label: 'Open', title: 'PRs waiting for review and your own open PRs' },
+  { id: 'history', label: 'History', title: 'Closed and merged PRs, with their reviews' },
+];
+
+export function Tabs() {
+  const { activeTab, setActiveTab } = useUIStore();
+  const { data: prs } = usePRs();
+
+  return (
+    <nav className="tabs">
+      {TABS.map((tab) => (
+        <button
+          key={tab.id}
+          className={`tabs__tab${activeTab === tab.id ? ' tabs__tab--active' : ''}`}
+          onClick={() => setActiveTab(tab.id)}
+          title={tab.title}
+        >
+          {tab.label}
+          {tab.id === 'open' && prs && ` (${prs.length})`}
+        </button>
+      ))}
+    </nav>
+  );
+}
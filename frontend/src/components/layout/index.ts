@@ -1,2 +1,3 @@
 export { Header } from './Header';
 export { StatusBar } from './StatusBar';
+export { Tabs } from './Tabs';
@@ -0,0 +1,91 @@
+import { memo, useCallback, useState } from 'react';
+import type { PR } from '@/types/pr';
+import { CommitSha } from '@/components/common';
+import { useDeletePR } from '@/hooks/usePRs';
+import { ReviewHistory } from './ReviewHistory';
+import { PRTimeline } from './PRTimeline';
+import { formatDate } from '@/utils/formatDate';
+
+interface ArchivedPRRowProps {
+  pr: PR;
+}
+
+export const ArchivedPRRow = memo(function ArchivedPRRow({ pr }: ArchivedPRRowProps) {
+  const deleteMutation = useDeletePR();
+  const [expanded, setExpanded] = useState<'history' | 'timeline' | null>(null);
+  const toggle = (panel: 'history' | 'timeline') => setExpanded((shown) => (shown === panel ? null : panel));
+  const reviewUrl = pr.review_html_path ? pr.review_url : null;
+
+  const handleDelete = useCallback(() => {
+    if (window.confirm(`Delete PR ${pr.owner}/${pr.repo}#${pr.number} and its reviews now?`)) {
+      deleteMutation.mutate({
+        host: pr.host,
+        owner: pr.owner,
+        repo: pr.repo,
+        number: pr.number,
+      });
+    }
+  }, [pr.host, pr.owner, pr.repo, pr.number, deleteMutation]);
+
+  return (
+    <>
+      <tr>
+        <td>
+          <a href={pr.github_url}>
+            {pr.owner}/{pr.repo} #{pr.number}
+          </a>
+          {!pr.is_mine && <span className="pr-table__host"> (review)</span>}
+          {pr.host !== 'github.com' && <span className="pr-table__host"> ({pr.host})</span>}
+          <div className="pr-table__title">{pr.title}</div>
+        </td>
+        <td>{pr.author}</td>
+        <td>
+          <CommitSha sha={pr.commit_sha} prUrl={pr.github_url} />
+        </td>
+        <td className="pr-table__closed">
+          {pr.merged_at ? (
+            <span className="status-badge status-badge--merged">merged</span>
+          ) : (
+            <span className="status-badge status-badge--closed">closed</span>
+          )}
+          <span className="pr-table__closed-at">{formatDate(pr.merged_at ?? pr.closed_at)}</span>
+        </td>
+        <td>{pr.notes || '-'}</td>
+        <td>
+          {reviewUrl ? <a href={reviewUrl}>View Review</a> : <span>-</span>}
+          <button
+            className="pr-table__history-btn"
+            onClick={() => toggle('history')}
+            title="Reviews of every commit"
+          >
+            {expanded === 'history' ? 'Hide history' : 'History'}
+          </button>
+          <button
+            className="pr-table__history-btn"
+            onClick={() => toggle('timeline')}
+            title="Everything that happened to this PR"
+          >
+            {expanded === 'timeline' ? 'Hide timeline' : 'Timeline'}
+          </button>
+        </td>
+        <td className="pr-table__actions">
+          <button
+            className="pr-table__delete-btn"
+            onClick={handleDelete}
+            disabled={deleteMutation.isPending}
+            title="Delete now instead of when the archive retention runs out"
+          >
+            {deleteMutation.isPending ? 'Deleting...' : 'Delete'}
+          </button>
+        </td>
+      </tr>
+      {expanded && (
+        <tr className="pr-table__history-row">
+          <td colSpan={7}>
+            {expanded === 'history' ? <ReviewHistory pr={pr} /> : <PRTimeline pr={pr} />}
+          </td>
+        </tr>
+      )}
+    </>
+  );
+});
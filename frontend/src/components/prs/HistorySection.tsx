@@ -0,0 +1,42 @@
+import { useArchivedPRs } from '@/hooks/usePRs';
+import { LoadingSpinner, ErrorMessage } from '@/components/common';
+import { ArchivedPRRow } from './ArchivedPRRow';
+
+export function HistorySection() {
+  // The server returns archived PRs most recently closed first
+  const { data: prs, isLoading, error } = useArchivedPRs();
+
+  return (
+    <section>
+      <h2>Closed &amp; Merged PRs ({prs?.length ?? 0})</h2>
+      {isLoading && <LoadingSpinner />}
+      {error && <ErrorMessage message={`Error loading history: ${error.message}`} />}
+      {!isLoading && !error && (
+        prs && prs.length > 0 ? (
+          <div className="pr-table-container">
+            <table className="pr-table">
+              <thead>
+                <tr>
+                  <th>PR</th>
+                  <th>Author</th>
+                  <th>Commit</th>
+                  <th>Closed</th>
+                  <th>Notes</th>
+                  <th>Review</th>
+                  <th>Actions</th>
+                </tr>
+              </thead>
+              <tbody>
+                {prs.map((pr) => (
+                  <ArchivedPRRow key={`${pr.host}/${pr.owner}/${pr.repo}/${pr.number}`} pr={pr} />
+                ))}
+              </tbody>
+            </table>
+          </div>
+        ) : (
+          <p>No closed or merged PRs yet.</p>
+        )
+      )}
+    </section>
+  );
+}
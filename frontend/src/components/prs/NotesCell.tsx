@@ -2,6 +2,7 @@ import { useState, useRef, useEffect, memo } from 'react';
 import { useUpdatePRNotes } from '@/hooks/usePRs';
 
 interface NotesCellProps {
+  host: string;
   owner: string;
   repo: string;
   number: number;
@@ -9,6 +10,7 @@ interface NotesCellProps {
 }
 
 export const NotesCell = memo(function NotesCell({
+  host,
   owner,
   repo,
   number,
@@ -48,6 +50,7 @@ export const NotesCell = memo(function NotesCell({
 
     try {
       await updateNotesMutation.mutateAsync({
+        host,
         owner,
         repo,
         number,
@@ -31,7 +31,7 @@ export function PRTable({ prs, showMyReview = false }: PRTableProps) {
         <tbody>
           {prs.map((pr) => (
             <PRTableRow
-              key={`${pr.owner}/${pr.repo}/${pr.number}`}
+              key={`${pr.host}/${pr.owner}/${pr.repo}/${pr.number}`}
               pr={pr}
               showMyReview={showMyReview}
             />
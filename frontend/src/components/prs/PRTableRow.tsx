@@ -1,9 +1,12 @@
-import { memo, useCallback } from 'react';
+import { memo, useCallback, useState } from 'react';
 import type { PR } from '@/types/pr';
 import { CommitSha, StatusBadge, ReviewStatusEmoji } from '@/components/common';
-import { useDeletePR } from '@/hooks/usePRs';
+import { useDeletePR, useRetryPR, useRegeneratePR, useCancelPR } from '@/hooks/usePRs';
 import { NotesCell } from './NotesCell';
 import { CIStatusIndicator } from './CIStatusIndicator';
+import { ReviewHistory } from './ReviewHistory';
+import { ReviewLogs } from './ReviewLogs';
+import { PRTimeline } from './PRTimeline';
 
 interface PRTableRowProps {
   pr: PR;
@@ -12,7 +15,12 @@ interface PRTableRowProps {
 
 export const PRTableRow = memo(function PRTableRow({ pr, showMyReview = false }: PRTableRowProps) {
   const deleteMutation = useDeletePR();
-  const prUrl = `https://github.com/${pr.owner}/${pr.repo}/pull/${pr.number}`;
+  const retryMutation = useRetryPR();
+  const regenerateMutation = useRegeneratePR();
+  const cancelMutation = useCancelPR();
+  const [expanded, setExpanded] = useState<'history' | 'logs' | 'timeline' | null>(null);
+  const toggle = (panel: 'history' | 'logs' | 'timeline') => setExpanded((shown) => (shown === panel ? null : panel));
+  const prUrl = pr.github_url;
   const reviewUrl = pr.status === 'completed' && pr.review_url
     ? pr.review_url
     : null;
@@ -20,67 +28,170 @@ export const PRTableRow = memo(function PRTableRow({ pr, showMyReview = false }:
   const handleDelete = useCallback(() => {
     if (window.confirm(`Delete PR ${pr.owner}/${pr.repo}#${pr.number} from the system?`)) {
       deleteMutation.mutate({
+        host: pr.host,
         owner: pr.owner,
         repo: pr.repo,
         number: pr.number,
       });
     }
-  }, [pr.owner, pr.repo, pr.number, deleteMutation]);
+  }, [pr.host, pr.owner, pr.repo, pr.number, deleteMutation]);
+
+  const handleRetry = useCallback(() => {
+    retryMutation.mutate({
+      host: pr.host,
+      owner: pr.owner,
+      repo: pr.repo,
+      number: pr.number,
+    });
+  }, [pr.host, pr.owner, pr.repo, pr.number, retryMutation]);
+
+  const handleRegenerate = useCallback(() => {
+    regenerateMutation.mutate({
+      host: pr.host,
+      owner: pr.owner,
+      repo: pr.repo,
+      number: pr.number,
+    });
+  }, [pr.host, pr.owner, pr.repo, pr.number, regenerateMutation]);
+
+  const handleCancel = useCallback(() => {
+    cancelMutation.mutate({
+      host: pr.host,
+      owner: pr.owner,
+      repo: pr.repo,
+      number: pr.number,
+    });
+  }, [pr.host, pr.owner, pr.repo, pr.number, cancelMutation]);
+
+  const failed = pr.status === 'error' || pr.status === 'failed';
+  const inProgress = pr.status === 'pending' || pr.status === 'generating';
 
   return (
-    <tr>
-      <td>
-        <a href={prUrl}>
-          {pr.owner}/{pr.repo} #{pr.number}
-        </a>
-        {pr.draft && <span className="pr-table__draft-indicator"> (Draft)</span>}
-        <div className="pr-table__title">{pr.title}</div>
-      </td>
-      <td>{pr.author}</td>
-      <td>
-        <CommitSha sha={pr.commit_sha} owner={pr.owner} repo={pr.repo} />
-      </td>
-      <td>
-        <StatusBadge status={pr.status} generatingSince={pr.generating_since} />
-      </td>
-      <td className="pr-table__ci-status">
-        <CIStatusIndicator state={pr.ci_state} failedChecks={pr.ci_failed_checks} />
-      </td>
-      {showMyReview && (
-        <td className="pr-table__review-emoji">
-          <ReviewStatusEmoji status={pr.my_review_status} />
-        </td>
-      )}
-      <td className="pr-table__notes">
-        <NotesCell
-          owner={pr.owner}
-          repo={pr.repo}
-          number={pr.number}
-          initialNotes={pr.notes}
-        />
-      </td>
-      <td className={`pr-table__approval-count ${pr.approval_count > 0 ? 'pr-table__approval-count--positive' : 'pr-table__approval-count--zero'}`}>
-        {pr.approval_count}
-      </td>
-      <td>
-        {reviewUrl ? (
-          <a href={reviewUrl}>
-            View Review
+    <>
+      <tr>
+        <td>
+          <a href={prUrl}>
+            {pr.owner}/{pr.repo} #{pr.number}
           </a>
-        ) : (
-          <span>-</span>
+          {pr.draft && <span className="pr-table__draft-indicator"> (Draft)</span>}
+          {pr.host !== 'github.com' && <span className="pr-table__host"> ({pr.host})</span>}
+          <div className="pr-table__title">{pr.title}</div>
+        </td>
+        <td>{pr.author}</td>
+        <td>
+          <CommitSha sha={pr.commit_sha} prUrl={pr.github_url} />
+        </td>
+        <td>
+          <StatusBadge
+            status={pr.status}
+            generatingSince={pr.generating_since}
+            queuePosition={pr.queue_position}
+            reviewAttempts={pr.review_attempts}
+            lastError={pr.last_error}
+            nextRetryAt={pr.next_retry_at}
+          />
+        </td>
+        <td className="pr-table__ci-status">
+          <CIStatusIndicator state={pr.ci_state} failedChecks={pr.ci_failed_checks} />
+        </td>
+        {showMyReview && (
+          <td className="pr-table__review-emoji">
+            <ReviewStatusEmoji status={pr.my_review_status} />
+          </td>
         )}
-      </td>
-      <td>
-        <button
-          className="pr-table__delete-btn"
-          onClick={handleDelete}
-          disabled={deleteMutation.isPending}
-          title="Remove from system"
-        >
-          {deleteMutation.isPending ? 'Deleting...' : 'Delete'}
-        </button>
-      </td>
-    </tr>
+        <td className="pr-table__notes">
+          <NotesCell
+            host={pr.host}
+            owner={pr.owner}
+            repo={pr.repo}
+            number={pr.number}
+            initialNotes={pr.notes}
+          />
+        </td>
+        <td className={`pr-table__approval-count ${pr.approval_count > 0 ? 'pr-table__approval-count--positive' : 'pr-table__approval-count--zero'}`}>
+          {pr.approval_count}
+        </td>
+        <td>
+          {reviewUrl ? (
+            <a href={reviewUrl}>
+              View Review
+            </a>
+          ) : (
+            <span>-</span>
+          )}
+          {failed && (
+            <button
+              className="pr-table__history-btn"
+              onClick={() => toggle('logs')}
+              title="Output of the failed review run"
+            >
+              {expanded === 'logs' ? 'Hide log' : 'Why?'}
+            </button>
+          )}
+          {failed && (
+            <button
+              className="pr-table__history-btn"
+              onClick={handleRetry}
+              disabled={retryMutation.isPending}
+              title="Generate the review again now"
+            >
+              {retryMutation.isPending ? 'Retrying...' : 'Retry now'}
+            </button>
+          )}
+          <button
+            className="pr-table__history-btn"
+            onClick={() => toggle('history')}
+            title="Reviews of earlier commits"
+          >
+            {expanded === 'history' ? 'Hide history' : 'History'}
+          </button>
+          <button
+            className="pr-table__history-btn"
+            onClick={() => toggle('timeline')}
+            title="Everything that has happened to this PR"
+          >
+            {expanded === 'timeline' ? 'Hide timeline' : 'Timeline'}
+          </button>
+        </td>
+        <td className="pr-table__actions">
+          {inProgress ? (
+            <button
+              className="pr-table__action-btn"
+              onClick={handleCancel}
+              disabled={cancelMutation.isPending}
+              title="Stop generating this review"
+            >
+              {cancelMutation.isPending ? 'Cancelling...' : 'Cancel'}
+            </button>
+          ) : (
+            <button
+              className="pr-table__action-btn"
+              onClick={handleRegenerate}
+              disabled={regenerateMutation.isPending}
+              title="Generate a new review, keeping notes and history"
+            >
+              {regenerateMutation.isPending ? 'Queueing...' : 'Regenerate'}
+            </button>
+          )}
+          <button
+            className="pr-table__delete-btn"
+            onClick={handleDelete}
+            disabled={deleteMutation.isPending}
+            title="Remove from system"
+          >
+            {deleteMutation.isPending ? 'Deleting...' : 'Delete'}
+          </button>
+        </td>
+      </tr>
+      {expanded && (
+        <tr className="pr-table__history-row">
+          <td colSpan={showMyReview ? 10 : 9}>
+            {expanded === 'history' && <ReviewHistory pr={pr} />}
+            {expanded === 'logs' && <ReviewLogs pr={pr} />}
+            {expanded === 'timeline' && <PRTimeline pr={pr} />}
+          </td>
+        </tr>
+      )}
+    </>
   );
 });
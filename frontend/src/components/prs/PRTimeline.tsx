@@ -0,0 +1,62 @@
+import type { PR } from '@/types/pr';
+import type { EventType } from '@/types/event';
+import { CommitSha, ErrorMessage } from '@/components/common';
+import { useTimeline } from '@/hooks/useEvents';
+import { formatDate } from '@/utils/formatDate';
+
+interface PRTimelineProps {
+  pr: PR;
+}
+
+const EVENT_LABELS: Record<EventType, string> = {
+  discovered: 'Discovered',
+  review_requested: 'Review requested',
+  commit_pushed: 'Commit pushed',
+  generating: 'Generating review',
+  completed: 'Review completed',
+  error: 'Review failed',
+  cancelled: 'Review cancelled',
+  ci_changed: 'CI changed',
+  approved: 'Approved',
+  closed: 'Closed',
+  reopened: 'Reopened',
+};
+
+export function PRTimeline({ pr }: PRTimelineProps) {
+  const { data: timeline, isLoading, error } = useTimeline(pr.id);
+
+  if (isLoading) return <p className="review-history__empty">Loading timeline...</p>;
+  if (error) return <ErrorMessage message={`Failed to load timeline: ${error.message}`} />;
+  if (!timeline || timeline.events.length === 0) {
+    return <p className="review-history__empty">Nothing recorded yet.</p>;
+  }
+
+  return (
+    <div className="review-history">
+      <table className="review-history__table">
+        <thead>
+          <tr>
+            <th>When</th>
+            <th>Event</th>
+            <th>Commit</th>
+            <th>Details</th>
+          </tr>
+        </thead>
+        <tbody>
+          {timeline.events.map((event) => (
+            <tr key={event.id}>
+              <td>{formatDate(event.created_at)}</td>
+              <td>
+                <span className={`pr-timeline__event pr-timeline__event--${event.type}`}>
+                  {EVENT_LABELS[event.type] ?? event.type}
+                </span>
+              </td>
+              <td>{event.commit_sha ? <CommitSha sha={event.commit_sha} prUrl={pr.github_url} /> : <span>-</span>}</td>
+              <td>{event.detail || '-'}</td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+    </div>
+  );
+}
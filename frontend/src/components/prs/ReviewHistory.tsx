@@ -0,0 +1,137 @@
+import { useState } from 'react';
+import type { PR } from '@/types/pr';
+import type { Review } from '@/types/review';
+import { CommitSha, ErrorMessage } from '@/components/common';
+import { useReviewHistory, useReviewDiff } from '@/hooks/useReviews';
+import { ReviewLogs } from './ReviewLogs';
+import { formatDate } from '@/utils/formatDate';
+
+interface ReviewHistoryProps {
+  pr: PR;
+}
+
+function duration(review: Review): string {
+  if (!review.finished_at) return '-';
+  const seconds = Math.round((new Date(review.finished_at).getTime() - new Date(review.started_at).getTime()) / 1000);
+  return seconds < 60 ? `${seconds}s` : `${Math.floor(seconds / 60)}m ${seconds % 60}s`;
+}
+
+export function ReviewHistory({ pr }: ReviewHistoryProps) {
+  const { data: reviews, isLoading, error } = useReviewHistory(
+    { host: pr.host, owner: pr.owner, repo: pr.repo, number: pr.number },
+    true
+  );
+  const [diffPair, setDiffPair] = useState<[number, number] | null>(null);
+  const [logRun, setLogRun] = useState<number | null>(null);
+
+  if (isLoading) return <p className="review-history__empty">Loading history...</p>;
+  if (error) return <ErrorMessage message={`Failed to load review history: ${error.message}`} />;
+  if (!reviews || reviews.length === 0) {
+    return <p className="review-history__empty">No reviews generated yet.</p>;
+  }
+
+  // Reviews are newest first; each completed review can be compared with the completed one before it
+  const completed = reviews.filter((review) => review.status === 'completed' && review.review_url);
+  const previousCompleted = (review: Review) => completed[completed.indexOf(review) + 1];
+
+  return (
+    <div className="review-history">
+      <table className="review-history__table">
+        <thead>
+          <tr>
+            <th>Commit</th>
+            <th>Started</th>
+            <th>Duration</th>
+            <th>Generator</th>
+            <th>Status</th>
+            <th>Review</th>
+            <th></th>
+          </tr>
+        </thead>
+        <tbody>
+          {reviews.map((review) => {
+            const previous = review.status === 'completed' ? previousCompleted(review) : undefined;
+            return (
+              <tr key={review.id} className={review.current ? 'review-history__row--current' : undefined}>
+                <td>
+                  <CommitSha sha={review.commit_sha} prUrl={pr.github_url} />
+                </td>
+                <td>{formatDate(review.started_at)}</td>
+                <td>{duration(review)}</td>
+                <td>{review.generator}</td>
+                <td>
+                  <span className={`status-badge status-badge--${review.status}`}>{review.status}</span>
+                  {review.exit_code !== null && review.exit_code !== 0 && (
+                    <span className="review-logs__exit-code"> exit {review.exit_code}</span>
+                  )}
+                </td>
+                <td>
+                  {review.review_url ? <a href={review.review_url}>View{review.current && ' (current)'}</a> : <span>-</span>}
+                </td>
+                <td>
+                  {(review.has_log || review.stderr_excerpt) && (
+                    <button className="review-history__diff-btn" onClick={() => setLogRun(review.id)}>
+                      Output
+                    </button>
+                  )}
+                  {previous && (
+                    <button
+                      className="review-history__diff-btn"
+                      onClick={() => setDiffPair([previous.id, review.id])}
+                      title={`Compare with the review of ${previous.commit_sha.substring(0, 7)}`}
+                    >
+                      Diff vs previous
+                    </button>
+                  )}
+                </td>
+              </tr>
+            );
+          })}
+        </tbody>
+      </table>
+      {logRun !== null && <ReviewLogs pr={pr} reviewId={logRun} onClose={() => setLogRun(null)} />}
+      {diffPair && <ReviewDiffView from={diffPair[0]} to={diffPair[1]} onClose={() => setDiffPair(null)} />}
+    </div>
+  );
+}
+
+interface ReviewDiffViewProps {
+  from: number;
+  to: number;
+  onClose: () => void;
+}
+
+function ReviewDiffView({ from, to, onClose }: ReviewDiffViewProps) {
+  const { data: diff, isLoading, error } = useReviewDiff(from, to);
+
+  return (
+    <div className="review-diff">
+      <div className="review-diff__header">
+        {diff ? (
+          <span>
+            Changes from {diff.from.commit_sha.substring(0, 7)} to {diff.to.commit_sha.substring(0, 7)}
+          </span>
+        ) : (
+          <span>Comparing reviews...</span>
+        )}
+        <button className="review-history__diff-btn" onClick={onClose}>
+          Close
+        </button>
+      </div>
+      {isLoading && <p className="review-history__empty">Loading diff...</p>}
+      {error && <ErrorMessage message={`Failed to compare reviews: ${error.message}`} />}
+      {diff && (
+        <pre className="review-diff__body">
+          {diff.lines.map((line, i) => (
+            <div
+              key={i}
+              className={`review-diff__line ${line.op === '+' ? 'review-diff__line--added' : line.op === '-' ? 'review-diff__line--removed' : ''}`}
+            >
+              {line.op} {line.text}
+            </div>
+          ))}
+        </pre>
+      )}
+    </div>
+  );
+}
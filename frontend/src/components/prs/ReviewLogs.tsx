@@ -0,0 +1,46 @@
+import type { PR } from '@/types/pr';
+import { ErrorMessage } from '@/components/common';
+import { useReviewLog } from '@/hooks/useReviews';
+import { formatDate } from '@/utils/formatDate';
+
+interface ReviewLogsProps {
+  pr: PR;
+  reviewId?: number; // Defaults to the latest run
+  onClose?: () => void;
+}
+
+export function ReviewLogs({ pr, reviewId, onClose }: ReviewLogsProps) {
+  const { data: run, isLoading, error } = useReviewLog(
+    { host: pr.host, owner: pr.owner, repo: pr.repo, number: pr.number },
+    reviewId
+  );
+
+  return (
+    <div className="review-logs">
+      <div className="review-logs__header">
+        {run ? (
+          <span>
+            {run.generator} run on {run.commit_sha.substring(0, 7)}, started {formatDate(run.started_at)}:{' '}
+            <span className={`status-badge status-badge--${run.status}`}>{run.status}</span>
+            {run.exit_code !== null && <span className="review-logs__exit-code"> exit code {run.exit_code}</span>}
+          </span>
+        ) : (
+          <span>Review output</span>
+        )}
+        {onClose && (
+          <button className="review-history__diff-btn" onClick={onClose}>
+            Close
+          </button>
+        )}
+      </div>
+      {isLoading && <p className="review-history__empty">Loading output...</p>}
+      {error && <ErrorMessage message={`Failed to load output: ${error.message}`} />}
+      {run && (
+        <pre className="review-logs__body">
+          {run.log_truncated && '[... earlier output truncated ...]\n'}
+          {run.log || run.stderr_excerpt || 'No output was captured for this run.'}
+        </pre>
+      )}
+    </div>
+  );
+}
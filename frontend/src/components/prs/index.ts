@@ -2,3 +2,7 @@ export { PRTable } from './PRTable';
 export { PRTableRow } from './PRTableRow';
 export { MyPRsSection } from './MyPRsSection';
 export { ReviewPRsSection } from './ReviewPRsSection';
+export { HistorySection } from './HistorySection';
+export { ReviewHistory } from './ReviewHistory';
+export { PRTimeline } from './PRTimeline';
+export { ReviewLogs } from './ReviewLogs';
@@ -0,0 +1,12 @@
+import { useQuery } from '@tanstack/react-query';
+import { fetchTimeline } from '@/api/events';
+import { PR_POLL_INTERVAL, PR_STALE_TIME } from '@/utils/constants';
+
+export function useTimeline(prId: number) {
+  return useQuery({
+    queryKey: ['timeline', prId],
+    queryFn: () => fetchTimeline(prId),
+    refetchInterval: PR_POLL_INTERVAL,
+    staleTime: PR_STALE_TIME,
+  });
+}
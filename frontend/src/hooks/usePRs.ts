@@ -1,7 +1,17 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
-import { fetchPRs, deletePR, updatePRNotes, type DeletePRParams, type UpdatePRNotesParams } from '@/api/prs';
+import {
+  fetchPRs,
+  fetchArchivedPRs,
+  deletePR,
+  retryPR,
+  regeneratePR,
+  cancelPR,
+  updatePRNotes,
+  type DeletePRParams,
+  type UpdatePRNotesParams,
+} from '@/api/prs';
 import type { PR } from '@/types/pr';
-import { PR_POLL_INTERVAL, PR_STALE_TIME } from '@/utils/constants';
+import { PR_POLL_INTERVAL, PR_STALE_TIME, ARCHIVED_PR_POLL_INTERVAL } from '@/utils/constants';
 
 export function usePRs() {
   return useQuery({
@@ -12,6 +22,16 @@ export function usePRs() {
   });
 }
 
+// Closed and merged PRs, most recently closed first
+export function useArchivedPRs() {
+  return useQuery({
+    queryKey: ['prs', 'archived'],
+    queryFn: fetchArchivedPRs,
+    refetchInterval: ARCHIVED_PR_POLL_INTERVAL,
+    staleTime: PR_STALE_TIME,
+  });
+}
+
 export function useDeletePR() {
   const queryClient = useQueryClient();
 
@@ -29,6 +49,7 @@ export function useDeletePR() {
         old?.filter(
           (pr) =>
             !(
+              pr.host === params.host &&
               pr.owner === params.owner &&
               pr.repo === params.repo &&
               pr.number === params.number
@@ -51,6 +72,48 @@ export function useDeletePR() {
   });
 }
 
+export function useRetryPR() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (params: DeletePRParams) => retryPR(params),
+    onError: (err) => {
+      alert(`Error retrying review: ${err.message}`);
+    },
+    onSettled: () => {
+      queryClient.invalidateQueries({ queryKey: ['prs'] });
+    },
+  });
+}
+
+export function useRegeneratePR() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (params: DeletePRParams) => regeneratePR(params),
+    onError: (err) => {
+      alert(`Error regenerating review: ${err.message}`);
+    },
+    onSettled: () => {
+      queryClient.invalidateQueries({ queryKey: ['prs'] });
+    },
+  });
+}
+
+export function useCancelPR() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (params: DeletePRParams) => cancelPR(params),
+    onError: (err) => {
+      alert(`Error cancelling review: ${err.message}`);
+    },
+    onSettled: () => {
+      queryClient.invalidateQueries({ queryKey: ['prs'] });
+    },
+  });
+}
+
 export function useUpdatePRNotes() {
   const queryClient = useQueryClient();
 
@@ -66,6 +129,7 @@ export function useUpdatePRNotes() {
       // Optimistically update
       queryClient.setQueryData<PR[]>(['prs'], (old) =>
         old?.map((pr) =>
+          pr.host === params.host &&
           pr.owner === params.owner &&
           pr.repo === params.repo &&
           pr.number === params.number
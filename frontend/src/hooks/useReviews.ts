@@ -0,0 +1,33 @@
+import { useQuery } from '@tanstack/react-query';
+import { fetchReviewHistory, fetchReviewDiff, fetchReviewLog, type ReviewHistoryParams } from '@/api/reviews';
+import { PR_POLL_INTERVAL, PR_STALE_TIME } from '@/utils/constants';
+
+export function useReviewHistory(params: ReviewHistoryParams, enabled: boolean) {
+  return useQuery({
+    queryKey: ['reviews', params.host, params.owner, params.repo, params.number],
+    queryFn: () => fetchReviewHistory(params),
+    enabled,
+    refetchInterval: PR_POLL_INTERVAL,
+    staleTime: PR_STALE_TIME,
+  });
+}
+
+export function useReviewDiff(from: number | null, to: number | null) {
+  return useQuery({
+    queryKey: ['review-diff', from, to],
+    queryFn: () => fetchReviewDiff(from as number, to as number),
+    enabled: from !== null && to !== null,
+    // Finished reviews never change
+    staleTime: Infinity,
+  });
+}
+
+export function useReviewLog(params: ReviewHistoryParams, reviewId?: number) {
+  return useQuery({
+    queryKey: ['review-log', params.host, params.owner, params.repo, params.number, reviewId ?? 'latest'],
+    queryFn: () => fetchReviewLog(params, reviewId),
+    // Keep following the log while the run is still generating
+    refetchInterval: (query) => (query.state.data?.status === 'generating' ? PR_POLL_INTERVAL : false),
+    staleTime: PR_STALE_TIME,
+  });
+}
@@ -2,9 +2,13 @@ import { create } from 'zustand';
 import { devtools, persist } from 'zustand/middleware';
 
 // UI Store - persisted in localStorage
+export type DashboardTab = 'open' | 'history';
+
 interface UIStore {
   priorityQueueCollapsed: boolean;
   togglePriorityQueue: () => void;
+  activeTab: DashboardTab;
+  setActiveTab: (tab: DashboardTab) => void;
 }
 
 export const useUIStore = create<UIStore>()(
@@ -16,6 +20,8 @@ export const useUIStore = create<UIStore>()(
           set((state) => ({
             priorityQueueCollapsed: !state.priorityQueueCollapsed,
           })),
+        activeTab: 'open',
+        setActiveTab: (tab) => set({ activeTab: tab }),
       }),
       { name: 'UIStore' }
     ),
@@ -0,0 +1,39 @@
+export type EventType =
+  | 'discovered'
+  | 'review_requested'
+  | 'commit_pushed'
+  | 'generating'
+  | 'completed'
+  | 'error'
+  | 'cancelled'
+  | 'ci_changed'
+  | 'approved'
+  | 'closed'
+  | 'reopened';
+
+export interface PREvent {
+  id: number;
+  pr_id: number;
+  host: string;
+  owner: string;
+  repo: string;
+  number: number;
+  type: EventType;
+  commit_sha: string; // Empty if not known
+  detail: string;
+  created_at: string;
+}
+
+export interface Timeline {
+  id: number;
+  host: string;
+  owner: string;
+  repo: string;
+  number: number;
+  title: string;
+  author: string;
+  status: string;
+  closed_at: string | null;
+  merged_at: string | null;
+  events: PREvent[]; // Oldest first
+}
@@ -1,4 +1,7 @@
 export interface PR {
+  id: number;
+  host: string;
+  account: string;
   owner: string;
   repo: string;
   number: number;
@@ -7,7 +10,7 @@ export interface PR {
   review_html_path: string;
   github_url: string;
   review_url: string;
-  status: 'pending' | 'generating' | 'completed' | 'error';
+  status: 'pending' | 'generating' | 'completed' | 'error' | 'failed' | 'cancelled';
   title: string;
   author: string;
   generating_since: string | null;
@@ -19,4 +22,10 @@ export interface PR {
   ci_state: 'success' | 'failure' | 'pending' | 'unknown';
   ci_failed_checks: string[];
   created_at: string | null;
+  queue_position: number | null;
+  review_attempts: number;
+  last_error: string;
+  next_retry_at: string | null;
+  closed_at: string | null; // Set once the PR is closed or merged and archived
+  merged_at: string | null;
 }
@@ -1,4 +1,5 @@
 export interface PrioritizedPR {
+  host: string;
   owner: string;
   repo: string;
   number: number;
@@ -15,6 +16,7 @@ export interface PrioritizedPR {
   review_count: number;
   approval_count: number;
   my_review_status: string;
+  requested_me: boolean;
   github_url: string;
   review_url: string;
   created_at: string;
@@ -0,0 +1,29 @@
+export interface Review {
+  id: number;
+  commit_sha: string;
+  generator: string;
+  started_at: string;
+  finished_at: string | null;
+  status: 'generating' | 'completed' | 'error' | 'cancelled';
+  review_url: string; // Empty if the run produced no review
+  stderr_excerpt: string;
+  exit_code: number | null; // Null if no process ran or it hasn't finished
+  has_log: boolean;
+  current: boolean; // The review the dashboard links to
+}
+
+export interface ReviewLog extends Review {
+  log: string;
+  log_truncated: boolean; // Only the end of the log is included
+}
+
+export interface ReviewDiffLine {
+  op: ' ' | '-' | '+';
+  text: string;
+}
+
+export interface ReviewDiff {
+  from: Review;
+  to: Review;
+  lines: ReviewDiffLine[];
+}
@@ -3,6 +3,8 @@ export interface StatusCounts {
   generating: number;
   pending: number;
   error: number;
+  failed: number;
+  cancelled: number;
 }
 
 export interface RecentCompletion {
@@ -12,6 +14,7 @@ export interface RecentCompletion {
 }
 
 export interface RateLimitInfo {
+  account: string;
   remaining: number;
   limit: number;
   reset_at: string;
@@ -19,14 +22,44 @@ export interface RateLimitInfo {
   error: string;
 }
 
+export interface SearchStat {
+  account: string;
+  kind: 'review_requested' | 'authored';
+  query: string;
+  total: number;
+  fetched: number;
+  truncated: boolean;
+}
+
+export interface DeferredAccount {
+  account: string;
+  until: string;
+}
+
+export interface RunningReview {
+  host: string;
+  owner: string;
+  repo: string;
+  number: number;
+  pid: number;
+  duration_seconds: number;
+}
+
 export interface ServerStatus {
   uptime_seconds: number;
   cbpr_running: boolean;
   cbpr_duration_seconds: number;
+  review_generator: 'cbpr' | 'command' | 'none';
+  running_reviews: RunningReview[];
+  queued_reviews: number;
   counts: StatusCounts;
   recent_completions: RecentCompletion[];
   missing_metadata_count: number;
   timestamp: number;
   seconds_until_next_poll: number;
   rate_limit: RateLimitInfo;
+  rate_limits: RateLimitInfo[];
+  search_stats: SearchStat[];
+  search_truncated: boolean;
+  deferred_accounts: DeferredAccount[];
 }
@@ -2,6 +2,7 @@
 export const PR_POLL_INTERVAL = 5000; // 5 seconds
 export const STATUS_POLL_INTERVAL = 5000; // 5 seconds
 export const PRIORITY_POLL_INTERVAL = 30000; // 30 seconds
+export const ARCHIVED_PR_POLL_INTERVAL = 30000; // 30 seconds
 
 // React Query stale time
 export const PR_STALE_TIME = 2000; // 2s
@@ -0,0 +1,148 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// installationTokenEarlyExpiry refreshes installation tokens this long before GitHub expires them
+const installationTokenEarlyExpiry = 5 * time.Minute
+
+// appTokenSource mints GitHub App installation tokens. Each call to Token signs a short-lived
+// app JWT and exchanges it for an installation token (valid for one hour).
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	tokenURL       string
+	httpClient     *http.Client
+}
+
+// NewAppTokenSource returns a token source that authenticates as a GitHub App installation.
+// privateKeyPEM is the app's private key as downloaded from GitHub; apiURL is the REST base URL
+// (e.g. DefaultAPIURL or a GHES <host>/api/v3/). Tokens are cached and refreshed before they expire.
+func NewAppTokenSource(appID, installationID int64, privateKeyPEM []byte, apiURL string) (oauth2.TokenSource, error) {
+	key, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+
+	src := &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		tokenURL:       fmt.Sprintf("%sapp/installations/%d/access_tokens", apiURL, installationID),
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, src, installationTokenEarlyExpiry), nil
+}
+
+// Token exchanges a freshly signed app JWT for an installation token
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.signJWT(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.tokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installation token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("installation token request for installation %d returned %d: %s", s.installationID, resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse installation token response: %w", err)
+	}
+	if result.Token == "" {
+		return nil, errors.New("installation token response did not include a token")
+	}
+
+	return &oauth2.Token{
+		AccessToken: result.Token,
+		TokenType:   "Bearer",
+		Expiry:      result.ExpiresAt,
+	}, nil
+}
+
+// signJWT builds the RS256 JWT GitHub expects when authenticating as the app itself.
+// iat is backdated a minute to allow for clock drift; GitHub rejects expirations over 10 minutes out.
+func (s *appTokenSource) signJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": fmt.Sprintf("%d", s.appID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey accepts PKCS#1 ("RSA PRIVATE KEY", what GitHub issues) and PKCS#8 PEM keys
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
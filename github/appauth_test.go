@@ -0,0 +1,104 @@
+package github_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-review-server/github"
+	"pr-review-server/github/githubtest"
+)
+
+const installationTokenRoute = "POST /app/installations/{id}/access_tokens"
+
+// newAppServer starts a githubtest server that only accepts JWTs signed by the returned PEM key for app 123
+func newAppServer(t *testing.T) (*githubtest.Server, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	srv := githubtest.NewServer(github.NewFake("me"))
+	t.Cleanup(srv.Close)
+	srv.SetAppKey(123, &key.PublicKey)
+	return srv, keyPEM
+}
+
+// TestAppTokenSource tests that the client authenticates with a minted installation token and reuses it
+func TestAppTokenSource(t *testing.T) {
+	srv, keyPEM := newAppServer(t)
+
+	ts, err := github.NewAppTokenSource(123, 456, keyPEM, srv.APIURL())
+	if err != nil {
+		t.Fatalf("NewAppTokenSource failed: %v", err)
+	}
+	client, err := github.NewClientWithTokenSource(ts, "me", srv.APIURL(), srv.GraphQLURL())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetRateLimitInfo(ctx); err != nil {
+			t.Fatalf("GetRateLimitInfo failed: %v", err)
+		}
+	}
+
+	if got := srv.RequestCount(installationTokenRoute); got != 1 {
+		t.Errorf("Expected one installation token to be minted, got %d", got)
+	}
+	if got := srv.LastAuthorization("GET /rate_limit"); !strings.HasPrefix(got, "Bearer ghs_456_") {
+		t.Errorf("Expected API requests to use the installation token, got %q", got)
+	}
+}
+
+// TestAppTokenSource_Refresh tests that tokens close to expiry are replaced with fresh ones
+func TestAppTokenSource_Refresh(t *testing.T) {
+	srv, keyPEM := newAppServer(t)
+	srv.InstallationTokenTTL = time.Minute // inside the refresh window, so every use mints a new token
+
+	ts, err := github.NewAppTokenSource(123, 456, keyPEM, srv.APIURL())
+	if err != nil {
+		t.Fatalf("NewAppTokenSource failed: %v", err)
+	}
+
+	first, err := ts.Token()
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	second, err := ts.Token()
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if first.AccessToken == second.AccessToken {
+		t.Errorf("Expected an expiring token to be refreshed, got %q twice", first.AccessToken)
+	}
+}
+
+// TestAppTokenSource_WrongKey tests that a JWT signed with another key is rejected
+func TestAppTokenSource_WrongKey(t *testing.T) {
+	srv, _ := newAppServer(t)
+	_, otherPEM := newAppServer(t)
+
+	ts, err := github.NewAppTokenSource(123, 456, otherPEM, srv.APIURL())
+	if err != nil {
+		t.Fatalf("NewAppTokenSource failed: %v", err)
+	}
+	if _, err := ts.Token(); err == nil {
+		t.Error("Expected token request signed with the wrong key to fail")
+	}
+}
+
+// TestNewAppTokenSource_InvalidKey tests that malformed private keys are rejected up front
+func TestNewAppTokenSource_InvalidKey(t *testing.T) {
+	if _, err := github.NewAppTokenSource(123, 456, []byte("not a key"), github.DefaultAPIURL); err == nil {
+		t.Error("Expected an error for a non-PEM private key")
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v57/github"
@@ -15,21 +19,62 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// Default endpoints for github.com
+const (
+	DefaultAPIURL     = "https://api.github.com/"
+	DefaultGraphQLURL = "https://api.github.com/graphql"
+	DefaultWebURL     = "https://github.com"
+)
+
+// PRWebURL builds the HTML link for a PR on the given GitHub web host (github.com if empty)
+func PRWebURL(webURL, owner, repo string, number int) string {
+	if webURL == "" {
+		webURL = DefaultWebURL
+	}
+	return fmt.Sprintf("%s/%s/%s/pull/%d", strings.TrimSuffix(webURL, "/"), owner, repo, number)
+}
+
 type Client struct {
 	gh         *github.Client
 	ghv4       *githubv4.Client
 	httpClient *http.Client
-	token      string
+	graphqlURL string
 	username   string
+
+	searchStatsMu sync.Mutex
+	searchStats   map[string]SearchStats // search kind -> counts from the latest search
+
+	rateLimit *rateLimitTransport
+}
+
+// Search kinds reported by GetSearchStats
+const (
+	SearchReviewRequested = "review_requested"
+	SearchAuthored        = "authored"
+)
+
+// searchPageSize is the maximum page size GitHub allows for search
+const searchPageSize = 100
+
+// SearchStats compares how many PRs a search matched with how many were fetched.
+// GitHub stops returning search results after 1000, so Fetched < Total means PRs were dropped.
+type SearchStats struct {
+	Kind    string // SearchReviewRequested or SearchAuthored
+	Query   string
+	Total   int // issueCount reported by GitHub
+	Fetched int // PRs actually returned
 }
 
 type RateLimitInfo struct {
 	Limit     int
 	Remaining int
 	ResetTime time.Time
+	GraphQL   *RateLimitInfo // GraphQL API limit, counted separately from REST; nil if not reported
 }
 
 type PullRequest struct {
+	Host      string // GitHub host; set by the poller from the account that fetched the PR
+	Account   string // Account ("username@host") that fetched the PR; set by the poller
 	Owner     string
 	Repo      string
 	Number    int
@@ -50,11 +95,33 @@ type PRReviewData struct {
 	MyReviewStatus string // "APPROVED", "CHANGES_REQUESTED", "COMMENTED", or ""
 }
 
+// PRState holds the fields the poller checks on every tracked PR each cycle
+type PRState struct {
+	Owner    string
+	Repo     string
+	Number   int
+	State    string // "OPEN", "CLOSED", or "MERGED"
+	Merged   bool
+	HeadSHA  string
+	Draft    bool
+	Title    string
+	Author   string
+	ClosedAt *time.Time // When the PR was closed or merged; nil while open
+	MergedAt *time.Time // nil unless merged
+}
+
+// IsOpen reports whether the PR is still open (not closed or merged)
+func (s *PRState) IsOpen() bool {
+	return s.State == "OPEN"
+}
+
 // PRDetails holds detailed information for prioritization
 type PRDetails struct {
 	Owner            string
 	Repo             string
 	Number           int
+	Title            string
+	Author           string
 	CreatedAt        time.Time
 	Additions        int
 	Deletions        int
@@ -63,6 +130,14 @@ type PRDetails struct {
 	RequestedMe      bool // Whether the user is explicitly requested
 }
 
+// CommitRef identifies a specific commit of a PR, used to look up CI status
+type CommitRef struct {
+	Owner     string
+	Repo      string
+	Number    int
+	CommitSHA string
+}
+
 // CIStatus holds CI check run status for a PR
 type CIStatus struct {
 	Owner        string
@@ -73,19 +148,57 @@ type CIStatus struct {
 }
 
 func NewClient(token, username string) *Client {
-	ctx := context.Background()
+	// Default endpoints are known-good, so this cannot fail
+	client, _ := NewClientWithEndpoints(token, username, DefaultAPIURL, DefaultGraphQLURL)
+	return client
+}
+
+// NewClientWithEndpoints creates a client that talks to the given REST base URL and GraphQL endpoint
+// instead of api.github.com. apiURL is used as-is (no /api/v3/ suffix is added).
+func NewClientWithEndpoints(token, username, apiURL, graphqlURL string) (*Client, error) {
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	tc := oauth2.NewClient(ctx, ts)
+	return NewClientWithTokenSource(ts, username, apiURL, graphqlURL)
+}
 
-	return &Client{
-		gh:         github.NewClient(tc),
-		ghv4:       githubv4.NewClient(tc),
-		httpClient: tc,
-		token:      token,
-		username:   username,
+// NewClientWithTokenSource creates a client that authenticates every request with tokens from ts,
+// e.g. a GitHub App installation token source from NewAppTokenSource.
+// username is still used for the review-requested: and author: searches.
+func NewClientWithTokenSource(ts oauth2.TokenSource, username, apiURL, graphqlURL string) (*Client, error) {
+	baseURL, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub API URL %q: %w", apiURL, err)
 	}
+	// go-github requires a trailing slash on the base URL
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+	if _, err := url.Parse(graphqlURL); err != nil {
+		return nil, fmt.Errorf("invalid GitHub GraphQL URL %q: %w", graphqlURL, err)
+	}
+
+	// Requests flow oauth2 (adds the token) -> rate limit backoff -> network
+	rateLimit := &rateLimitTransport{base: http.DefaultTransport}
+	tc := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: oauth2.ReuseTokenSource(nil, ts),
+			Base:   rateLimit,
+		},
+	}
+
+	gh := github.NewClient(tc)
+	gh.BaseURL = baseURL
+
+	return &Client{
+		gh:          gh,
+		ghv4:        githubv4.NewEnterpriseClient(graphqlURL, tc),
+		httpClient:  tc,
+		graphqlURL:  graphqlURL,
+		username:    username,
+		searchStats: make(map[string]SearchStats),
+		rateLimit:   rateLimit,
+	}, nil
 }
 
 func (c *Client) GetPRsRequestingReview(ctx context.Context) ([]PullRequest, error) {
@@ -93,122 +206,233 @@ func (c *Client) GetPRsRequestingReview(ctx context.Context) ([]PullRequest, err
 	query := fmt.Sprintf("type:pr state:open review-requested:%s", c.username)
 	log.Printf("GitHub search query: %s", query)
 
-	opts := &github.SearchOptions{
-		ListOptions: github.ListOptions{PerPage: 100},
+	prs, err := c.searchPullRequests(ctx, SearchReviewRequested, query)
+	if err != nil {
+		log.Printf("GitHub search error: %v", err)
+		return nil, err
 	}
 
-	result, resp, err := c.gh.Search.Issues(ctx, query, opts)
+	for _, pr := range prs {
+		log.Printf("Found PR: %s/%s#%d - %s", pr.Owner, pr.Repo, pr.Number, pr.Title)
+	}
+	return prs, nil
+}
+
+func (c *Client) GetMyOpenPRs(ctx context.Context) ([]PullRequest, error) {
+	// Search for PRs authored by the user that are open
+	query := fmt.Sprintf("type:pr state:open author:%s", c.username)
+	log.Printf("GitHub search query (my PRs): %s", query)
+
+	prs, err := c.searchPullRequests(ctx, SearchAuthored, query)
 	if err != nil {
-		log.Printf("GitHub search error: %v", err)
+		log.Printf("GitHub search error (my PRs): %v", err)
 		return nil, err
 	}
 
-	log.Printf("GitHub search returned %d total results (rate limit: %d/%d remaining)",
-		result.GetTotal(), resp.Rate.Remaining, resp.Rate.Limit)
+	for _, pr := range prs {
+		log.Printf("Found my PR: %s/%s#%d - %s", pr.Owner, pr.Repo, pr.Number, pr.Title)
+	}
+	return prs, nil
+}
+
+// GetSearchStats returns total vs fetched counts from the latest review-requested and authored searches
+func (c *Client) GetSearchStats() []SearchStats {
+	c.searchStatsMu.Lock()
+	defer c.searchStatsMu.Unlock()
 
+	stats := make([]SearchStats, 0, len(c.searchStats))
+	for _, s := range c.searchStats {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Kind > stats[j].Kind })
+	return stats
+}
+
+// searchPullRequests runs an issue search through GraphQL, following the cursor until every page is fetched.
+// Head SHA, draft state and created_at come back with each hit so no follow-up PullRequests.Get call is needed.
+func (c *Client) searchPullRequests(ctx context.Context, kind, searchQuery string) ([]PullRequest, error) {
 	var prs []PullRequest
-	for _, issue := range result.Issues {
-		if issue.PullRequestLinks == nil {
-			continue
+	var cursor *string
+	total, pages := 0, 0
+	for {
+		page, err := c.searchPullRequestsPage(ctx, searchQuery, cursor)
+		if err != nil {
+			return nil, err
 		}
+		pages++
+		total = page.issueCount
+		prs = append(prs, page.prs...)
 
-		// Extract owner and repo from repository URL
-		// RepositoryURL format: https://api.github.com/repos/{owner}/{repo}
-		repoURL := issue.GetRepositoryURL()
-		parts := strings.Split(repoURL, "/")
-		if len(parts) < 2 {
-			log.Printf("Invalid repository URL: %s", repoURL)
-			continue
+		// An empty page would never advance the cursor
+		if !page.hasNextPage || page.endCursor == "" || page.nodeCount == 0 {
+			break
 		}
-		repoOwner := parts[len(parts)-2]
-		repoName := parts[len(parts)-1]
-		prNumber := issue.GetNumber()
-
-		log.Printf("Found PR: %s/%s#%d - %s", repoOwner, repoName, prNumber, issue.GetTitle())
+		cursor = &page.endCursor
+	}
 
-		// Get the PR to fetch the HEAD commit SHA
-		pr, _, err := c.gh.PullRequests.Get(ctx, repoOwner, repoName, prNumber)
-		if err != nil {
-			log.Printf("Error fetching PR details for %s/%s#%d: %v", repoOwner, repoName, prNumber, err)
-			continue // Skip this PR if we can't fetch it
-		}
-
-		createdAt := pr.GetCreatedAt().Time
-		prs = append(prs, PullRequest{
-			Owner:     repoOwner,
-			Repo:      repoName,
-			Number:    prNumber,
-			CommitSHA: pr.GetHead().GetSHA(),
-			Title:     pr.GetTitle(),
-			URL:       pr.GetHTMLURL(),
-			Author:    pr.GetUser().GetLogin(),
-			CreatedAt: &createdAt,
-			Draft:     pr.GetDraft(),
-		})
+	log.Printf("GitHub search fetched %d/%d results in %d page(s)", len(prs), total, pages)
+	if len(prs) < total {
+		log.Printf("WARNING: GitHub search for %q was truncated: fetched %d of %d results", searchQuery, len(prs), total)
 	}
 
+	c.searchStatsMu.Lock()
+	c.searchStats[kind] = SearchStats{Kind: kind, Query: searchQuery, Total: total, Fetched: len(prs)}
+	c.searchStatsMu.Unlock()
+
 	return prs, nil
 }
 
-func (c *Client) GetMyOpenPRs(ctx context.Context) ([]PullRequest, error) {
-	// Search for PRs authored by the user that are open
-	query := fmt.Sprintf("type:pr state:open author:%s", c.username)
-	log.Printf("GitHub search query (my PRs): %s", query)
+// searchPage is one page of GraphQL search results
+type searchPage struct {
+	prs         []PullRequest
+	nodeCount   int
+	issueCount  int
+	hasNextPage bool
+	endCursor   string
+}
 
-	opts := &github.SearchOptions{
-		ListOptions: github.ListOptions{PerPage: 100},
+// searchPullRequestsPage fetches the page of search results after cursor (the first page if nil)
+func (c *Client) searchPullRequestsPage(ctx context.Context, searchQuery string, cursor *string) (*searchPage, error) {
+	const query = `query($searchQuery: String!, $pageSize: Int!, $cursor: String) {
+		search(query: $searchQuery, type: ISSUE, first: $pageSize, after: $cursor) {
+			issueCount
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			nodes {
+				... on PullRequest {
+					number
+					title
+					url
+					isDraft
+					createdAt
+					headRefOid
+					author {
+						login
+					}
+					repository {
+						name
+						owner {
+							login
+						}
+					}
+				}
+			}
+		}
+		rateLimit {
+			limit
+			remaining
+			cost
+		}
+	}`
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": map[string]interface{}{"searchQuery": searchQuery, "pageSize": searchPageSize, "cursor": cursor},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GraphQL query: %w", err)
 	}
 
-	result, resp, err := c.gh.Search.Issues(ctx, query, opts)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphqlURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		log.Printf("GitHub search error (my PRs): %v", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to build HTTP request: %w", err)
 	}
 
-	log.Printf("GitHub search returned %d of my open PRs (rate limit: %d/%d remaining)",
-		result.GetTotal(), resp.Rate.Remaining, resp.Rate.Limit)
+	req.Header.Set("Content-Type", "application/json")
 
-	var prs []PullRequest
-	for _, issue := range result.Issues {
-		if issue.PullRequestLinks == nil {
-			continue
-		}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL search: %w", err)
+	}
+	defer resp.Body.Close()
 
-		// Extract owner and repo from repository URL
-		repoURL := issue.GetRepositoryURL()
-		parts := strings.Split(repoURL, "/")
-		if len(parts) < 2 {
-			log.Printf("Invalid repository URL: %s", repoURL)
-			continue
-		}
-		repoOwner := parts[len(parts)-2]
-		repoName := parts[len(parts)-1]
-		prNumber := issue.GetNumber()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GraphQL search failed with status %d", resp.StatusCode)
+	}
 
-		log.Printf("Found my PR: %s/%s#%d - %s", repoOwner, repoName, prNumber, issue.GetTitle())
+	type Login struct {
+		Login string `json:"login"`
+	}
+	type PRNode struct {
+		Number     int       `json:"number"`
+		Title      string    `json:"title"`
+		URL        string    `json:"url"`
+		IsDraft    bool      `json:"isDraft"`
+		CreatedAt  time.Time `json:"createdAt"`
+		HeadRefOid string    `json:"headRefOid"`
+		Author     *Login    `json:"author"`
+		Repository struct {
+			Name  string `json:"name"`
+			Owner Login  `json:"owner"`
+		} `json:"repository"`
+	}
+	type GraphQLResponse struct {
+		Data struct {
+			Search struct {
+				IssueCount int `json:"issueCount"`
+				PageInfo   struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []PRNode `json:"nodes"`
+			} `json:"search"`
+			RateLimit struct {
+				Limit     int `json:"limit"`
+				Remaining int `json:"remaining"`
+				Cost      int `json:"cost"`
+			} `json:"rateLimit"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
 
-		// Get the PR to fetch the HEAD commit SHA
-		pr, _, err := c.gh.PullRequests.Get(ctx, repoOwner, repoName, prNumber)
-		if err != nil {
-			log.Printf("Error fetching my PR details for %s/%s#%d: %v", repoOwner, repoName, prNumber, err)
+	var graphqlResp GraphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&graphqlResp); err != nil {
+		return nil, fmt.Errorf("failed to decode GraphQL response: %w", err)
+	}
+	if len(graphqlResp.Errors) > 0 {
+		return nil, fmt.Errorf("GraphQL search error: %s", graphqlResp.Errors[0].Message)
+	}
+
+	search := graphqlResp.Data.Search
+	rate := graphqlResp.Data.RateLimit
+	log.Printf("GitHub search page returned %d of %d total results (GraphQL rate limit: %d/%d remaining, cost %d)",
+		len(search.Nodes), search.IssueCount, rate.Remaining, rate.Limit, rate.Cost)
+
+	page := &searchPage{
+		nodeCount:   len(search.Nodes),
+		issueCount:  search.IssueCount,
+		hasNextPage: search.PageInfo.HasNextPage,
+		endCursor:   search.PageInfo.EndCursor,
+	}
+	for _, node := range search.Nodes {
+		// Non-PR nodes (issues) decode with a zero number
+		if node.Number == 0 {
 			continue
 		}
 
-		createdAt := pr.GetCreatedAt().Time
-		prs = append(prs, PullRequest{
-			Owner:     repoOwner,
-			Repo:      repoName,
-			Number:    prNumber,
-			CommitSHA: pr.GetHead().GetSHA(),
-			Title:     pr.GetTitle(),
-			URL:       pr.GetHTMLURL(),
-			Author:    pr.GetUser().GetLogin(),
+		author := ""
+		if node.Author != nil {
+			author = node.Author.Login
+		}
+		createdAt := node.CreatedAt
+		page.prs = append(page.prs, PullRequest{
+			Owner:     node.Repository.Owner.Login,
+			Repo:      node.Repository.Name,
+			Number:    node.Number,
+			CommitSHA: node.HeadRefOid,
+			Title:     node.Title,
+			URL:       node.URL,
+			Author:    author,
 			CreatedAt: &createdAt,
-			Draft:     pr.GetDraft(),
+			Draft:     node.IsDraft,
 		})
 	}
 
-	return prs, nil
+	return page, nil
 }
 
 // IsPROpen checks if a PR is currently open (not closed or merged)
@@ -294,30 +518,19 @@ func (c *Client) GetRateLimitInfo(ctx context.Context) (*RateLimitInfo, error) {
 	}
 
 	core := limits.GetCore()
-	return &RateLimitInfo{
+	info := &RateLimitInfo{
 		Limit:     core.Limit,
 		Remaining: core.Remaining,
 		ResetTime: core.Reset.Time,
-	}, nil
-}
-
-// IsRateLimited checks if we're currently rate limited (has few or no requests remaining)
-func (c *Client) IsRateLimited(ctx context.Context) bool {
-	info, err := c.GetRateLimitInfo(ctx)
-	if err != nil {
-		log.Printf("[RATE_LIMIT] Warning: Failed to check rate limit: %v", err)
-		return false // Assume not rate limited if we can't check
 	}
-
-	// Consider rate limited if we have less than 10 requests remaining
-	// or if we're completely out
-	isLimited := info.Remaining < 10
-	if isLimited {
-		resetIn := time.Until(info.ResetTime)
-		log.Printf("[RATE_LIMIT] WARNING: Rate limit low! %d/%d requests remaining (resets in %v at %s)",
-			info.Remaining, info.Limit, resetIn.Round(time.Minute), info.ResetTime.Format("15:04:05 MST"))
+	if graphql := limits.GetGraphQL(); graphql != nil {
+		info.GraphQL = &RateLimitInfo{
+			Limit:     graphql.Limit,
+			Remaining: graphql.Remaining,
+			ResetTime: graphql.Reset.Time,
+		}
 	}
-	return isLimited
+	return info, nil
 }
 
 // GetApprovalCount returns the number of current approvals on a PR
@@ -448,12 +661,11 @@ func (c *Client) fetchReviewDataForRepo(ctx context.Context, prs []PullRequest)
 		return nil, fmt.Errorf("failed to marshal GraphQL query: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.github.com/graphql", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphqlURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to build HTTP request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
@@ -609,6 +821,10 @@ func (c *Client) fetchDetailsForRepo(ctx context.Context, prs []PullRequest) (ma
 			%s: repository(owner: "%s", name: "%s") {
 				pullRequest(number: %d) {
 					number
+					title
+					author {
+						login
+					}
 					createdAt
 					additions
 					deletions
@@ -643,12 +859,11 @@ func (c *Client) fetchDetailsForRepo(ctx context.Context, prs []PullRequest) (ma
 		return nil, fmt.Errorf("failed to marshal GraphQL query: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.github.com/graphql", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphqlURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to build HTTP request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
@@ -682,6 +897,8 @@ func (c *Client) fetchDetailsForRepo(ctx context.Context, prs []PullRequest) (ma
 	}
 	type PRData struct {
 		Number         int                `json:"number"`
+		Title          string             `json:"title"`
+		Author         *ReviewAuthor      `json:"author"`
 		CreatedAt      string             `json:"createdAt"`
 		Additions      int                `json:"additions"`
 		Deletions      int                `json:"deletions"`
@@ -738,11 +955,19 @@ func (c *Client) fetchDetailsForRepo(ctx context.Context, prs []PullRequest) (ma
 			}
 		}
 
+		// Deleted users (ghost) have a nil author
+		author := ""
+		if prData.Author != nil {
+			author = prData.Author.Login
+		}
+
 		key := fmt.Sprintf("%s/%s/%d", owner, repo, prNumber)
 		results[key] = &PRDetails{
 			Owner:        owner,
 			Repo:         repo,
 			Number:       prNumber,
+			Title:        prData.Title,
+			Author:       author,
 			CreatedAt:    createdAt,
 			Additions:    prData.Additions,
 			Deletions:    prData.Deletions,
@@ -758,8 +983,178 @@ func (c *Client) fetchDetailsForRepo(ctx context.Context, prs []PullRequest) (ma
 	return results, nil
 }
 
+// BatchGetPRStates fetches state, head commit, draft flag, title and author for multiple PRs using GraphQL.
+// Groups PRs by repository and makes one query per repository. PRs that can't be resolved (deleted,
+// or no longer accessible) are left out of the result.
+// Returns a map of "owner/repo/number" -> PRState
+func (c *Client) BatchGetPRStates(ctx context.Context, prs []PullRequest) (map[string]*PRState, error) {
+	if len(prs) == 0 {
+		return make(map[string]*PRState), nil
+	}
+
+	// Group PRs by repository
+	prsByRepo := make(map[string][]PullRequest)
+	for _, pr := range prs {
+		key := fmt.Sprintf("%s/%s", pr.Owner, pr.Repo)
+		prsByRepo[key] = append(prsByRepo[key], pr)
+	}
+
+	results := make(map[string]*PRState)
+
+	// Fetch states for each repository
+	for repoKey, repoPRs := range prsByRepo {
+		log.Printf("[GRAPHQL] Fetching state for %d PRs in %s", len(repoPRs), repoKey)
+
+		repoData, err := c.fetchStatesForRepo(ctx, repoPRs)
+		if err != nil {
+			// A rate limit backoff applies to every repo, so stop instead of failing each one
+			var rateLimited *RateLimitedError
+			if errors.As(err, &rateLimited) {
+				return results, err
+			}
+			log.Printf("[GRAPHQL] Error fetching state for %s: %v", repoKey, err)
+			// Continue with other repos even if one fails
+			continue
+		}
+
+		// Merge results
+		for k, v := range repoData {
+			results[k] = v
+		}
+	}
+
+	log.Printf("[GRAPHQL] Successfully fetched state for %d/%d PRs", len(results), len(prs))
+	return results, nil
+}
+
+// fetchStatesForRepo fetches PR states for all PRs in a single repository using GraphQL
+func (c *Client) fetchStatesForRepo(ctx context.Context, prs []PullRequest) (map[string]*PRState, error) {
+	if len(prs) == 0 {
+		return make(map[string]*PRState), nil
+	}
+
+	owner := prs[0].Owner
+	repo := prs[0].Repo
+
+	// Build a single GraphQL query with aliases for all PRs in this repo
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("query {")
+
+	prAliases := make(map[string]int) // alias -> PR number
+	for i, pr := range prs {
+		alias := fmt.Sprintf("pr%d", i)
+		prAliases[alias] = pr.Number
+		queryBuilder.WriteString(fmt.Sprintf(`
+			%s: repository(owner: "%s", name: "%s") {
+				pullRequest(number: %d) {
+					number
+					state
+					merged
+					closedAt
+					mergedAt
+					headRefOid
+					isDraft
+					title
+					author {
+						login
+					}
+				}
+			}
+		`, alias, owner, repo, pr.Number))
+	}
+
+	queryBuilder.WriteString("}")
+
+	// Execute the batched query
+	graphqlQuery := map[string]string{"query": queryBuilder.String()}
+	jsonData, err := json.Marshal(graphqlQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GraphQL query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphqlURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GraphQL query failed with status %d", resp.StatusCode)
+	}
+
+	// Define structs for GraphQL response parsing
+	type Author struct {
+		Login string `json:"login"`
+	}
+	type PRData struct {
+		Number     int        `json:"number"`
+		State      string     `json:"state"`
+		Merged     bool       `json:"merged"`
+		ClosedAt   *time.Time `json:"closedAt"`
+		MergedAt   *time.Time `json:"mergedAt"`
+		HeadRefOid string     `json:"headRefOid"`
+		IsDraft    bool       `json:"isDraft"`
+		Title      string     `json:"title"`
+		Author     *Author    `json:"author"`
+	}
+	type RepoData struct {
+		// nil when the PR can't be resolved; GitHub reports a NOT_FOUND error alongside
+		PullRequest *PRData `json:"pullRequest"`
+	}
+	type GraphQLResponse struct {
+		Data map[string]*RepoData `json:"data"`
+	}
+
+	var graphqlResp GraphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&graphqlResp); err != nil {
+		return nil, fmt.Errorf("failed to decode GraphQL response: %w", err)
+	}
+
+	// Parse results
+	results := make(map[string]*PRState)
+	for alias, prNumber := range prAliases {
+		repoData, ok := graphqlResp.Data[alias]
+		if !ok || repoData == nil || repoData.PullRequest == nil {
+			log.Printf("[GRAPHQL] Warning: Could not resolve PR %s/%s#%d", owner, repo, prNumber)
+			continue
+		}
+
+		prData := repoData.PullRequest
+
+		// Deleted users (ghost) have a nil author
+		author := ""
+		if prData.Author != nil {
+			author = prData.Author.Login
+		}
+
+		key := fmt.Sprintf("%s/%s/%d", owner, repo, prNumber)
+		results[key] = &PRState{
+			Owner:    owner,
+			Repo:     repo,
+			Number:   prNumber,
+			State:    prData.State,
+			Merged:   prData.Merged,
+			HeadSHA:  prData.HeadRefOid,
+			Draft:    prData.IsDraft,
+			Title:    prData.Title,
+			Author:   author,
+			ClosedAt: prData.ClosedAt,
+			MergedAt: prData.MergedAt,
+		}
+	}
+
+	return results, nil
+}
+
 // BatchGetCIStatus fetches CI check status for multiple PRs using GraphQL
-func (c *Client) BatchGetCIStatus(ctx context.Context, prs []struct{ Owner, Repo string; Number int; CommitSHA string }) (map[string]*CIStatus, error) {
+func (c *Client) BatchGetCIStatus(ctx context.Context, prs []CommitRef) (map[string]*CIStatus, error) {
 	if len(prs) == 0 {
 		return make(map[string]*CIStatus), nil
 	}
@@ -768,10 +1163,10 @@ func (c *Client) BatchGetCIStatus(ctx context.Context, prs []struct{ Owner, Repo
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString("query {")
 
-	prAliases := make(map[string]struct{ Owner, Repo string; Number int })
+	prAliases := make(map[string]CommitRef)
 	for i, pr := range prs {
 		alias := fmt.Sprintf("pr%d", i)
-		prAliases[alias] = struct{ Owner, Repo string; Number int }{pr.Owner, pr.Repo, pr.Number}
+		prAliases[alias] = pr
 
 		queryBuilder.WriteString(fmt.Sprintf(`
 			%s: repository(owner: "%s", name: "%s") {
@@ -812,12 +1207,11 @@ func (c *Client) BatchGetCIStatus(ctx context.Context, prs []struct{ Owner, Repo
 		return nil, fmt.Errorf("failed to marshal GraphQL query: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.github.com/graphql", bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphqlURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
@@ -0,0 +1,182 @@
+package github_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"pr-review-server/github"
+	"pr-review-server/github/githubtest"
+)
+
+func newTestClient(t *testing.T, fake *github.Fake) (*github.Client, *githubtest.Server) {
+	t.Helper()
+	srv := githubtest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client, err := github.NewClientWithEndpoints("test-token", "me", srv.APIURL(), srv.GraphQLURL())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, srv
+}
+
+// TestGetPRsRequestingReview tests that search results carry head SHA, draft and created_at from a single GraphQL query
+func TestGetPRsRequestingReview(t *testing.T) {
+	fake := github.NewFake("me")
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "abc1234", Title: "Add caching", Author: "alice", CreatedAt: &createdAt}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "web", Number: 2, CommitSHA: "def5678", Author: "bob", Draft: true}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "web", Number: 3, CommitSHA: "0000000", Author: "carol"}, "someone-else")
+
+	client, srv := newTestClient(t, fake)
+	prs, err := client.GetPRsRequestingReview(context.Background())
+	if err != nil {
+		t.Fatalf("GetPRsRequestingReview failed: %v", err)
+	}
+
+	if len(prs) != 2 {
+		t.Fatalf("Expected 2 PRs requesting my review, got %d", len(prs))
+	}
+	first := prs[0]
+	if first.Owner != "acme" || first.Repo != "api" || first.Number != 1 || first.CommitSHA != "abc1234" ||
+		first.Title != "Add caching" || first.Author != "alice" || first.URL != "https://github.com/acme/api/pull/1" {
+		t.Errorf("Unexpected PR fields: %+v", first)
+	}
+	if first.CreatedAt == nil || !first.CreatedAt.Equal(createdAt) {
+		t.Errorf("Expected created_at %v, got %v", createdAt, first.CreatedAt)
+	}
+	if !prs[1].Draft {
+		t.Errorf("Expected PR #2 to be a draft")
+	}
+
+	if got := srv.RequestCount("POST /graphql"); got != 1 {
+		t.Errorf("Expected a single GraphQL request, got %d", got)
+	}
+	if got := srv.RequestCount("GET /repos/{owner}/{repo}/pulls/{number}"); got != 0 {
+		t.Errorf("Expected no per-PR REST calls, got %d", got)
+	}
+}
+
+// TestBatchGetPRReviewData tests approval counting and my review status over GraphQL
+func TestBatchGetPRReviewData(t *testing.T) {
+	fake := github.NewFake("me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "abc1234"}, "me")
+	fake.AddReview("acme", "api", 1, "bob", "CHANGES_REQUESTED")
+	fake.AddReview("acme", "api", 1, "bob", "APPROVED")
+	fake.AddReview("acme", "api", 1, "carol", "APPROVED")
+	fake.AddReview("acme", "api", 1, "me", "COMMENTED")
+
+	client, _ := newTestClient(t, fake)
+	results, err := client.BatchGetPRReviewData(context.Background(), []github.PullRequest{{Owner: "acme", Repo: "api", Number: 1}})
+	if err != nil {
+		t.Fatalf("BatchGetPRReviewData failed: %v", err)
+	}
+
+	data, ok := results["acme/api/1"]
+	if !ok {
+		t.Fatalf("Expected review data for acme/api/1, got %v", results)
+	}
+	if data.ApprovalCount != 2 {
+		t.Errorf("Expected 2 approvals, got %d", data.ApprovalCount)
+	}
+	if data.MyReviewStatus != "COMMENTED" {
+		t.Errorf("Expected my status COMMENTED, got %q", data.MyReviewStatus)
+	}
+}
+
+// TestBatchGetPRStates tests that state, head and metadata for a repo's PRs come from one GraphQL query,
+// and that unresolvable PRs are left out rather than reported as closed
+func TestBatchGetPRStates(t *testing.T) {
+	fake := github.NewFake("me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "abc1234", Title: "Add caching", Author: "alice"}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 2, CommitSHA: "def5678", Author: "bob", Draft: true}, "me")
+	fake.ClosePR("acme", "api", 1, true)
+
+	client, srv := newTestClient(t, fake)
+	results, err := client.BatchGetPRStates(context.Background(), []github.PullRequest{
+		{Owner: "acme", Repo: "api", Number: 1},
+		{Owner: "acme", Repo: "api", Number: 2},
+		{Owner: "acme", Repo: "api", Number: 99},
+	})
+	if err != nil {
+		t.Fatalf("BatchGetPRStates failed: %v", err)
+	}
+
+	merged := results["acme/api/1"]
+	if merged == nil || merged.State != "MERGED" || !merged.Merged || merged.IsOpen() ||
+		merged.HeadSHA != "abc1234" || merged.Title != "Add caching" || merged.Author != "alice" {
+		t.Errorf("Unexpected state for merged PR: %+v", merged)
+	}
+	if merged != nil && (merged.ClosedAt == nil || merged.MergedAt == nil || !merged.MergedAt.Equal(*merged.ClosedAt)) {
+		t.Errorf("Expected merged PR to report when it was closed and merged, got %v and %v", merged.ClosedAt, merged.MergedAt)
+	}
+	if open := results["acme/api/2"]; open == nil || !open.IsOpen() || !open.Draft || open.HeadSHA != "def5678" || open.ClosedAt != nil {
+		t.Errorf("Unexpected state for open draft PR: %+v", open)
+	}
+	if missing, ok := results["acme/api/99"]; ok {
+		t.Errorf("Expected unresolvable PR to be omitted, got %+v", missing)
+	}
+
+	if got := srv.RequestCount("POST /graphql"); got != 1 {
+		t.Errorf("Expected a single GraphQL request, got %d", got)
+	}
+}
+
+// TestBatchGetCIStatus tests CI rollup parsing, including commits without checks
+func TestBatchGetCIStatus(t *testing.T) {
+	fake := github.NewFake("me")
+	fake.SetCheckState("acme", "api", "abc1234", "failure", "lint", "unit")
+
+	client, _ := newTestClient(t, fake)
+	results, err := client.BatchGetCIStatus(context.Background(), []github.CommitRef{
+		{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "abc1234"},
+		{Owner: "acme", Repo: "api", Number: 2, CommitSHA: "def5678"},
+	})
+	if err != nil {
+		t.Fatalf("BatchGetCIStatus failed: %v", err)
+	}
+
+	if got := results["acme/api/1"]; got == nil || got.State != "failure" || len(got.FailedChecks) != 2 {
+		t.Errorf("Expected failure with 2 failed checks, got %+v", got)
+	}
+	if got := results["acme/api/2"]; got == nil || got.State != "unknown" {
+		t.Errorf("Expected unknown state for commit without checks, got %+v", got)
+	}
+}
+
+// TestGetMyOpenPRs_Pagination tests that all search pages are fetched and truncation is reported
+func TestGetMyOpenPRs_Pagination(t *testing.T) {
+	fake := github.NewFake("me")
+	for i := 1; i <= 250; i++ {
+		fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: i, CommitSHA: fmt.Sprintf("sha%d", i), Author: "me"})
+	}
+
+	client, srv := newTestClient(t, fake)
+	prs, err := client.GetMyOpenPRs(context.Background())
+	if err != nil {
+		t.Fatalf("GetMyOpenPRs failed: %v", err)
+	}
+	if len(prs) != 250 {
+		t.Fatalf("Expected all 250 PRs across pages, got %d", len(prs))
+	}
+	if got := srv.RequestCount("POST /graphql"); got != 3 {
+		t.Errorf("Expected 3 pages of 100, got %d requests", got)
+	}
+
+	// GitHub stops returning search results past its limit; the gap must be visible
+	srv.SearchLimit = 200
+	prs, err = client.GetMyOpenPRs(context.Background())
+	if err != nil {
+		t.Fatalf("GetMyOpenPRs failed: %v", err)
+	}
+	if len(prs) != 200 {
+		t.Fatalf("Expected 200 PRs up to the search limit, got %d", len(prs))
+	}
+
+	stats := client.GetSearchStats()
+	if len(stats) != 1 || stats[0].Kind != github.SearchAuthored || stats[0].Total != 250 || stats[0].Fetched != 200 {
+		t.Errorf("Expected authored search stats 200/250, got %+v", stats)
+	}
+}
@@ -0,0 +1,472 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakePR is a PR held by Fake. Tests seed it via AddPR and mutate it through
+// the helper methods rather than touching the fields directly.
+type FakePR struct {
+	PullRequest
+	State              string // "open" or "closed"
+	Merged             bool
+	ClosedAt           *time.Time // Set by ClosePR
+	Additions          int
+	Deletions          int
+	ChangedFiles       int
+	RequestedReviewers []string
+	Reviews            []FakeReview // chronological order
+}
+
+// FakeReview is a single review submitted on a FakePR
+type FakeReview struct {
+	Login string
+	State string // "APPROVED", "CHANGES_REQUESTED", "COMMENTED", "DISMISSED", "PENDING"
+}
+
+// Fake is an in-memory Provider for tests. It is safe for concurrent use.
+type Fake struct {
+	mu        sync.Mutex
+	username  string
+	prs       map[string]*FakePR
+	ciStatus  map[string]*CIStatus // "owner/repo@sha" -> status
+	rateLimit RateLimitInfo
+	errs      map[string]error       // method name -> error to return
+	calls     map[string]int         // method name -> call count
+	searches  map[string]SearchStats // search kind -> latest counts
+	backoff   time.Time
+}
+
+// NewFake creates an empty Fake for the given user login
+func NewFake(username string) *Fake {
+	return &Fake{
+		username: username,
+		prs:      make(map[string]*FakePR),
+		ciStatus: make(map[string]*CIStatus),
+		rateLimit: RateLimitInfo{
+			Limit:     5000,
+			Remaining: 5000,
+			ResetTime: time.Now().Add(time.Hour),
+		},
+		errs:     make(map[string]error),
+		calls:    make(map[string]int),
+		searches: make(map[string]SearchStats),
+	}
+}
+
+// Compile-time check that Fake implements Provider
+var _ Provider = (*Fake)(nil)
+
+func fakeKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s/%d", owner, repo, number)
+}
+
+func fakeCommitKey(owner, repo, sha string) string {
+	return fmt.Sprintf("%s/%s@%s", owner, repo, sha)
+}
+
+// AddPR adds (or replaces) an open PR. requestedReviewers lists the logins whose review is requested.
+func (f *Fake) AddPR(pr PullRequest, requestedReviewers ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pr.CreatedAt == nil {
+		now := time.Now()
+		pr.CreatedAt = &now
+	}
+	if pr.URL == "" {
+		pr.URL = PRWebURL("", pr.Owner, pr.Repo, pr.Number)
+	}
+	f.prs[fakeKey(pr.Owner, pr.Repo, pr.Number)] = &FakePR{
+		PullRequest:        pr,
+		State:              "open",
+		RequestedReviewers: requestedReviewers,
+	}
+}
+
+// PushCommit moves a PR's HEAD to a new commit SHA
+func (f *Fake) PushCommit(owner, repo string, number int, sha string) {
+	f.update(owner, repo, number, func(pr *FakePR) { pr.CommitSHA = sha })
+}
+
+// AddReview appends a review to a PR
+func (f *Fake) AddReview(owner, repo string, number int, login, state string) {
+	f.update(owner, repo, number, func(pr *FakePR) {
+		pr.Reviews = append(pr.Reviews, FakeReview{Login: login, State: state})
+	})
+}
+
+// ClosePR closes a PR now, optionally marking it as merged
+func (f *Fake) ClosePR(owner, repo string, number int, merged bool) {
+	now := time.Now().UTC().Truncate(time.Second)
+	f.update(owner, repo, number, func(pr *FakePR) {
+		pr.State = "closed"
+		pr.Merged = merged
+		pr.ClosedAt = &now
+	})
+}
+
+// ReopenPR reopens a closed PR
+func (f *Fake) ReopenPR(owner, repo string, number int) {
+	f.update(owner, repo, number, func(pr *FakePR) {
+		pr.State = "open"
+		pr.Merged = false
+		pr.ClosedAt = nil
+	})
+}
+
+// SetDraft toggles a PR's draft flag
+func (f *Fake) SetDraft(owner, repo string, number int, draft bool) {
+	f.update(owner, repo, number, func(pr *FakePR) { pr.Draft = draft })
+}
+
+// SetDiffStats sets the size information returned by BatchGetPRDetails
+func (f *Fake) SetDiffStats(owner, repo string, number, additions, deletions, changedFiles int) {
+	f.update(owner, repo, number, func(pr *FakePR) {
+		pr.Additions = additions
+		pr.Deletions = deletions
+		pr.ChangedFiles = changedFiles
+	})
+}
+
+// SetCheckState sets the CI rollup state for a specific commit.
+// Commits without a check state are reported as "unknown".
+func (f *Fake) SetCheckState(owner, repo, sha, state string, failedChecks ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if failedChecks == nil {
+		failedChecks = []string{}
+	}
+	f.ciStatus[fakeCommitKey(owner, repo, sha)] = &CIStatus{
+		State:        state,
+		FailedChecks: failedChecks,
+	}
+}
+
+// SetRateLimit sets the value returned by GetRateLimitInfo
+func (f *Fake) SetRateLimit(info RateLimitInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rateLimit = info
+}
+
+// SetError makes the named Provider method (e.g. "BatchGetPRStates") return err. Pass nil to clear.
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// CallCount returns how many times the named Provider method has been called
+func (f *Fake) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// Snapshot returns copies of all PRs (open and closed) ordered by owner/repo/number.
+// Unlike the Provider methods it is not counted by CallCount.
+func (f *Fake) Snapshot() []FakePR {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var keys []string
+	for k := range f.prs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	prs := make([]FakePR, 0, len(keys))
+	for _, k := range keys {
+		pr := *f.prs[k]
+		pr.RequestedReviewers = append([]string(nil), pr.RequestedReviewers...)
+		pr.Reviews = append([]FakeReview(nil), pr.Reviews...)
+		prs = append(prs, pr)
+	}
+	return prs
+}
+
+// CheckStatus returns the CI state set via SetCheckState for a commit, if any
+func (f *Fake) CheckStatus(owner, repo, sha string) (state string, failedChecks []string, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status, ok := f.ciStatus[fakeCommitKey(owner, repo, sha)]
+	if !ok {
+		return "", nil, false
+	}
+	return status.State, append([]string(nil), status.FailedChecks...), true
+}
+
+// RateLimit returns the value set via SetRateLimit without counting a call
+func (f *Fake) RateLimit() RateLimitInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rateLimit
+}
+
+// update applies fn to a PR if it exists
+func (f *Fake) update(owner, repo string, number int, fn func(pr *FakePR)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pr, ok := f.prs[fakeKey(owner, repo, number)]; ok {
+		fn(pr)
+	}
+}
+
+// record counts a call and returns the configured error for the method, if any.
+// Callers must hold f.mu.
+func (f *Fake) record(method string) error {
+	f.calls[method]++
+	return f.errs[method]
+}
+
+// sortedPRs returns all PRs matching keep, ordered by key for deterministic results.
+// Callers must hold f.mu.
+func (f *Fake) sortedPRs(keep func(pr *FakePR) bool) []PullRequest {
+	var keys []string
+	for k, pr := range f.prs {
+		if keep(pr) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	prs := make([]PullRequest, 0, len(keys))
+	for _, k := range keys {
+		prs = append(prs, f.prs[k].PullRequest)
+	}
+	return prs
+}
+
+func (f *Fake) GetPRsRequestingReview(ctx context.Context) ([]PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetPRsRequestingReview"); err != nil {
+		return nil, err
+	}
+	prs := f.sortedPRs(func(pr *FakePR) bool {
+		if pr.State != "open" {
+			return false
+		}
+		for _, login := range pr.RequestedReviewers {
+			if login == f.username {
+				return true
+			}
+		}
+		return false
+	})
+	return f.recordSearch(SearchReviewRequested, "review-requested:"+f.username, prs), nil
+}
+
+func (f *Fake) GetMyOpenPRs(ctx context.Context) ([]PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetMyOpenPRs"); err != nil {
+		return nil, err
+	}
+	prs := f.sortedPRs(func(pr *FakePR) bool {
+		return pr.State == "open" && pr.Author == f.username
+	})
+	return f.recordSearch(SearchAuthored, "author:"+f.username, prs), nil
+}
+
+// recordSearch records search counts; the fake never truncates, so total and fetched match
+func (f *Fake) recordSearch(kind, query string, prs []PullRequest) []PullRequest {
+	f.searches[kind] = SearchStats{Kind: kind, Query: query, Total: len(prs), Fetched: len(prs)}
+	return prs
+}
+
+// SetBackoff simulates GitHub asking for no requests until the given time
+func (f *Fake) SetBackoff(until time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backoff = until
+}
+
+func (f *Fake) BackoffUntil() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Now().After(f.backoff) {
+		return time.Time{}
+	}
+	return f.backoff
+}
+
+func (f *Fake) GetSearchStats() []SearchStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["GetSearchStats"]++
+	stats := make([]SearchStats, 0, len(f.searches))
+	for _, s := range f.searches {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Kind > stats[j].Kind })
+	return stats
+}
+
+func (f *Fake) BatchGetPRStates(ctx context.Context, prs []PullRequest) (map[string]*PRState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("BatchGetPRStates"); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*PRState)
+	for _, req := range prs {
+		key := fakeKey(req.Owner, req.Repo, req.Number)
+		pr, ok := f.prs[key]
+		if !ok {
+			continue
+		}
+
+		state := "OPEN"
+		if pr.Merged {
+			state = "MERGED"
+		} else if pr.State == "closed" {
+			state = "CLOSED"
+		}
+		results[key] = &PRState{
+			Owner:    req.Owner,
+			Repo:     req.Repo,
+			Number:   req.Number,
+			State:    state,
+			Merged:   pr.Merged,
+			HeadSHA:  pr.CommitSHA,
+			Draft:    pr.Draft,
+			Title:    pr.Title,
+			Author:   pr.Author,
+			ClosedAt: pr.ClosedAt,
+		}
+		if pr.Merged {
+			results[key].MergedAt = pr.ClosedAt
+		}
+	}
+	return results, nil
+}
+
+func (f *Fake) BatchGetPRReviewData(ctx context.Context, prs []PullRequest) (map[string]*PRReviewData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("BatchGetPRReviewData"); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*PRReviewData)
+	for _, req := range prs {
+		key := fakeKey(req.Owner, req.Repo, req.Number)
+		pr, ok := f.prs[key]
+		if !ok {
+			continue
+		}
+
+		// Same semantics as the GraphQL implementation: latest non-PENDING, non-DISMISSED review per user
+		userLatestReview := make(map[string]string)
+		for _, review := range pr.Reviews {
+			if review.State != "PENDING" && review.State != "DISMISSED" {
+				userLatestReview[review.Login] = review.State
+			}
+		}
+		approvalCount := 0
+		for _, state := range userLatestReview {
+			if state == "APPROVED" {
+				approvalCount++
+			}
+		}
+
+		results[key] = &PRReviewData{
+			Owner:          req.Owner,
+			Repo:           req.Repo,
+			Number:         req.Number,
+			ApprovalCount:  approvalCount,
+			MyReviewStatus: userLatestReview[f.username],
+		}
+	}
+	return results, nil
+}
+
+func (f *Fake) BatchGetPRDetails(ctx context.Context, prs []PullRequest) (map[string]*PRDetails, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("BatchGetPRDetails"); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*PRDetails)
+	for _, req := range prs {
+		key := fakeKey(req.Owner, req.Repo, req.Number)
+		pr, ok := f.prs[key]
+		if !ok {
+			continue
+		}
+
+		reviewerSet := make(map[string]bool)
+		for _, review := range pr.Reviews {
+			reviewerSet[review.Login] = true
+		}
+		requestedMe := false
+		for _, login := range pr.RequestedReviewers {
+			if login == f.username {
+				requestedMe = true
+				break
+			}
+		}
+
+		results[key] = &PRDetails{
+			Owner:        req.Owner,
+			Repo:         req.Repo,
+			Number:       req.Number,
+			Title:        pr.Title,
+			Author:       pr.Author,
+			CreatedAt:    *pr.CreatedAt,
+			Additions:    pr.Additions,
+			Deletions:    pr.Deletions,
+			ChangedFiles: pr.ChangedFiles,
+			ReviewCount:  len(reviewerSet),
+			RequestedMe:  requestedMe,
+		}
+	}
+	return results, nil
+}
+
+func (f *Fake) BatchGetCIStatus(ctx context.Context, prs []CommitRef) (map[string]*CIStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("BatchGetCIStatus"); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*CIStatus)
+	for _, ref := range prs {
+		state := "unknown"
+		failedChecks := []string{}
+		if status, ok := f.ciStatus[fakeCommitKey(ref.Owner, ref.Repo, ref.CommitSHA)]; ok {
+			state = status.State
+			failedChecks = append(failedChecks, status.FailedChecks...)
+		}
+		results[fakeKey(ref.Owner, ref.Repo, ref.Number)] = &CIStatus{
+			Owner:        ref.Owner,
+			Repo:         ref.Repo,
+			Number:       ref.Number,
+			State:        state,
+			FailedChecks: failedChecks,
+		}
+	}
+	return results, nil
+}
+
+func (f *Fake) GetRateLimitInfo(ctx context.Context) (*RateLimitInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRateLimitInfo"); err != nil {
+		return nil, err
+	}
+	info := f.rateLimit
+	return &info, nil
+}
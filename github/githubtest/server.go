@@ -0,0 +1,515 @@
+// Package githubtest provides an httptest-based stand-in for the GitHub REST and GraphQL APIs.
+//
+// The server is backed by a *github.Fake, so tests seed and mutate state with the same helpers
+// (AddPR, PushCommit, ClosePR, ...) used for in-process fakes, while exercising the real
+// github.Client end-to-end over HTTP.
+package githubtest
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pr-review-server/github"
+)
+
+// Server serves the subset of the GitHub API used by github.Client
+type Server struct {
+	*httptest.Server
+	Fake *github.Fake
+
+	// SearchLimit caps how many search results can be paged through (GitHub stops at 1000)
+	SearchLimit int
+	// InstallationTokenTTL is the lifetime of minted GitHub App installation tokens (GitHub uses one hour)
+	InstallationTokenTTL time.Duration
+	// RetryAfter, when set, rejects API requests with a secondary rate limit asking clients to wait this long
+	RetryAfter time.Duration
+
+	mu       sync.Mutex
+	requests map[string]int    // route pattern -> request count
+	lastAuth map[string]string // route pattern -> Authorization header of the latest request
+	appID    int64
+	appKey   *rsa.PublicKey
+	minted   int
+}
+
+// NewServer starts a server backed by fake. Callers must Close it when done.
+func NewServer(fake *github.Fake) *Server {
+	s := &Server{
+		Fake:                 fake,
+		SearchLimit:          1000,
+		InstallationTokenTTL: time.Hour,
+		requests:             make(map[string]int),
+		lastAuth:             make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	s.handle(mux, "GET /repos/{owner}/{repo}/pulls/{number}", s.handleGetPull)
+	s.handle(mux, "GET /repos/{owner}/{repo}/pulls/{number}/reviews", s.handleListReviews)
+	s.handle(mux, "GET /rate_limit", s.handleRateLimit)
+	s.handle(mux, "POST /graphql", s.handleGraphQL)
+	s.handle(mux, "POST /app/installations/{id}/access_tokens", s.handleInstallationToken)
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// APIURL returns the REST base URL to pass to github.NewClientWithEndpoints
+func (s *Server) APIURL() string {
+	return s.URL + "/"
+}
+
+// GraphQLURL returns the GraphQL endpoint to pass to github.NewClientWithEndpoints
+func (s *Server) GraphQLURL() string {
+	return s.URL + "/graphql"
+}
+
+// RequestCount returns how many requests were served for a route pattern, e.g. "POST /graphql"
+func (s *Server) RequestCount(pattern string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[pattern]
+}
+
+// LastAuthorization returns the Authorization header of the latest request for a route pattern
+func (s *Server) LastAuthorization(pattern string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastAuth[pattern]
+}
+
+// SetAppKey makes the installation token route verify that app JWTs are issued by appID and signed by key
+func (s *Server) SetAppKey(appID int64, key *rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appID = appID
+	s.appKey = key
+}
+
+// handle registers fn under pattern, counting requests and rejecting unauthenticated ones
+func (s *Server) handle(mux *http.ServeMux, pattern string, fn http.HandlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[pattern]++
+		s.lastAuth[pattern] = r.Header.Get("Authorization")
+		s.mu.Unlock()
+
+		if r.Header.Get("Authorization") == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Requires authentication"})
+			return
+		}
+
+		// go-github reads rate limits from these headers on every response
+		rate := s.Fake.RateLimit()
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rate.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rate.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(rate.ResetTime.Unix(), 10))
+
+		// Like GitHub, /rate_limit keeps answering when the limit is exhausted
+		if pattern != "GET /rate_limit" {
+			if s.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(s.RetryAfter.Seconds())))
+				writeJSON(w, http.StatusForbidden, map[string]string{"message": "You have exceeded a secondary rate limit"})
+				return
+			}
+			if rate.Remaining <= 0 {
+				writeJSON(w, http.StatusForbidden, map[string]string{"message": "API rate limit exceeded"})
+				return
+			}
+		}
+
+		fn(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// findPR looks up a PR by owner/repo/number in the fake's current state
+func (s *Server) findPR(owner, repo string, number int) (github.FakePR, bool) {
+	for _, pr := range s.Fake.Snapshot() {
+		if pr.Owner == owner && pr.Repo == repo && pr.Number == number {
+			return pr, true
+		}
+	}
+	return github.FakePR{}, false
+}
+
+// searchPRs filters PRs by the "state:", "review-requested:" and "author:" search qualifiers
+func (s *Server) searchPRs(q string) []github.FakePR {
+	var reviewRequested, author, state string
+	for _, term := range strings.Fields(q) {
+		key, value, ok := strings.Cut(term, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "review-requested":
+			reviewRequested = value
+		case "author":
+			author = value
+		case "state":
+			state = value
+		}
+	}
+
+	var matches []github.FakePR
+	for _, pr := range s.Fake.Snapshot() {
+		if state != "" && pr.State != state {
+			continue
+		}
+		if author != "" && pr.Author != author {
+			continue
+		}
+		if reviewRequested != "" && !contains(pr.RequestedReviewers, reviewRequested) {
+			continue
+		}
+		matches = append(matches, pr)
+	}
+	return matches
+}
+
+// pathPR resolves the {owner}/{repo}/{number} path values of a request
+func (s *Server) pathPR(w http.ResponseWriter, r *http.Request) (github.FakePR, bool) {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return github.FakePR{}, false
+	}
+	pr, ok := s.findPR(r.PathValue("owner"), r.PathValue("repo"), number)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return github.FakePR{}, false
+	}
+	return pr, true
+}
+
+func (s *Server) handleGetPull(w http.ResponseWriter, r *http.Request) {
+	pr, ok := s.pathPR(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"number":     pr.Number,
+		"state":      pr.State,
+		"merged":     pr.Merged,
+		"title":      pr.Title,
+		"html_url":   pr.URL,
+		"draft":      pr.Draft,
+		"created_at": pr.CreatedAt.UTC().Format(time.RFC3339),
+		"head":       map[string]string{"sha": pr.CommitSHA},
+		"user":       map[string]string{"login": pr.Author},
+		"additions":  pr.Additions,
+		"deletions":  pr.Deletions,
+	})
+}
+
+func (s *Server) handleListReviews(w http.ResponseWriter, r *http.Request) {
+	pr, ok := s.pathPR(w, r)
+	if !ok {
+		return
+	}
+	reviews := []map[string]interface{}{}
+	for _, review := range pr.Reviews {
+		reviews = append(reviews, map[string]interface{}{
+			"user":  map[string]string{"login": review.Login},
+			"state": review.State,
+		})
+	}
+	writeJSON(w, http.StatusOK, reviews)
+}
+
+func (s *Server) handleRateLimit(w http.ResponseWriter, r *http.Request) {
+	rate := s.Fake.RateLimit()
+	core := map[string]interface{}{
+		"limit":     rate.Limit,
+		"remaining": rate.Remaining,
+		"reset":     rate.ResetTime.Unix(),
+	}
+	resources := map[string]interface{}{"core": core}
+	if rate.GraphQL != nil {
+		resources["graphql"] = map[string]interface{}{
+			"limit":     rate.GraphQL.Limit,
+			"remaining": rate.GraphQL.Remaining,
+			"reset":     rate.GraphQL.ResetTime.Unix(),
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resources": resources,
+		"rate":      core,
+	})
+}
+
+// handleInstallationToken exchanges an app JWT for an installation token ("ghs_<installation>_<n>")
+func (s *Server) handleInstallationToken(w http.ResponseWriter, r *http.Request) {
+	jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if err := s.verifyAppJWT(jwt); err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.minted++
+	token := fmt.Sprintf("ghs_%s_%d", r.PathValue("id"), s.minted)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token":      token,
+		"expires_at": time.Now().Add(s.InstallationTokenTTL).UTC().Format(time.RFC3339),
+	})
+}
+
+// verifyAppJWT checks the RS256 signature, issuer and expiry of an app JWT when a key is registered via SetAppKey
+func (s *Server) verifyAppJWT(jwt string) error {
+	s.mu.Lock()
+	appID, key := s.appID, s.appKey
+	s.mu.Unlock()
+	if key == nil {
+		return nil
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return errors.New("A JSON web token could not be decoded")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.New("A JSON web token could not be decoded")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return errors.New("JWT signature does not match the app's public key")
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errors.New("A JSON web token could not be decoded")
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return errors.New("A JSON web token could not be decoded")
+	}
+	if claims.Iss != strconv.FormatInt(appID, 10) {
+		return errors.New("Integration not found")
+	}
+	if time.Unix(claims.Exp, 0).Before(time.Now()) {
+		return errors.New("JWT has expired")
+	}
+	return nil
+}
+
+// aliasPattern matches the aliased repository lookups that github.Client builds, e.g.
+// `pr0: repository(owner: "o", name: "r") { pullRequest(number: 1) {` or `{ object(oid: "sha") {`
+var aliasPattern = regexp.MustCompile(`(\w+):\s*repository\(owner:\s*"([^"]*)",\s*name:\s*"([^"]*)"\)\s*\{\s*(?:pullRequest\(number:\s*(\d+)\)|object\(oid:\s*"([^"]*)"\))`)
+
+// handleGraphQL answers PR searches and aliased repository queries. Every pullRequest node carries
+// the union of fields any client query asks for; unrequested fields are ignored by the client's decoder.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+
+	if strings.Contains(body.Query, "search(") {
+		s.handleGraphQLSearch(w, body.Variables)
+		return
+	}
+
+	data := map[string]interface{}{}
+	var errs []map[string]interface{}
+	for _, m := range aliasPattern.FindAllStringSubmatch(body.Query, -1) {
+		alias, owner, repo, numberStr, oid := m[1], m[2], m[3], m[4], m[5]
+
+		if oid != "" {
+			data[alias] = map[string]interface{}{"object": s.commitNode(owner, repo, oid)}
+			continue
+		}
+
+		number, _ := strconv.Atoi(numberStr)
+		pr, ok := s.findPR(owner, repo, number)
+		if !ok {
+			data[alias] = map[string]interface{}{"pullRequest": nil}
+			errs = append(errs, map[string]interface{}{
+				"type":    "NOT_FOUND",
+				"path":    []string{alias, "pullRequest"},
+				"message": fmt.Sprintf("Could not resolve to a PullRequest with the number of %d.", number),
+			})
+			continue
+		}
+		data[alias] = map[string]interface{}{"pullRequest": pullRequestNode(pr)}
+	}
+
+	resp := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		resp["errors"] = errs
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGraphQLSearch answers `search(query: $searchQuery, type: ISSUE, first: $pageSize, after: $cursor)`
+// with pullRequest nodes. Cursors are result offsets; results past SearchLimit are never returned.
+func (s *Server) handleGraphQLSearch(w http.ResponseWriter, variables map[string]interface{}) {
+	q, _ := variables["searchQuery"].(string)
+	matches := s.searchPRs(q)
+
+	pageSize := 100
+	if n, ok := variables["pageSize"].(float64); ok && n > 0 {
+		pageSize = int(n)
+	}
+	start := 0
+	if cursor, ok := variables["cursor"].(string); ok {
+		start, _ = strconv.Atoi(cursor)
+	}
+	available := len(matches)
+	if s.SearchLimit > 0 && available > s.SearchLimit {
+		available = s.SearchLimit
+	}
+	end := start + pageSize
+	if end > available {
+		end = available
+	}
+
+	nodes := []map[string]interface{}{}
+	for i := start; i < end; i++ {
+		nodes = append(nodes, pullRequestNode(matches[i]))
+	}
+
+	rate := s.Fake.RateLimit()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"search": map[string]interface{}{
+				"issueCount": len(matches),
+				"pageInfo": map[string]interface{}{
+					"hasNextPage": end < available,
+					"endCursor":   strconv.Itoa(end),
+				},
+				"nodes": nodes,
+			},
+			"rateLimit": map[string]interface{}{
+				"limit":     rate.Limit,
+				"remaining": rate.Remaining,
+				"cost":      1,
+			},
+		},
+	})
+}
+
+// pullRequestNode renders a FakePR as a GraphQL PullRequest object
+func pullRequestNode(pr github.FakePR) map[string]interface{} {
+	state := "OPEN"
+	var mergedAt interface{}
+	if pr.Merged {
+		state = "MERGED"
+		mergedAt = timestampOrNil(pr.ClosedAt)
+	} else if pr.State == "closed" {
+		state = "CLOSED"
+	}
+
+	reviews := []map[string]interface{}{}
+	for _, review := range pr.Reviews {
+		reviews = append(reviews, map[string]interface{}{
+			"author": map[string]string{"login": review.Login},
+			"state":  review.State,
+		})
+	}
+	requests := []map[string]interface{}{}
+	for _, login := range pr.RequestedReviewers {
+		requests = append(requests, map[string]interface{}{
+			"requestedReviewer": map[string]string{"login": login},
+		})
+	}
+
+	return map[string]interface{}{
+		"number":         pr.Number,
+		"title":          pr.Title,
+		"url":            pr.URL,
+		"state":          state,
+		"merged":         pr.Merged,
+		"closedAt":       timestampOrNil(pr.ClosedAt),
+		"mergedAt":       mergedAt,
+		"isDraft":        pr.Draft,
+		"headRefOid":     pr.CommitSHA,
+		"createdAt":      pr.CreatedAt.UTC().Format(time.RFC3339),
+		"author":         map[string]string{"login": pr.Author},
+		"additions":      pr.Additions,
+		"deletions":      pr.Deletions,
+		"changedFiles":   pr.ChangedFiles,
+		"reviews":        map[string]interface{}{"nodes": reviews},
+		"reviewRequests": map[string]interface{}{"nodes": requests},
+		"repository": map[string]interface{}{
+			"name":  pr.Repo,
+			"owner": map[string]string{"login": pr.Owner},
+		},
+	}
+}
+
+// timestampOrNil renders an optional timestamp as GitHub does, null when unset
+func timestampOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// commitNode renders the CI state of a commit as a GraphQL Commit object with a statusCheckRollup
+func (s *Server) commitNode(owner, repo, sha string) map[string]interface{} {
+	state, failedChecks, ok := s.Fake.CheckStatus(owner, repo, sha)
+	if !ok {
+		return map[string]interface{}{"statusCheckRollup": nil}
+	}
+
+	nodes := []map[string]interface{}{}
+	for _, name := range failedChecks {
+		nodes = append(nodes, map[string]interface{}{
+			"__typename": "CheckRun",
+			"name":       name,
+			"status":     "COMPLETED",
+			"conclusion": "FAILURE",
+		})
+	}
+	if state == "pending" {
+		nodes = append(nodes, map[string]interface{}{
+			"__typename": "CheckRun",
+			"name":       "build",
+			"status":     "IN_PROGRESS",
+			"conclusion": "",
+		})
+	}
+
+	return map[string]interface{}{
+		"statusCheckRollup": map[string]interface{}{
+			"state":    strings.ToUpper(state),
+			"contexts": map[string]interface{}{"nodes": nodes},
+		},
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
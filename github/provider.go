@@ -0,0 +1,41 @@
+package github
+
+import (
+	"context"
+	"time"
+)
+
+// Provider is the subset of GitHub operations used by the poller, server and prioritizer.
+// *Client is the production implementation; Fake is an in-memory implementation for tests.
+type Provider interface {
+	// GetPRsRequestingReview returns open PRs where the user is a requested reviewer
+	GetPRsRequestingReview(ctx context.Context) ([]PullRequest, error)
+	// GetMyOpenPRs returns open PRs authored by the user
+	GetMyOpenPRs(ctx context.Context) ([]PullRequest, error)
+	// BatchGetPRStates returns a map of "owner/repo/number" -> PRState, omitting PRs that can't be resolved
+	BatchGetPRStates(ctx context.Context, prs []PullRequest) (map[string]*PRState, error)
+	// BatchGetPRReviewData returns a map of "owner/repo/number" -> PRReviewData
+	BatchGetPRReviewData(ctx context.Context, prs []PullRequest) (map[string]*PRReviewData, error)
+	// BatchGetPRDetails returns a map of "owner/repo/number" -> PRDetails
+	BatchGetPRDetails(ctx context.Context, prs []PullRequest) (map[string]*PRDetails, error)
+	// BatchGetCIStatus returns a map of "owner/repo/number" -> CIStatus
+	BatchGetCIStatus(ctx context.Context, prs []CommitRef) (map[string]*CIStatus, error)
+	// GetRateLimitInfo returns the current rate limit status
+	GetRateLimitInfo(ctx context.Context) (*RateLimitInfo, error)
+	// BackoffUntil returns when a rate limit backoff requested by GitHub ends (zero if none)
+	BackoffUntil() time.Time
+	// GetSearchStats returns total vs fetched counts from the latest PR searches
+	GetSearchStats() []SearchStats
+}
+
+// Compile-time check that Client implements Provider
+var _ Provider = (*Client)(nil)
+
+// Account is an authenticated GitHub identity on one host, paired with the Provider that talks to it
+type Account struct {
+	Name     string // "username@host", stored with each PR to record which account discovered it
+	Host     string // e.g. "github.com" or a GitHub Enterprise Server hostname
+	Username string
+	WebURL   string // Web host used for PR links, e.g. "https://github.com"
+	Client   Provider
+}
@@ -0,0 +1,96 @@
+package github
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitedError is returned without contacting GitHub while a rate limit backoff is in effect
+type RateLimitedError struct {
+	Until time.Time
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("GitHub rate limit backoff in effect until %s", e.Until.Format("15:04:05 MST"))
+}
+
+// rateLimitTransport stops sending requests once GitHub rejects one for rate limiting: secondary
+// limits carry a Retry-After header, exhausted primary limits carry X-RateLimit-Remaining: 0 and
+// X-RateLimit-Reset. Until the backoff expires every request fails fast with *RateLimitedError.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if until := t.backoffUntil(); !until.IsZero() {
+		return nil, &RateLimitedError{Until: until}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if until, ok := rateLimitBackoff(resp, time.Now()); ok {
+		t.mu.Lock()
+		if until.After(t.until) {
+			t.until = until
+		}
+		t.mu.Unlock()
+		log.Printf("[RATE_LIMIT] GitHub returned %d for %s, backing off until %s",
+			resp.StatusCode, req.URL.Path, until.Format("15:04:05 MST"))
+	}
+	return resp, nil
+}
+
+// backoffUntil returns when the current backoff ends, or the zero time if none is in effect
+func (t *rateLimitTransport) backoffUntil() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Now().After(t.until) {
+		return time.Time{}
+	}
+	return t.until
+}
+
+// rateLimitBackoff reports how long GitHub asked us to wait after a rate-limited response
+func rateLimitBackoff(resp *http.Response, now time.Time) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+
+	// Secondary rate limits
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return now.Add(time.Duration(seconds) * time.Second), true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return at, true
+		}
+	}
+
+	// Primary rate limit exhausted
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0), true
+		}
+	}
+
+	// GitHub documents a one minute wait for secondary limits without Retry-After
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return now.Add(time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+// BackoffUntil returns when the current rate limit backoff ends, or the zero time if requests may be sent
+func (c *Client) BackoffUntil() time.Time {
+	return c.rateLimit.backoffUntil()
+}
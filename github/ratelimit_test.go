@@ -0,0 +1,79 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pr-review-server/github"
+	"pr-review-server/github/githubtest"
+)
+
+const getPullRoute = "GET /repos/{owner}/{repo}/pulls/{number}"
+
+func newRateLimitServer(t *testing.T) (*githubtest.Server, *github.Client) {
+	t.Helper()
+	fake := github.NewFake("me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "abc1234"})
+	srv := githubtest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client, err := github.NewClientWithEndpoints("test-token", "me", srv.APIURL(), srv.GraphQLURL())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return srv, client
+}
+
+// TestRateLimit_RetryAfter tests that a secondary rate limit stops further requests until Retry-After passes
+func TestRateLimit_RetryAfter(t *testing.T) {
+	srv, client := newRateLimitServer(t)
+	srv.RetryAfter = time.Minute
+	ctx := context.Background()
+
+	if _, err := client.GetPRHeadSHA(ctx, "acme", "api", 1); err == nil {
+		t.Fatal("Expected the rate-limited request to fail")
+	}
+
+	until := client.BackoffUntil()
+	if remaining := time.Until(until); remaining < 50*time.Second || remaining > time.Minute {
+		t.Fatalf("Expected a backoff of about a minute, got %v", remaining)
+	}
+
+	// The next request fails fast without reaching GitHub
+	_, err := client.GetPRHeadSHA(ctx, "acme", "api", 1)
+	var rateLimited *github.RateLimitedError
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("Expected RateLimitedError during backoff, got %v", err)
+	}
+	if got := srv.RequestCount(getPullRoute); got != 1 {
+		t.Errorf("Expected one request to reach the server, got %d", got)
+	}
+}
+
+// TestRateLimit_Exhausted tests that an exhausted primary limit backs off until the reset time
+func TestRateLimit_Exhausted(t *testing.T) {
+	srv, client := newRateLimitServer(t)
+	reset := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	srv.Fake.SetRateLimit(github.RateLimitInfo{Limit: 5000, Remaining: 0, ResetTime: reset})
+
+	if _, err := client.IsPROpen(context.Background(), "acme", "api", 1); err == nil {
+		t.Fatal("Expected the request to fail with the limit exhausted")
+	}
+	if until := client.BackoffUntil(); !until.Equal(reset) {
+		t.Errorf("Expected backoff until the reset at %v, got %v", reset, until)
+	}
+}
+
+// TestRateLimit_NoBackoff tests that successful requests leave the client free to continue
+func TestRateLimit_NoBackoff(t *testing.T) {
+	_, client := newRateLimitServer(t)
+
+	if _, err := client.GetPRHeadSHA(context.Background(), "acme", "api", 1); err != nil {
+		t.Fatalf("GetPRHeadSHA failed: %v", err)
+	}
+	if until := client.BackoffUntil(); !until.IsZero() {
+		t.Errorf("Expected no backoff, got %v", until)
+	}
+}
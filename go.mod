@@ -6,6 +6,7 @@ toolchain go1.24.11
 
 require (
 	github.com/google/go-github/v57 v57.0.0
+	github.com/lib/pq v1.12.3
 	github.com/mattn/go-sqlite3 v1.14.33
 	github.com/shurcooL/githubv4 v0.0.0-20240727222349-48295856cce7
 	golang.org/x/oauth2 v0.34.0
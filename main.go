@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"pr-review-server/config"
@@ -17,66 +20,100 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg := config.Load()
 
 	// Validate required config
-	if cfg.GitHubToken == "" {
-		log.Fatal("GITHUB_TOKEN environment variable is required")
-	}
-	if cfg.GitHubUsername == "" {
-		log.Fatal("GITHUB_USERNAME environment variable is required")
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
 	}
 
 	log.Printf("Starting PR Review Server...")
-	log.Printf("GitHub Username: %s", cfg.GitHubUsername)
+	for _, account := range cfg.Accounts {
+		auth := "token"
+		if account.UsesApp() {
+			auth = fmt.Sprintf("GitHub App %d, installation %d", account.AppID, account.AppInstallationID)
+		}
+		log.Printf("GitHub Account: %s (API: %s, GraphQL: %s, auth: %s)", account.Name(), account.APIURL, account.GraphQLURL, auth)
+	}
 	log.Printf("Polling Interval: %s", cfg.PollingInterval)
+	if cfg.WebhookSecret != "" {
+		log.Printf("Webhooks: enabled at /api/webhooks/github")
+	}
 	log.Printf("Server Port: %s", cfg.ServerPort)
 	log.Printf("Reviews Directory: %s", cfg.ReviewsDir)
-	log.Printf("CBPR Path: %s", cfg.CbprPath)
-
-	// Check if cbpr is available and configured for AI reviews
-	cbprPath, err := exec.LookPath(cfg.CbprPath)
-	if err != nil {
-		// Don't log a scary warning if the user just doesn't have cbpr installed
-		if cfg.CbprPath != config.DefaultCbprPath {
-			log.Printf("⚠️  WARNING: cbpr not found at specified path '%s'. AI review generation is disabled.", cfg.CbprPath)
+	log.Printf("Review Generator: %s", cfg.ReviewGenerator)
+
+	switch cfg.ReviewGenerator {
+	case config.ReviewGeneratorNone:
+		log.Println("ⓘ  INFO: REVIEW_GENERATOR=none. PRs are tracked without generating reviews.")
+	case config.ReviewGeneratorCommand:
+		log.Printf("Review Command: %s", strings.Join(cfg.ReviewCommand, " "))
+		if _, err := exec.LookPath(cfg.ReviewCommand[0]); err != nil {
+			log.Printf("⚠️  WARNING: review command '%s' not found. Reviews will fail until it is installed.", cfg.ReviewCommand[0])
+		}
+	default:
+		log.Printf("CBPR Path: %s", cfg.CbprPath)
+
+		// Check if cbpr is available and configured for AI reviews
+		cbprPath, err := exec.LookPath(cfg.CbprPath)
+		if err != nil {
+			// Don't log a scary warning if the user just doesn't have cbpr installed
+			if cfg.CbprPath != config.DefaultCbprPath {
+				log.Printf("⚠️  WARNING: cbpr not found at specified path '%s'. AI review generation is disabled.", cfg.CbprPath)
+			} else {
+				log.Println("ⓘ  INFO: cbpr not found in PATH. AI review generation is disabled. This is normal if you don't intend to use it.")
+			}
+		} else if cfg.GeminiAPIKey == "" {
+			log.Printf("⚠️  WARNING: cbpr found at '%s' but GEMINI_API_KEY is not set. AI review generation is disabled.", cbprPath)
 		} else {
-			log.Println("ⓘ  INFO: cbpr not found in PATH. AI review generation is disabled. This is normal if you don't intend to use it.")
+			log.Printf("✅ cbpr found at '%s'. AI review generation is enabled.", cbprPath)
+			cfg.CbprEnabled = true
 		}
-	} else if cfg.GeminiAPIKey == "" {
-		log.Printf("⚠️  WARNING: cbpr found at '%s' but GEMINI_API_KEY is not set. AI review generation is disabled.", cbprPath)
-	} else {
-		log.Printf("✅ cbpr found at '%s'. AI review generation is enabled.", cbprPath)
-		cfg.CbprEnabled = true
 	}
 
 	// Create required directories
-	dbDir := filepath.Dir(cfg.DBPath)
-	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		log.Fatalf("Failed to create data directory: %v", err)
-	}
 	if err := os.MkdirAll(cfg.ReviewsDir, 0755); err != nil {
 		log.Fatalf("Failed to create reviews directory: %v", err)
 	}
 
 	// Initialize database
-	database, err := db.New(cfg.DBPath)
+	database, err := openDatabase(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		log.Fatalf("Failed to open database: %v", err)
 	}
 	defer database.Close()
-	log.Printf("Database initialized at %s", cfg.DBPath)
-
-	// Initialize GitHub client
-	ghClient := github.NewClient(cfg.GitHubToken, cfg.GitHubUsername)
-	log.Println("GitHub client initialized")
+	if _, err := database.MigrateTo(db.LatestSchemaVersion); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	log.Printf("Database initialized at %s", databaseName(cfg))
+
+	// Initialize a GitHub client per account
+	var accounts []github.Account
+	for _, account := range cfg.Accounts {
+		ghClient, err := newGitHubClient(account)
+		if err != nil {
+			log.Fatalf("Failed to initialize GitHub client for %s: %v", account.Name(), err)
+		}
+		accounts = append(accounts, github.Account{
+			Name:     account.Name(),
+			Host:     account.Host,
+			Username: account.Username,
+			WebURL:   account.WebURL,
+			Client:   ghClient,
+		})
+	}
+	log.Printf("GitHub clients initialized for %d account(s)", len(accounts))
 
 	// Initialize server first (so poller can update its cache)
-	srv := server.New(cfg, database, ghClient)
+	srv := server.New(cfg, database, accounts)
 
 	// Initialize poller
-	p := poller.New(cfg, database, ghClient)
+	p := poller.New(cfg, database, accounts)
 
 	// Wire poller to update server's cache
 	p.SetCacheUpdateFunc(srv.UpdatePRCache)
@@ -84,9 +121,15 @@ func main() {
 	// Wire server to trigger poller on delete
 	srv.SetPollTrigger(p.Trigger)
 
+	// Wire webhook receiver to refresh individual PRs
+	srv.SetPRRefresh(p.RefreshPR)
+
 	// Wire poller to server for status queries
 	srv.SetPoller(p)
 
+	// Generate reviews in priority order
+	p.SetPriorityFunc(srv.ReviewPriority)
+
 	// Start poller in background
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -112,3 +155,43 @@ func main() {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// newGitHubClient creates a client for account, authenticating with its token or as a GitHub App installation
+func newGitHubClient(account config.Account) (*github.Client, error) {
+	if !account.UsesApp() {
+		return github.NewClientWithEndpoints(account.Token, account.Username, account.APIURL, account.GraphQLURL)
+	}
+
+	privateKey, err := os.ReadFile(account.AppPrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+	ts, err := github.NewAppTokenSource(account.AppID, account.AppInstallationID, privateKey, account.APIURL)
+	if err != nil {
+		return nil, err
+	}
+	return github.NewClientWithTokenSource(ts, account.Username, account.APIURL, account.GraphQLURL)
+}
+
+// openDatabase opens the PostgreSQL database at DATABASE_URL if it's set, otherwise the SQLite
+// database at DB_PATH, without migrating it
+func openDatabase(cfg *config.Config) (*db.DB, error) {
+	if cfg.DatabaseURL != "" {
+		return db.OpenPostgres(cfg.DatabaseURL)
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.DBPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return db.Open(cfg.DBPath)
+}
+
+// databaseName describes the configured database for logs, without the URL's password
+func databaseName(cfg *config.Config) string {
+	if cfg.DatabaseURL == "" {
+		return cfg.DBPath
+	}
+	if u, err := url.Parse(cfg.DatabaseURL); err == nil && u.Host != "" {
+		return u.Redacted()
+	}
+	return "PostgreSQL"
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"pr-review-server/config"
+	"pr-review-server/db"
+)
+
+const migrateUsage = `Usage: pr-review-server migrate [command]
+
+Commands:
+  up            Apply all pending migrations (default)
+  status        List migrations and whether they have been applied
+  to <version>  Migrate up or down to a schema version
+  down          Revert the latest migration
+
+The database is DATABASE_URL if set, otherwise DB_PATH (default ./data/pr-review.db).`
+
+// runMigrate runs the migrate command, returning the process exit code
+func runMigrate(args []string) int {
+	cfg := config.Load()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if command == "help" || command == "-h" || command == "--help" {
+		fmt.Println(migrateUsage)
+		return 0
+	}
+
+	name := databaseName(cfg)
+	database, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database %s: %v\n", name, err)
+		return 1
+	}
+	defer database.Close()
+
+	current, err := database.SchemaVersion()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read schema version: %v\n", err)
+		return 1
+	}
+
+	var target int
+	switch command {
+	case "status":
+		return printMigrationStatus(database, name)
+	case "up":
+		target = db.LatestSchemaVersion
+	case "down":
+		target = current - 1
+	case "to":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		if target, err = strconv.Atoi(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid schema version %q\n", args[1])
+			return 2
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown migrate command %q\n\n%s\n", command, migrateUsage)
+		return 2
+	}
+
+	ran, err := database.MigrateTo(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Migration failed after %d migrations: %v\n", ran, err)
+		return 1
+	}
+	version, err := database.SchemaVersion()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read schema version: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Ran %d migrations; %s is at schema version %d (latest %d)\n", ran, name, version, db.LatestSchemaVersion)
+	return 0
+}
+
+func printMigrationStatus(database db.Store, name string) int {
+	statuses, err := database.MigrationStatus()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read migrations: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Database: %s\n", name)
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = "applied " + status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		reversible := ""
+		if !status.Reversible {
+			reversible = " (irreversible)"
+		}
+		fmt.Printf("  %3d  %-30s %s%s\n", status.Version, status.Name, applied, reversible)
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package poller
+
+import (
+	"log"
+	"time"
+
+	"pr-review-server/db"
+	"pr-review-server/github"
+)
+
+// RegenerateReview discards a PR's current review and queues a new review of its head commit right
+// away, cancelling one in progress. Notes and other metadata are kept, as are earlier reviews in the
+// PR's history. It reports false if the PR isn't tracked or has been archived.
+func (p *Poller) RegenerateReview(host, owner, repo string, number int) (bool, error) {
+	if !generatesReviews(p.generator) {
+		return false, ErrReviewsDisabled
+	}
+	pr, err := p.db.GetPR(host, owner, repo, number)
+	if err != nil || pr == nil || pr.Archived() {
+		return false, err
+	}
+	key := prKey(host, owner, repo, number)
+
+	// Reset before killing so the interrupted run is recorded as cancelled rather than as a failure
+	if err := p.db.ResetPRToOutdated(host, owner, repo, number, pr.LastCommitSHA); err != nil {
+		return false, err
+	}
+	if pr.Status == "generating" && p.killReview(host, owner, repo, number) {
+		log.Printf("[REGENERATE] Killed running review for %s", key)
+	}
+
+	// rerun lets the job wait behind the run being killed instead of being dropped as a duplicate
+	p.reviews.push(&reviewJob{pr: p.pullRequestFor(*pr), isMine: pr.IsMine, rerun: true})
+	log.Printf("[REGENERATE] Queued a new review of %s at %s", key, pr.LastCommitSHA)
+	return true, nil
+}
+
+// CancelReview stops a PR's queued or running review and marks it cancelled. The PR isn't reviewed
+// again until it gets a new commit or is regenerated. It reports false if no review was queued or
+// running.
+func (p *Poller) CancelReview(host, owner, repo string, number int) (bool, error) {
+	cancelled, err := p.db.CancelPRReview(host, owner, repo, number)
+	if err != nil || !cancelled {
+		return false, err
+	}
+	key := prKey(host, owner, repo, number)
+
+	p.reviews.remove(key)
+	if p.killReview(host, owner, repo, number) {
+		log.Printf("[CANCEL] Killed running review for %s", key)
+	}
+	log.Printf("[CANCEL] Cancelled review of %s", key)
+	return true, nil
+}
+
+// ArchivePR moves a closed or merged PR to the archive and stops its queued or running review.
+// mergedAt is nil if it was closed without merging. It reports false if the PR isn't tracked or is
+// already archived.
+func (p *Poller) ArchivePR(host, owner, repo string, number int, closedAt time.Time, mergedAt *time.Time) (bool, error) {
+	// Archive first, so a review killed below can't record a result on the closed PR
+	archived, err := p.db.ArchivePR(host, owner, repo, number, closedAt, mergedAt)
+	if err != nil {
+		return false, err
+	}
+	key := prKey(host, owner, repo, number)
+
+	p.reviews.remove(key)
+	if p.killReview(host, owner, repo, number) {
+		log.Printf("[CLEANUP] Killed running review for %s", key)
+	}
+	return archived, nil
+}
+
+// pullRequestFor builds the review job's view of a tracked PR from its database row
+func (p *Poller) pullRequestFor(pr db.PR) github.PullRequest {
+	ghPR := github.PullRequest{
+		Host:      pr.Host,
+		Account:   pr.Account,
+		Owner:     pr.RepoOwner,
+		Repo:      pr.RepoName,
+		Number:    pr.PRNumber,
+		CommitSHA: pr.LastCommitSHA,
+		Title:     pr.Title,
+		Author:    pr.Author,
+		CreatedAt: pr.CreatedAt,
+		Draft:     pr.Draft,
+	}
+	if acct, ok := p.accountForPR(prRef{host: pr.Host, owner: pr.RepoOwner, repo: pr.RepoName, number: pr.PRNumber}); ok {
+		ghPR.URL = github.PRWebURL(acct.WebURL, pr.RepoOwner, pr.RepoName, pr.PRNumber)
+	}
+	return ghPR
+}
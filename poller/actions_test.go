@@ -0,0 +1,159 @@
+package poller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pr-review-server/db"
+	"pr-review-server/github"
+)
+
+// waitForRunningReview waits until a review process has started
+func waitForRunningReview(t *testing.T, p *Poller) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(p.GetRunningReviews()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the review to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRegenerateReview_KeepsNotes tests that regenerating a completed review generates a new one and
+// keeps the PR's notes and review history
+func TestRegenerateReview_KeepsNotes(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	gen := &recordingGenerator{}
+	p.SetReviewGenerator(gen)
+	startTestWorkers(t, p)
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(context.Background())
+	p.reviews.waitIdle()
+	if err := database.UpdatePRNotes("github.com", "acme", "api", 1, "check later"); err != nil {
+		t.Fatalf("UpdatePRNotes failed: %v", err)
+	}
+
+	found, err := p.RegenerateReview("github.com", "acme", "api", 1)
+	if err != nil || !found {
+		t.Fatalf("Expected regenerate to succeed, got %v (%v)", found, err)
+	}
+	p.reviews.waitIdle()
+
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+	if pr.Status != "completed" || pr.Notes != "check later" {
+		t.Errorf("Expected completed review with notes kept, got %s %q", pr.Status, pr.Notes)
+	}
+	if got := len(gen.requests); got != 2 {
+		t.Errorf("Expected 2 generator runs, got %d", got)
+	}
+	if reviews, _ := database.GetReviews(pr.ID); len(reviews) != 2 {
+		t.Errorf("Expected both reviews in history, got %d", len(reviews))
+	}
+
+	if found, err := p.RegenerateReview("github.com", "acme", "api", 2); err != nil || found {
+		t.Errorf("Expected unknown PR not to be found, got %v (%v)", found, err)
+	}
+}
+
+// TestRegenerateReview_RestartsRunningReview tests that regenerating a review in progress kills it
+// and runs it again, recording the interrupted run as cancelled rather than failed
+func TestRegenerateReview_RestartsRunningReview(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	// The first run hangs; the rerun finds the marker and finishes
+	marker := filepath.Join(t.TempDir(), "started")
+	p.SetReviewGenerator(&CommandGenerator{Argv: []string{"sh", "-c", "test -f " + marker + " || { touch " + marker + "; sleep 30; }; echo '<p>Looks good</p>'"}})
+	startTestWorkers(t, p)
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(context.Background())
+	waitForRunningReview(t, p)
+
+	if found, err := p.RegenerateReview("github.com", "acme", "api", 1); err != nil || !found {
+		t.Fatalf("Expected regenerate to succeed, got %v (%v)", found, err)
+	}
+	p.reviews.waitIdle()
+
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+	if pr.Status != "completed" || pr.ReviewAttempts != 0 {
+		t.Errorf("Expected completed with no failed attempts, got %s/%d", pr.Status, pr.ReviewAttempts)
+	}
+	reviews, _ := database.GetReviews(pr.ID)
+	if len(reviews) != 2 || reviews[0].Status != "completed" || reviews[1].Status != "cancelled" {
+		t.Errorf("Expected a completed rerun after a cancelled run, got %+v", reviews)
+	}
+}
+
+// TestCancelReview tests that cancelling a running review kills it and that the PR isn't queued again
+// at the same commit
+func TestCancelReview(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	p.SetReviewGenerator(&CommandGenerator{Argv: []string{"sleep", "30"}})
+	startTestWorkers(t, p)
+	ctx := context.Background()
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(ctx)
+	waitForRunningReview(t, p)
+
+	cancelled, err := p.CancelReview("github.com", "acme", "api", 1)
+	if err != nil || !cancelled {
+		t.Fatalf("Expected cancel to succeed, got %v (%v)", cancelled, err)
+	}
+	p.reviews.waitIdle()
+
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+	if pr.Status != "cancelled" {
+		t.Errorf("Expected cancelled, got %s", pr.Status)
+	}
+	reviews, _ := database.GetReviews(pr.ID)
+	if len(reviews) != 1 || reviews[0].Status != "cancelled" {
+		t.Errorf("Expected one cancelled run, got %+v", reviews)
+	}
+
+	p.poll(ctx)
+	p.reviews.waitIdle()
+	if reviews, _ := database.GetReviews(pr.ID); len(reviews) != 1 {
+		t.Errorf("Expected cancelled review not to be requeued, got %d runs", len(reviews))
+	}
+	if cancelled, err := p.CancelReview("github.com", "acme", "api", 1); err != nil || cancelled {
+		t.Errorf("Expected nothing left to cancel, got %v (%v)", cancelled, err)
+	}
+}
+
+// TestArchivePR_StopsRunningReview tests that archiving a PR mid-review kills the run and that the
+// killed run doesn't flip the archived PR to completed or errored
+func TestArchivePR_StopsRunningReview(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	p.SetReviewGenerator(&CommandGenerator{Argv: []string{"sleep", "30"}})
+	startTestWorkers(t, p)
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(context.Background())
+	waitForRunningReview(t, p)
+
+	merged := time.Now()
+	if archived, err := p.ArchivePR("github.com", "acme", "api", 1, merged, &merged); err != nil || !archived {
+		t.Fatalf("Expected archive to succeed, got %v (%v)", archived, err)
+	}
+	p.reviews.waitIdle()
+
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+	if !pr.Archived() || pr.Status != "pending" || pr.ReviewAttempts != 0 {
+		t.Errorf("Expected the archived PR left pending with no failed attempt, got %+v", pr)
+	}
+	if running := p.GetRunningReviews(); len(running) != 0 {
+		t.Errorf("Expected the review to be killed, got %+v", running)
+	}
+	reviews, _ := database.GetReviews(pr.ID)
+	if len(reviews) != 1 || reviews[0].Status != "cancelled" {
+		t.Errorf("Expected one cancelled run, got %+v", reviews)
+	}
+	events, _ := database.GetPREvents(pr.ID)
+	if last := events[len(events)-1]; last.Type != db.EventClosed {
+		t.Errorf("Expected closing to be the last event, got %+v", last)
+	}
+}
@@ -0,0 +1,211 @@
+package poller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"pr-review-server/config"
+	"pr-review-server/github"
+)
+
+// ReviewRequest is one review for a ReviewGenerator to produce
+type ReviewRequest struct {
+	PR         github.PullRequest
+	OutputPath string        // Absolute path the review HTML must be written to
+	OnStart    func(pid int) // Called with the PID when an external process starts, so it can be cancelled
+	Log        io.Writer     // Receives the generator's combined output as it runs; may be nil
+}
+
+// ReviewArtifact is the result of a review generation. Failed generations may return an artifact
+// alongside the error so the generator's output can be kept for diagnosis.
+type ReviewArtifact struct {
+	Path     string // Review HTML file, equal to the request's OutputPath
+	Output   []byte // Combined stdout/stderr of the generator, if it ran a process
+	ExitCode *int   // Exit code of the generator process, if it ran one (-1 if it was killed by a signal)
+}
+
+// ReviewGenerator produces a review for a PR. Implementations write HTML to req.OutputPath and
+// return an error if the review could not be generated; a cancelled ctx must stop the generator.
+type ReviewGenerator interface {
+	Name() string
+	Generate(ctx context.Context, req ReviewRequest) (*ReviewArtifact, error)
+}
+
+// NewReviewGenerator returns the generator selected by REVIEW_GENERATOR. cbpr falls back to the
+// no-op generator when it isn't installed or configured.
+func NewReviewGenerator(cfg *config.Config) ReviewGenerator {
+	switch cfg.ReviewGenerator {
+	case config.ReviewGeneratorCommand:
+		return &CommandGenerator{Argv: cfg.ReviewCommand}
+	case config.ReviewGeneratorNone:
+		return NoopGenerator{}
+	default:
+		if !cfg.CbprEnabled {
+			return NoopGenerator{}
+		}
+		return &CbprGenerator{Path: cfg.CbprPath}
+	}
+}
+
+// CbprGenerator runs `cbpr review` for each PR
+type CbprGenerator struct {
+	Path string // cbpr binary
+}
+
+func (g *CbprGenerator) Name() string {
+	return "cbpr"
+}
+
+func (g *CbprGenerator) Generate(ctx context.Context, req ReviewRequest) (*ReviewArtifact, error) {
+	pr := req.PR
+	repoName := fmt.Sprintf("%s/%s", pr.Owner, pr.Repo)
+	cmd := exec.CommandContext(ctx,
+		g.Path,
+		"review",
+		fmt.Sprintf("--repo-name=%s", repoName),
+		"-n", "3",
+		"-p", fmt.Sprintf("%d", pr.Number),
+		fmt.Sprintf("--output=%s", req.OutputPath), // cbpr writes to a temp dir unless told otherwise
+	)
+	cmd.Env = reviewEnv(pr.Host)
+
+	log.Printf("[CBPR] Executing: cbpr review --repo-name=%s -n 3 -p %d --output=%s", repoName, pr.Number, req.OutputPath)
+	return runReviewCommand(cmd, req, nil)
+}
+
+// CommandGenerator runs an arbitrary review tool. Argv is a template: {host}, {owner}, {repo},
+// {number}, {commit}, {url} and {output} are replaced in each argument. If no argument contains
+// {output}, the command's stdout is saved as the review instead.
+type CommandGenerator struct {
+	Argv []string
+}
+
+func (g *CommandGenerator) Name() string {
+	return "command"
+}
+
+func (g *CommandGenerator) Generate(ctx context.Context, req ReviewRequest) (*ReviewArtifact, error) {
+	if len(g.Argv) == 0 {
+		return nil, fmt.Errorf("no review command configured")
+	}
+
+	pr := req.PR
+	replacer := strings.NewReplacer(
+		"{host}", pr.Host,
+		"{owner}", pr.Owner,
+		"{repo}", pr.Repo,
+		"{number}", strconv.Itoa(pr.Number),
+		"{commit}", pr.CommitSHA,
+		"{url}", pr.URL,
+		"{output}", req.OutputPath,
+	)
+	argv := make([]string, len(g.Argv))
+	writesOutput := false
+	for i, arg := range g.Argv {
+		if strings.Contains(arg, "{output}") {
+			writesOutput = true
+		}
+		argv[i] = replacer.Replace(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = reviewEnv(pr.Host)
+
+	var stdout *bytes.Buffer
+	if !writesOutput {
+		stdout = &bytes.Buffer{}
+	}
+
+	log.Printf("[REVIEW] Executing: %s", strings.Join(argv, " "))
+	artifact, err := runReviewCommand(cmd, req, stdout)
+	if err != nil || stdout == nil {
+		return artifact, err
+	}
+	if err := os.WriteFile(req.OutputPath, stdout.Bytes(), 0644); err != nil {
+		return artifact, fmt.Errorf("failed to write review: %w", err)
+	}
+	return artifact, nil
+}
+
+// ErrReviewsDisabled is returned when asked to generate a review with REVIEW_GENERATOR=none
+var ErrReviewsDisabled = errors.New("review generation is disabled")
+
+// NoopGenerator generates no reviews. PRs are still tracked and shown on the dashboard.
+type NoopGenerator struct{}
+
+func (NoopGenerator) Name() string {
+	return "none"
+}
+
+func (NoopGenerator) Generate(ctx context.Context, req ReviewRequest) (*ReviewArtifact, error) {
+	return nil, ErrReviewsDisabled
+}
+
+// reviewWaitDelay is how long a cancelled review's output is drained after its process is killed.
+// Without it, a child that escaped the process group and still holds the output pipes would keep
+// the review running.
+const reviewWaitDelay = 5 * time.Second
+
+// runReviewCommand starts cmd in its own process group, reports its PID and waits for it. stdout,
+// when set, receives the command's stdout separately from the combined output kept for logs.
+func runReviewCommand(cmd *exec.Cmd, req ReviewRequest, stdout *bytes.Buffer) (*ReviewArtifact, error) {
+	startInProcessGroup(cmd)
+	cmd.WaitDelay = reviewWaitDelay
+
+	var output bytes.Buffer
+	var combined io.Writer = &output
+	if req.Log != nil {
+		combined = io.MultiWriter(&output, req.Log)
+	}
+	cmd.Stderr = combined
+	if stdout != nil {
+		cmd.Stdout = stdout
+	} else {
+		cmd.Stdout = combined
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+	if req.OnStart != nil {
+		req.OnStart(cmd.Process.Pid)
+	}
+
+	err := cmd.Wait()
+	exitCode := cmd.ProcessState.ExitCode()
+	if err != nil {
+		if output.Len() > 0 {
+			log.Printf("[REVIEW] Output of failed command: %s", output.String())
+		}
+		return &ReviewArtifact{Output: output.Bytes(), ExitCode: &exitCode}, fmt.Errorf("%s failed: %w", cmd.Path, err)
+	}
+
+	if _, err := os.Stat(req.OutputPath); os.IsNotExist(err) && stdout == nil {
+		return &ReviewArtifact{Output: output.Bytes(), ExitCode: &exitCode}, fmt.Errorf("%s succeeded but file not created at %s", cmd.Path, req.OutputPath)
+	}
+	return &ReviewArtifact{Path: req.OutputPath, Output: output.Bytes(), ExitCode: &exitCode}, nil
+}
+
+// reviewEnv returns the environment for a generator process, pointing gh at the PR's host when it isn't github.com
+func reviewEnv(host string) []string {
+	env := os.Environ()
+	if host != "" && host != "github.com" {
+		env = append(env, "GH_HOST="+host)
+	}
+	return env
+}
+
+// generatesReviews reports whether the generator produces reviews at all
+func generatesReviews(g ReviewGenerator) bool {
+	_, noop := g.(NoopGenerator)
+	return !noop
+}
@@ -0,0 +1,382 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pr-review-server/db"
+	"pr-review-server/db/dbtest"
+	"pr-review-server/github"
+)
+
+// recordingGenerator writes a placeholder review and records the PRs it was asked to review
+type recordingGenerator struct {
+	mu       sync.Mutex
+	requests []ReviewRequest
+}
+
+func (g *recordingGenerator) Name() string {
+	return "recording"
+}
+
+func (g *recordingGenerator) Generate(ctx context.Context, req ReviewRequest) (*ReviewArtifact, error) {
+	g.mu.Lock()
+	g.requests = append(g.requests, req)
+	g.mu.Unlock()
+	if err := os.WriteFile(req.OutputPath, []byte("<html>review</html>"), 0644); err != nil {
+		return nil, err
+	}
+	return &ReviewArtifact{Path: req.OutputPath}, nil
+}
+
+func testReviewRequest(t *testing.T) ReviewRequest {
+	t.Helper()
+	return ReviewRequest{
+		PR:         github.PullRequest{Host: "github.com", Owner: "acme", Repo: "api", Number: 7, CommitSHA: "abc1234"},
+		OutputPath: filepath.Join(t.TempDir(), "review.html"),
+	}
+}
+
+// TestCommandGenerator_Placeholders tests that the argv template is filled in per PR
+func TestCommandGenerator_Placeholders(t *testing.T) {
+	req := testReviewRequest(t)
+	var pid int
+	req.OnStart = func(p int) { pid = p }
+
+	g := &CommandGenerator{Argv: []string{"sh", "-c", `echo "$1" > "$2"`, "sh", "{owner}/{repo}#{number}@{commit}", "{output}"}}
+	if _, err := g.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	got, err := os.ReadFile(req.OutputPath)
+	if err != nil {
+		t.Fatalf("Expected review to be written: %v", err)
+	}
+	if strings.TrimSpace(string(got)) != "acme/api#7@abc1234" {
+		t.Errorf("Unexpected review contents %q", got)
+	}
+	if pid == 0 {
+		t.Errorf("Expected OnStart to receive the process PID")
+	}
+}
+
+// TestCommandGenerator_Stdout tests that stdout becomes the review when the template has no {output}
+func TestCommandGenerator_Stdout(t *testing.T) {
+	req := testReviewRequest(t)
+
+	g := &CommandGenerator{Argv: []string{"echo", "review of {owner}/{repo}#{number}"}}
+	if _, err := g.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(req.OutputPath)
+	if strings.TrimSpace(string(got)) != "review of acme/api#7" {
+		t.Errorf("Unexpected review contents %q", got)
+	}
+}
+
+// TestCommandGenerator_Failure tests that a failing command is reported and leaves no review
+func TestCommandGenerator_Failure(t *testing.T) {
+	req := testReviewRequest(t)
+
+	g := &CommandGenerator{Argv: []string{"sh", "-c", "exit 3"}}
+	if _, err := g.Generate(context.Background(), req); err == nil {
+		t.Fatal("Expected an error from a failing command")
+	}
+	if _, err := os.Stat(req.OutputPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no review file, got %v", err)
+	}
+}
+
+// TestNoopGenerator_ReviewsDisabled tests that the disabled generator reports ErrReviewsDisabled
+func TestNoopGenerator_ReviewsDisabled(t *testing.T) {
+	if _, err := (NoopGenerator{}).Generate(context.Background(), testReviewRequest(t)); !errors.Is(err, ErrReviewsDisabled) {
+		t.Errorf("Expected ErrReviewsDisabled, got %v", err)
+	}
+}
+
+// TestPoll_CustomReviewGenerator tests that the poller generates reviews through a plugged-in generator
+func TestPoll_CustomReviewGenerator(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	generator := &recordingGenerator{}
+	p.SetReviewGenerator(generator)
+	startTestWorkers(t, p)
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(context.Background())
+	p.reviews.waitIdle()
+
+	if len(generator.requests) != 1 || generator.requests[0].PR.Number != 1 {
+		t.Fatalf("Expected one review request for PR 1, got %+v", generator.requests)
+	}
+	pr, err := database.GetPR("github.com", "acme", "api", 1)
+	if err != nil || pr == nil {
+		t.Fatalf("Expected PR to be stored: %v", err)
+	}
+	if pr.Status != "completed" || pr.ReviewHTMLPath != filepath.Base(generator.requests[0].OutputPath) {
+		t.Errorf("Expected completed review at %s, got status=%s path=%s", filepath.Base(generator.requests[0].OutputPath), pr.Status, pr.ReviewHTMLPath)
+	}
+}
+
+// TestPoll_ReviewHistoryKeptPerCommit tests that a new commit gets a new review file and the review of
+// the previous commit stays on disk and in the PR's history
+func TestPoll_ReviewHistoryKeptPerCommit(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	generator := &recordingGenerator{}
+	p.SetReviewGenerator(generator)
+	startTestWorkers(t, p)
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(context.Background())
+	p.reviews.waitIdle()
+	fake.PushCommit("acme", "api", 1, "bbbbbbb2")
+	p.poll(context.Background())
+	p.reviews.waitIdle()
+
+	pr, err := database.GetPR("github.com", "acme", "api", 1)
+	if err != nil || pr == nil {
+		t.Fatalf("Expected PR to be stored: %v", err)
+	}
+	reviews, err := database.GetReviews(pr.ID)
+	if err != nil {
+		t.Fatalf("GetReviews failed: %v", err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("Expected 2 reviews, got %+v", reviews)
+	}
+	if reviews[0].CommitSHA != "bbbbbbb2" || reviews[1].CommitSHA != "aaaaaaa1" {
+		t.Errorf("Expected newest review first, got %s then %s", reviews[0].CommitSHA, reviews[1].CommitSHA)
+	}
+	for _, review := range reviews {
+		if review.Status != "completed" || review.Generator != "recording" || review.FinishedAt == nil {
+			t.Errorf("Expected finished completed review by recording, got %+v", review)
+		}
+		if _, err := os.Stat(filepath.Join(p.reviewDir, review.ArtifactPath)); err != nil {
+			t.Errorf("Expected review file for %s to be kept: %v", review.CommitSHA, err)
+		}
+	}
+	if reviews[0].ArtifactPath == reviews[1].ArtifactPath {
+		t.Errorf("Expected a separate file per commit, both are %s", reviews[0].ArtifactPath)
+	}
+	if pr.ReviewHTMLPath != reviews[0].ArtifactPath {
+		t.Errorf("Expected PR to link to the latest review %s, got %s", reviews[0].ArtifactPath, pr.ReviewHTMLPath)
+	}
+
+	// Closing the PR archives it with its history
+	fake.ClosePR("acme", "api", 1, true)
+	p.poll(context.Background())
+	if remaining, _ := database.GetReviews(pr.ID); len(remaining) != 2 {
+		t.Errorf("Expected review history to be kept, got %+v", remaining)
+	}
+
+	// Deleting it once past the retention removes every review file along with the history
+	p.cfg.ArchiveRetention = time.Nanosecond
+	p.poll(context.Background())
+	for _, review := range reviews {
+		if _, err := os.Stat(filepath.Join(p.reviewDir, review.ArtifactPath)); !os.IsNotExist(err) {
+			t.Errorf("Expected review file %s to be deleted, got %v", review.ArtifactPath, err)
+		}
+	}
+	if remaining, _ := database.GetReviews(pr.ID); len(remaining) != 0 {
+		t.Errorf("Expected review history to be deleted, got %+v", remaining)
+	}
+}
+
+// TestRunReviewJob_FailureLogged tests that a failed run's output, exit code and log file are recorded
+func TestRunReviewJob_FailureLogged(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	p.SetReviewGenerator(&CommandGenerator{Argv: []string{"sh", "-c", "echo 'Error: quota exceeded' >&2; exit 3"}})
+	startTestWorkers(t, p)
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(context.Background())
+	p.reviews.waitIdle()
+
+	pr, err := database.GetPR("github.com", "acme", "api", 1)
+	if err != nil || pr == nil {
+		t.Fatalf("Expected PR to be stored: %v", err)
+	}
+	if pr.Status != "error" {
+		t.Errorf("Expected error status, got %s", pr.Status)
+	}
+	reviews, err := database.GetReviews(pr.ID)
+	if err != nil || len(reviews) != 1 {
+		t.Fatalf("Expected one review run, got %+v (%v)", reviews, err)
+	}
+	review := reviews[0]
+	if review.Status != "error" || review.ExitCode == nil || *review.ExitCode != 3 {
+		t.Errorf("Expected error with exit code 3, got %+v", review)
+	}
+	if !strings.Contains(review.StderrExcerpt, "quota exceeded") {
+		t.Errorf("Expected stderr in excerpt, got %q", review.StderrExcerpt)
+	}
+	logData, err := os.ReadFile(filepath.Join(p.reviewDir, review.LogPath))
+	if err != nil {
+		t.Fatalf("Expected log file %q: %v", review.LogPath, err)
+	}
+	if !strings.Contains(string(logData), "quota exceeded") || !strings.Contains(string(logData), "review failed") {
+		t.Errorf("Expected output and failure in log, got %q", logData)
+	}
+}
+
+// TestRunReviewJob_RetriesWithBackoff tests that a failing review is retried once its backoff has
+// elapsed, marked failed after the last attempt, and started afresh on a new commit
+func TestRunReviewJob_RetriesWithBackoff(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	p.cfg.ReviewMaxAttempts = 2
+	p.cfg.ReviewRetryBackoff = time.Hour
+	p.SetReviewGenerator(&CommandGenerator{Argv: []string{"sh", "-c", "echo 'Error: quota exceeded' >&2; exit 3"}})
+	startTestWorkers(t, p)
+	ctx := context.Background()
+
+	getPR := func() *db.PR {
+		t.Helper()
+		pr, err := database.GetPR("github.com", "acme", "api", 1)
+		if err != nil || pr == nil {
+			t.Fatalf("Expected PR to be stored: %v", err)
+		}
+		return pr
+	}
+	runs := func() int {
+		t.Helper()
+		reviews, err := database.GetReviews(getPR().ID)
+		if err != nil {
+			t.Fatalf("Failed to get reviews: %v", err)
+		}
+		return len(reviews)
+	}
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(ctx)
+	p.reviews.waitIdle()
+
+	pr := getPR()
+	if pr.Status != "error" || pr.ReviewAttempts != 1 || pr.NextRetryAt == nil {
+		t.Fatalf("Expected error with a retry scheduled after one attempt, got %s/%d/%v", pr.Status, pr.ReviewAttempts, pr.NextRetryAt)
+	}
+	if wait := time.Until(*pr.NextRetryAt); wait < 55*time.Minute || wait > time.Hour {
+		t.Errorf("Expected retry in about an hour, got %v", wait)
+	}
+	if !strings.Contains(pr.LastError, "exit status 3") {
+		t.Errorf("Expected last error to be recorded, got %q", pr.LastError)
+	}
+
+	// Not due yet: polling again doesn't rerun the review
+	p.poll(ctx)
+	p.reviews.waitIdle()
+	if got := runs(); got != 1 {
+		t.Errorf("Expected no retry before the backoff elapsed, got %d runs", got)
+	}
+
+	if n, err := database.ResetDueErrorPRs(time.Now().Add(2 * time.Hour)); err != nil || n != 1 {
+		t.Fatalf("Expected the PR to be due for retry, got %d (%v)", n, err)
+	}
+	p.poll(ctx)
+	p.reviews.waitIdle()
+
+	pr = getPR()
+	if pr.Status != "failed" || pr.ReviewAttempts != 2 || pr.NextRetryAt != nil {
+		t.Fatalf("Expected failed with no retry after the last attempt, got %s/%d/%v", pr.Status, pr.ReviewAttempts, pr.NextRetryAt)
+	}
+	if n, err := database.ResetDueErrorPRs(time.Now().Add(24 * time.Hour)); err != nil || n != 0 {
+		t.Errorf("Expected failed PRs not to be retried automatically, got %d (%v)", n, err)
+	}
+	p.poll(ctx)
+	p.reviews.waitIdle()
+	if got := runs(); got != 2 {
+		t.Errorf("Expected 2 runs once failed, got %d", got)
+	}
+
+	// A new commit gets a fresh set of attempts
+	fake.PushCommit("acme", "api", 1, "aaaaaaa2")
+	p.poll(ctx)
+	p.reviews.waitIdle()
+
+	pr = getPR()
+	if pr.LastCommitSHA != "aaaaaaa2" || pr.Status != "error" || pr.ReviewAttempts != 1 {
+		t.Errorf("Expected first attempt at the new commit, got %s %s/%d", pr.LastCommitSHA, pr.Status, pr.ReviewAttempts)
+	}
+}
+
+// racingStore runs change just before SetPRGenerating, as if the PR changed after runReviewJob
+// checked it
+type racingStore struct {
+	db.Store
+	change func()
+}
+
+func (s *racingStore) SetPRGenerating(host, owner, repo string, prNumber int, commitSHA string) (bool, error) {
+	s.change()
+	return s.Store.SetPRGenerating(host, owner, repo, prNumber, commitSHA)
+}
+
+// TestRunReviewJob_PRChangedBeforeStart tests that a review isn't started when the PR moves to a new
+// commit or is cancelled between runReviewJob's check and marking it generating
+func TestRunReviewJob_PRChangedBeforeStart(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(database *db.DB) error
+		wantStatus string
+		wantSHA    string
+	}{
+		{"new commit", func(database *db.DB) error {
+			return database.ResetPRToOutdated("github.com", "acme", "api", 1, "bbbbbbb2")
+		}, "pending", "bbbbbbb2"},
+		{"cancelled", func(database *db.DB) error {
+			_, err := database.CancelPRReview("github.com", "acme", "api", 1)
+			return err
+		}, "cancelled", "aaaaaaa1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, database, _ := newTestPoller(t)
+			generator := &recordingGenerator{}
+			p.SetReviewGenerator(generator)
+			dbtest.AddPR(t, database, db.PR{RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "aaaaaaa1", Status: "pending"})
+			p.db = &racingStore{Store: database, change: func() {
+				if err := tt.change(database); err != nil {
+					t.Fatalf("Failed to change PR: %v", err)
+				}
+			}}
+
+			p.runReviewJob(context.Background(), testJob("api", 1, "aaaaaaa1"))
+
+			if len(generator.requests) != 0 {
+				t.Errorf("Expected no review to be generated, got %+v", generator.requests)
+			}
+			pr, err := database.GetPR("github.com", "acme", "api", 1)
+			if err != nil || pr == nil {
+				t.Fatalf("Expected PR to be stored: %v", err)
+			}
+			if pr.Status != tt.wantStatus || pr.LastCommitSHA != tt.wantSHA {
+				t.Errorf("Expected %s at %s, got %s at %s", tt.wantStatus, tt.wantSHA, pr.Status, pr.LastCommitSHA)
+			}
+			if reviews, _ := database.GetReviews(pr.ID); len(reviews) != 0 {
+				t.Errorf("Expected no review run to be recorded, got %+v", reviews)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{4, 40 * time.Minute},
+		{20, 2560 * time.Minute}, // Stops doubling once past a day
+	}
+	for _, tt := range tests {
+		if got := retryBackoff(5*time.Minute, tt.attempts); got != tt.want {
+			t.Errorf("retryBackoff(5m, %d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
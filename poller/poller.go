@@ -3,12 +3,16 @@ package poller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -20,81 +24,56 @@ import (
 
 type Poller struct {
 	cfg             *config.Config
-	db              *db.DB
-	ghClient        *github.Client
+	db              db.Store
+	accounts        []github.Account
+	generator       ReviewGenerator
 	reviewDir       string
 	cacheUpdateFunc func([]github.PullRequest)
 	triggerChan     chan struct{}
 	polling         bool
 	pollMutex       sync.Mutex
-	cbprPID         int
-	cbprStartTime   time.Time
-	cbprMutex       sync.Mutex
-	// Track active review processes for cancellation
-	activeReviews map[string]int // prKey (owner/repo/number) -> PID
+	// Review jobs waiting for a worker, and the processes of running ones for cancellation
+	reviews       *reviewQueue
+	activeReviews map[string]reviewProcess // prKey (host:owner/repo#number) -> process
 	reviewsMutex  sync.Mutex
 	// Track last poll time for countdown display
 	lastPollTime time.Time
 	pollTimeMutex sync.RWMutex
 	// Track ticker start time for accurate countdown
 	tickerStartTime time.Time
+	// Accounts skipped until their rate limit resets
+	deferredUntil map[string]time.Time // account name -> when it can be polled again
+	deferredMutex sync.Mutex
+	// PRs queued for a targeted refresh (e.g. from webhooks)
+	refreshQueue map[string]prRef // prKey -> PR
+	refreshMutex sync.Mutex
+	refreshChan  chan struct{}
 }
 
-func New(cfg *config.Config, database *db.DB, ghClient *github.Client) *Poller {
+func New(cfg *config.Config, database db.Store, accounts []github.Account) *Poller {
 	return &Poller{
 		cfg:           cfg,
 		db:            database,
-		ghClient:      ghClient,
+		accounts:      accounts,
+		generator:     NewReviewGenerator(cfg),
 		reviewDir:     cfg.ReviewsDir,
 		triggerChan:   make(chan struct{}, 1), // Buffered to prevent blocking
-		activeReviews: make(map[string]int),
+		reviews:       newReviewQueue(cfg.ReviewRepoConcurrency),
+		activeReviews: make(map[string]reviewProcess),
+		deferredUntil: make(map[string]time.Time),
+		refreshQueue:  make(map[string]prRef),
+		refreshChan:   make(chan struct{}, 1),
 	}
 }
 
-// upsertPRPreservingReviewData upserts a PR while preserving existing review data (doesn't fetch from GitHub)
-// This is used when updating PR status/files but we want to keep approval counts unchanged
-func (p *Poller) upsertPRPreservingReviewData(ctx context.Context, owner, repo string, prNumber int, commitSHA, htmlPath, status, title, author string, isMine bool, createdAt *time.Time, draft bool) error {
-	// Get existing PR to preserve review data
-	existingPR, err := p.db.GetPR(owner, repo, prNumber)
-	if err != nil {
-		log.Printf("[DB] Error: failed to get existing PR data for %s/%s#%d: %v", owner, repo, prNumber, err)
-		return err // Propagate DB error to prevent data loss
-	}
-
-	// Default to existing values (or 0 if no existing PR)
-	approvalCount := 0
-	myReviewStatus := ""
-	notes := ""
-	if existingPR != nil {
-		approvalCount = existingPR.ApprovalCount
-		myReviewStatus = existingPR.MyReviewStatus
-		notes = existingPR.Notes
-	}
-
-	pr := &db.PR{
-		RepoOwner:      owner,
-		RepoName:       repo,
-		PRNumber:       prNumber,
-		LastCommitSHA:  commitSHA,
-		ReviewHTMLPath: htmlPath,
-		Status:         status,
-		Title:          title,
-		Author:         author,
-		IsMine:         isMine,
-		ApprovalCount:  approvalCount,
-		MyReviewStatus: myReviewStatus,
-		CreatedAt:      createdAt,
-		Draft:          draft,
-		Notes:          notes,
-	}
-
-	// Set LastReviewedAt when marking as completed
-	if status == "completed" {
-		now := time.Now().UTC()
-		pr.LastReviewedAt = &now
-	}
-
-	return p.db.UpsertPR(pr)
+// SetReviewGenerator replaces the generator chosen from the config
+func (p *Poller) SetReviewGenerator(g ReviewGenerator) {
+	p.generator = g
+}
+
+// GetReviewGenerator returns the name of the review generator in use
+func (p *Poller) GetReviewGenerator() string {
+	return p.generator.Name()
 }
 
 func (p *Poller) SetCacheUpdateFunc(f func([]github.PullRequest)) {
@@ -121,10 +100,18 @@ func (p *Poller) Start(ctx context.Context) {
 	p.tickerStartTime = tickerStartTime
 	p.pollTimeMutex.Unlock()
 
-	// Start cbpr process monitor
+	// Reviews left generating by a previous run will never finish
+	if count, err := p.db.CancelUnfinishedReviews(); err != nil {
+		log.Printf("[REVIEW] ERROR: Failed to cancel unfinished reviews: %v", err)
+	} else if count > 0 {
+		log.Printf("[REVIEW] Cancelled %d reviews left unfinished by a previous run", count)
+	}
+
+	// Start review workers and the process monitor
+	p.startReviewWorkers(ctx)
 	monitorTicker := time.NewTicker(30 * time.Second)
 	defer monitorTicker.Stop()
-	go p.monitorCbprProcesses(ctx, monitorTicker)
+	go p.monitorReviewProcesses(ctx, monitorTicker)
 
 	log.Println("Starting poller...")
 	log.Printf("Ticker created at %s, will fire every %v", tickerStartTime.Format("15:04:05.000"), p.cfg.PollingInterval)
@@ -143,51 +130,86 @@ func (p *Poller) Start(ctx context.Context) {
 			p.startPoll(ctx, "scheduled")
 		case <-p.triggerChan:
 			p.startPoll(ctx, "manual")
+		case <-p.refreshChan:
+			p.startRefresh(ctx)
 		}
 	}
 }
 
-func (p *Poller) monitorCbprProcesses(ctx context.Context, ticker *time.Ticker) {
+func (p *Poller) monitorReviewProcesses(ctx context.Context, ticker *time.Ticker) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			p.cbprMutex.Lock()
-			if p.cbprPID != 0 {
-				elapsed := time.Since(p.cbprStartTime)
-				if elapsed > 5*time.Minute {
-					log.Printf("[MONITOR] WARNING: cbpr process %d has been running for %v, killing it", p.cbprPID, elapsed)
-					// Kill the process
-					process, err := os.FindProcess(p.cbprPID)
-					if err == nil {
-						process.Kill()
-					}
-					p.cbprPID = 0
-				} else if elapsed > 2*time.Minute {
-					log.Printf("[MONITOR] WARNING: cbpr process %d has been running for %v (threshold: 2m)", p.cbprPID, elapsed)
+			timeout := p.cfg.ReviewTimeout
+			p.reviewsMutex.Lock()
+			for key, proc := range p.activeReviews {
+				elapsed := time.Since(proc.started)
+				if timeout > 0 && elapsed > timeout+reviewWaitDelay {
+					// The job's deadline should already have killed it
+					log.Printf("[MONITOR] WARNING: review process %d for %s has been running for %v, past REVIEW_TIMEOUT (%v), killing it", proc.pid, key, elapsed, timeout)
+					killProcessGroup(proc.pid)
+				} else if timeout > 0 && elapsed > timeout/2 {
+					log.Printf("[MONITOR] WARNING: review process %d for %s has been running for %v (timeout: %v)", proc.pid, key, elapsed, timeout)
 				} else {
-					log.Printf("[MONITOR] cbpr process %d running normally (%v elapsed)", p.cbprPID, elapsed)
+					log.Printf("[MONITOR] review process %d for %s running normally (%v elapsed)", proc.pid, key, elapsed)
 				}
 			}
-			p.cbprMutex.Unlock()
+			p.reviewsMutex.Unlock()
 		}
 	}
 }
 
-func (p *Poller) GetCbprStatus() (running bool, duration time.Duration) {
-	p.cbprMutex.Lock()
-	defer p.cbprMutex.Unlock()
-	if p.cbprPID != 0 {
+// RunningReview is a review generator process that is currently running
+type RunningReview struct {
+	Host      string
+	Owner     string
+	Repo      string
+	Number    int
+	PID       int
+	StartedAt time.Time
+}
+
+// GetRunningReviews returns the review processes currently running, oldest first
+func (p *Poller) GetRunningReviews() []RunningReview {
+	p.reviewsMutex.Lock()
+	defer p.reviewsMutex.Unlock()
+
+	running := make([]RunningReview, 0, len(p.activeReviews))
+	for key, proc := range p.activeReviews {
 		// Verify the process is actually still running
-		if !p.isPIDRunning(p.cbprPID) {
-			log.Printf("[MONITOR] WARNING: Tracked PID %d is no longer running, clearing", p.cbprPID)
-			p.cbprPID = 0
-			return false, 0
+		if !p.isPIDRunning(proc.pid) {
+			log.Printf("[MONITOR] WARNING: Tracked PID %d is no longer running, clearing", proc.pid)
+			delete(p.activeReviews, key)
+			continue
 		}
-		return true, time.Since(p.cbprStartTime)
-	}
-	return false, 0
+		running = append(running, RunningReview{
+			Host:      proc.pr.Host,
+			Owner:     proc.pr.Owner,
+			Repo:      proc.pr.Repo,
+			Number:    proc.pr.Number,
+			PID:       proc.pid,
+			StartedAt: proc.started,
+		})
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].StartedAt.Before(running[j].StartedAt) })
+	return running
+}
+
+// GetQueuedReviewCount returns the number of reviews waiting for a worker
+func (p *Poller) GetQueuedReviewCount() int {
+	return p.reviews.waitingCount()
+}
+
+// GetReviewQueue returns the reviews waiting for a worker, next to run first
+func (p *Poller) GetReviewQueue() []QueuedReview {
+	return p.reviews.snapshot()
+}
+
+// SetPriorityFunc sets where review priorities come from. Set it before Start.
+func (p *Poller) SetPriorityFunc(f PriorityFunc) {
+	p.reviews.priority = f
 }
 
 func (p *Poller) GetLastPollTime() time.Time {
@@ -246,56 +268,83 @@ func (p *Poller) isPIDRunning(pid int) bool {
 }
 
 // prKey creates a unique key for tracking a PR
-func prKey(owner, repo string, number int) string {
-	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+func prKey(host, owner, repo string, number int) string {
+	return fmt.Sprintf("%s:%s/%s#%d", host, owner, repo, number)
+}
+
+// reviewProcess is a running review generator process
+type reviewProcess struct {
+	pr      github.PullRequest
+	pid     int
+	started time.Time
 }
 
 // trackReview adds a PR's review process to the active reviews map
-func (p *Poller) trackReview(owner, repo string, number, pid int) {
+func (p *Poller) trackReview(pr github.PullRequest, pid int) {
 	p.reviewsMutex.Lock()
 	defer p.reviewsMutex.Unlock()
-	key := prKey(owner, repo, number)
-	p.activeReviews[key] = pid
+	key := prKey(pr.Host, pr.Owner, pr.Repo, pr.Number)
+	p.activeReviews[key] = reviewProcess{pr: pr, pid: pid, started: time.Now()}
 	log.Printf("[TRACK] Tracking review for %s with PID %d", key, pid)
 }
 
 // untrackReview removes a PR's review process from the active reviews map
-func (p *Poller) untrackReview(owner, repo string, number int) {
+func (p *Poller) untrackReview(host, owner, repo string, number int) {
 	p.reviewsMutex.Lock()
 	defer p.reviewsMutex.Unlock()
-	key := prKey(owner, repo, number)
+	key := prKey(host, owner, repo, number)
 	delete(p.activeReviews, key)
 	log.Printf("[TRACK] Untracked review for %s", key)
 }
 
-// killReview kills an active review process if it exists
-func (p *Poller) killReview(owner, repo string, number int) bool {
+// killReview kills an active review process, and any processes it started, if it exists
+func (p *Poller) killReview(host, owner, repo string, number int) bool {
 	p.reviewsMutex.Lock()
-	key := prKey(owner, repo, number)
-	pid, exists := p.activeReviews[key]
+	key := prKey(host, owner, repo, number)
+	proc, exists := p.activeReviews[key]
 	p.reviewsMutex.Unlock()
 
 	if !exists {
 		return false
 	}
+	pid := proc.pid
 
-	log.Printf("[KILL] Attempting to kill review process for %s (PID %d)", key, pid)
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		log.Printf("[KILL] Failed to find process %d: %v", pid, err)
-		return false
-	}
-
-	if err := process.Kill(); err != nil {
+	log.Printf("[KILL] Attempting to kill review process group for %s (PID %d)", key, pid)
+	if err := killProcessGroup(pid); err != nil {
 		log.Printf("[KILL] Failed to kill process %d: %v", pid, err)
 		return false
 	}
 
 	log.Printf("[KILL] Successfully killed process %d for %s", pid, key)
-	p.untrackReview(owner, repo, number)
+	p.untrackReview(host, owner, repo, number)
 	return true
 }
 
+// resetStaleGeneratingPRs resets PRs marked "generating" that no review worker is running, so they
+// are queued again. Running reviews are left alone however long they take; REVIEW_TIMEOUT bounds them.
+func (p *Poller) resetStaleGeneratingPRs() (int, error) {
+	prs, err := p.db.GetAllPRs()
+	if err != nil {
+		return 0, err
+	}
+
+	reset := 0
+	for _, pr := range prs {
+		if pr.Status != "generating" || p.reviews.isRunning(prKey(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber)) {
+			continue
+		}
+		ok, err := p.db.ResetGeneratingPR(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber)
+		if err != nil {
+			return reset, err
+		}
+		if ok {
+			log.Printf("[POLL] PR %s/%s#%d is marked generating but no review is running, resetting to pending", pr.RepoOwner, pr.RepoName, pr.PRNumber)
+			reset++
+		}
+	}
+	return reset, nil
+}
+
 func (p *Poller) startPoll(ctx context.Context, trigger string) {
 	p.pollMutex.Lock()
 	if p.polling {
@@ -314,60 +363,121 @@ func (p *Poller) startPoll(ctx context.Context, trigger string) {
 			p.polling = false
 			p.pollMutex.Unlock()
 			log.Printf("Completed %s poll", trigger)
+			// Pick up PRs queued for refresh while the poll ran
+			p.signalRefresh()
 		}()
 		p.poll(ctx)
 	}()
 }
 
-// cleanupClosedPRs removes PRs from the database and filesystem if they're closed on GitHub
-func (p *Poller) cleanupClosedPRs(ctx context.Context) (int, error) {
-	// Get all PRs from database
+// archiveClosedPRs archives the account's PRs that have been closed or merged on GitHub
+func (p *Poller) archiveClosedPRs(acct github.Account, states map[string]*github.PRState) (int, error) {
+	// Get all open PRs from database
 	allPRs, err := p.db.GetAllPRs()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get PRs from database: %w", err)
 	}
 
-	removed := 0
-	for _, pr := range allPRs {
+	archived := 0
+	for _, pr := range filterAccountPRs(allPRs, acct) {
 		// Check if PR is still open on GitHub
-		isOpen, err := p.ghClient.IsPROpen(ctx, pr.RepoOwner, pr.RepoName, pr.PRNumber)
-		if err != nil {
+		state, ok := states[fmt.Sprintf("%s/%s/%d", pr.RepoOwner, pr.RepoName, pr.PRNumber)]
+		if !ok {
 			// If we can't fetch the PR, it might be deleted or we don't have access
 			// Log but continue - we'll handle it on next poll
-			log.Printf("[CLEANUP] Warning: Could not check status of PR %s/%s#%d: %v",
-				pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+			log.Printf("[CLEANUP] Warning: Could not check status of PR %s/%s#%d",
+				pr.RepoOwner, pr.RepoName, pr.PRNumber)
 			continue
 		}
 
-		// If PR is closed, remove it
-		if !isOpen {
-			log.Printf("[CLEANUP] PR %s/%s#%d is closed, removing from system",
-				pr.RepoOwner, pr.RepoName, pr.PRNumber)
+		// If PR is closed, archive it
+		if !state.IsOpen() && p.archiveClosedPR(pr, state) {
+			archived++
+		}
+	}
 
-			// Delete HTML file if it exists
-			if pr.ReviewHTMLPath != "" {
-				htmlPath := filepath.Join(p.reviewDir, pr.ReviewHTMLPath)
-				if err := os.Remove(htmlPath); err != nil && !os.IsNotExist(err) {
-					log.Printf("[CLEANUP] Warning: Failed to delete HTML file %s: %v", htmlPath, err)
-				} else if err == nil {
-					log.Printf("[CLEANUP] Deleted HTML file: %s", htmlPath)
-				}
-			}
+	return archived, nil
+}
 
-			// Delete from database
-			if err := p.db.DeletePR(pr.RepoOwner, pr.RepoName, pr.PRNumber); err != nil {
-				log.Printf("[CLEANUP] ERROR: Failed to delete PR %s/%s#%d from database: %v",
-					pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
-				continue
-			}
+// archiveClosedPR stops any review of a closed or merged PR and moves it to the archive. Its review
+// files and history are kept until the archive retention runs out.
+func (p *Poller) archiveClosedPR(pr db.PR, state *github.PRState) bool {
+	log.Printf("[CLEANUP] PR %s/%s#%d is %s, archiving",
+		pr.RepoOwner, pr.RepoName, pr.PRNumber, strings.ToLower(state.State))
 
-			log.Printf("[CLEANUP] Successfully removed closed PR %s/%s#%d",
-				pr.RepoOwner, pr.RepoName, pr.PRNumber)
-			removed++
+	// GitHub reports when it was closed; fall back to now for responses without it
+	closedAt := time.Now()
+	if state.ClosedAt != nil {
+		closedAt = *state.ClosedAt
+	}
+	if _, err := p.ArchivePR(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, closedAt, state.MergedAt); err != nil {
+		log.Printf("[CLEANUP] ERROR: Failed to archive PR %s/%s#%d: %v",
+			pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+		return false
+	}
+
+	log.Printf("[CLEANUP] Archived closed PR %s/%s#%d",
+		pr.RepoOwner, pr.RepoName, pr.PRNumber)
+	return true
+}
+
+// pruneArchivedPRs deletes archived PRs closed longer ago than ARCHIVE_RETENTION, with their review
+// files and history
+func (p *Poller) pruneArchivedPRs() (int, error) {
+	if p.cfg.ArchiveRetention <= 0 {
+		return 0, nil
+	}
+	expired, err := p.db.GetArchivedPRsClosedBefore(time.Now().Add(-p.cfg.ArchiveRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get archived PRs: %w", err)
+	}
+
+	pruned := 0
+	for _, pr := range expired {
+		// Delete HTML files and logs of the current and past reviews
+		if removed := RemoveReviewFiles(p.db, p.reviewDir, &pr); removed > 0 {
+			log.Printf("[CLEANUP] Deleted %d review files of %s/%s#%d", removed, pr.RepoOwner, pr.RepoName, pr.PRNumber)
+		}
+		if err := p.db.DeletePR(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber); err != nil {
+			log.Printf("[CLEANUP] ERROR: Failed to delete archived PR %s/%s#%d from database: %v",
+				pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+			continue
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// RemoveReviewFiles deletes the HTML files and output logs of a PR's current and past reviews from
+// reviewsDir and returns how many were deleted. The PR's database rows are left to the caller.
+func RemoveReviewFiles(database db.Store, reviewsDir string, pr *db.PR) int {
+	paths := map[string]bool{}
+	if pr.ReviewHTMLPath != "" {
+		paths[pr.ReviewHTMLPath] = true
+	}
+	reviews, err := database.GetReviews(pr.ID)
+	if err != nil {
+		log.Printf("[CLEANUP] Warning: Failed to get review history for %s/%s#%d: %v", pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+	}
+	for _, review := range reviews {
+		if review.ArtifactPath != "" {
+			paths[review.ArtifactPath] = true
+		}
+		if review.LogPath != "" {
+			paths[review.LogPath] = true
 		}
 	}
 
-	return removed, nil
+	removed := 0
+	for path := range paths {
+		htmlPath := filepath.Join(reviewsDir, path)
+		if err := os.Remove(htmlPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("[CLEANUP] Warning: Failed to delete HTML file %s: %v", htmlPath, err)
+		} else if err == nil {
+			removed++
+		}
+	}
+	return removed
 }
 
 // speak uses platform-appropriate TTS command for voice notifications
@@ -402,13 +512,14 @@ func (p *Poller) speak(message string) {
 	}()
 }
 
-// backfillPRMetadata fills in missing title/author for existing PRs by fetching from GitHub
-func (p *Poller) backfillPRMetadata(ctx context.Context) (int, error) {
+// backfillPRMetadata fills in missing title/author for existing PRs from their fetched state
+func (p *Poller) backfillPRMetadata(acct github.Account, states map[string]*github.PRState) (int, error) {
 	// Get PRs with missing metadata
 	prs, err := p.db.GetPRsWithMissingMetadata()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get PRs with missing metadata: %w", err)
 	}
+	prs = filterAccountPRs(prs, acct)
 
 	if len(prs) == 0 {
 		return 0, nil
@@ -416,23 +527,23 @@ func (p *Poller) backfillPRMetadata(ctx context.Context) (int, error) {
 
 	updated := 0
 	for _, pr := range prs {
-		// Fetch PR details from GitHub
-		title, author, err := p.ghClient.GetPRDetails(ctx, pr.RepoOwner, pr.RepoName, pr.PRNumber)
-		if err != nil {
-			log.Printf("[BACKFILL] Warning: Could not fetch PR details for %s/%s#%d: %v",
-				pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+		key := fmt.Sprintf("%s/%s/%d", pr.RepoOwner, pr.RepoName, pr.PRNumber)
+		detail, ok := states[key]
+		if !ok {
+			log.Printf("[BACKFILL] Warning: Could not fetch PR details for %s/%s#%d",
+				pr.RepoOwner, pr.RepoName, pr.PRNumber)
 			continue
 		}
 
 		// Update database with metadata
-		if err := p.db.UpdatePRMetadata(pr.RepoOwner, pr.RepoName, pr.PRNumber, title, author); err != nil {
+		if err := p.db.UpdatePRMetadata(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, detail.Title, detail.Author); err != nil {
 			log.Printf("[BACKFILL] ERROR: Failed to update metadata for %s/%s#%d: %v",
 				pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
 			continue
 		}
 
 		log.Printf("[BACKFILL] Updated metadata for PR %s/%s#%d: %s by %s",
-			pr.RepoOwner, pr.RepoName, pr.PRNumber, title, author)
+			pr.RepoOwner, pr.RepoName, pr.PRNumber, detail.Title, detail.Author)
 		updated++
 	}
 
@@ -440,31 +551,38 @@ func (p *Poller) backfillPRMetadata(ctx context.Context) (int, error) {
 }
 
 // backfillPRCreatedAt fills in missing created_at timestamps for existing PRs by fetching from GitHub
-func (p *Poller) backfillPRCreatedAt(ctx context.Context) (int, error) {
+func (p *Poller) backfillPRCreatedAt(ctx context.Context, acct github.Account) (int, error) {
 	// Get PRs with missing created_at
 	prs, err := p.db.GetPRsWithMissingCreatedAt()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get PRs with missing created_at: %w", err)
 	}
+	prs = filterAccountPRs(prs, acct)
 
 	if len(prs) == 0 {
 		return 0, nil
 	}
 
+	// Batch fetch PR details from GitHub (one GraphQL query per repository)
+	details, err := acct.Client.BatchGetPRDetails(ctx, toPullRequests(prs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch PR details: %w", err)
+	}
+
 	updated := 0
 	for _, pr := range prs {
-		// Fetch PR details from GitHub
-		ghPR, _, err := p.ghClient.GetPR(ctx, pr.RepoOwner, pr.RepoName, pr.PRNumber)
-		if err != nil {
-			log.Printf("[BACKFILL] Warning: Could not fetch PR for created_at %s/%s#%d: %v",
-				pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+		key := fmt.Sprintf("%s/%s/%d", pr.RepoOwner, pr.RepoName, pr.PRNumber)
+		detail, ok := details[key]
+		if !ok {
+			log.Printf("[BACKFILL] Warning: Could not fetch PR for created_at %s/%s#%d",
+				pr.RepoOwner, pr.RepoName, pr.PRNumber)
 			continue
 		}
 
-		createdAt := ghPR.GetCreatedAt().Time
+		createdAt := detail.CreatedAt
 
 		// Update database with created_at
-		if err := p.db.UpdatePRCreatedAt(pr.RepoOwner, pr.RepoName, pr.PRNumber, createdAt); err != nil {
+		if err := p.db.UpdatePRCreatedAt(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, createdAt); err != nil {
 			log.Printf("[BACKFILL] ERROR: Failed to update created_at for %s/%s#%d: %v",
 				pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
 			continue
@@ -478,13 +596,95 @@ func (p *Poller) backfillPRCreatedAt(ctx context.Context) (int, error) {
 	return updated, nil
 }
 
+// toPullRequests converts database PRs to the github.PullRequest shape used by batch queries
+func toPullRequests(prs []db.PR) []github.PullRequest {
+	ghPRs := make([]github.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		ghPRs = append(ghPRs, github.PullRequest{
+			Host:      pr.Host,
+			Account:   pr.Account,
+			Owner:     pr.RepoOwner,
+			Repo:      pr.RepoName,
+			Number:    pr.PRNumber,
+			CommitSHA: pr.LastCommitSHA,
+		})
+	}
+	return ghPRs
+}
+
+// accountPRs filters database PRs down to those polled by acct.
+// Rows without an account predate multi-account support and belong to any account on their host.
+func filterAccountPRs(prs []db.PR, acct github.Account) []db.PR {
+	var filtered []db.PR
+	for _, pr := range prs {
+		if pr.Host == acct.Host && (pr.Account == "" || pr.Account == acct.Name) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// reviewFilename returns the HTML filename for a PR's review.
+// github.com keeps the original owner_repo_number.html naming; other hosts are prefixed to avoid collisions.
+func reviewFilename(host, owner, repo string, number int) string {
+	if host == "" || host == "github.com" {
+		return fmt.Sprintf("%s_%s_%d.html", owner, repo, number)
+	}
+	return fmt.Sprintf("%s_%s_%s_%d.html", host, owner, repo, number)
+}
+
+// reviewArtifactFilename returns the HTML filename for a PR's review at one commit, so reviews of
+// earlier commits are kept alongside it
+func reviewArtifactFilename(host, owner, repo string, number int, commitSHA string) string {
+	if len(commitSHA) > 12 {
+		commitSHA = commitSHA[:12]
+	}
+	return strings.TrimSuffix(reviewFilename(host, owner, repo, number), ".html") + "_" + commitSHA + ".html"
+}
+
+// reviewLogsDir is the subdirectory of the reviews directory holding each run's generator output
+const reviewLogsDir = "logs"
+
+// createReviewLog creates the output log for one review run and returns its path relative to the
+// reviews directory. A nil file means the log couldn't be created and the run goes unlogged.
+func createReviewLog(absReviewDir, reviewFile string) (string, *os.File) {
+	dir := filepath.Join(absReviewDir, reviewLogsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("[REVIEW] WARNING: Failed to create review logs directory: %v", err)
+		return "", nil
+	}
+	f, err := os.CreateTemp(dir, strings.TrimSuffix(reviewFile, ".html")+"_*.log")
+	if err != nil {
+		log.Printf("[REVIEW] WARNING: Failed to create review log: %v", err)
+		return "", nil
+	}
+	return filepath.Join(reviewLogsDir, filepath.Base(f.Name())), f
+}
+
+// maxOutputExcerpt bounds how much generator output is stored with each review
+const maxOutputExcerpt = 4096
+
+// outputExcerpt returns the end of a generator's output, where errors usually are, preceded by the
+// error that failed the review, if any
+func outputExcerpt(output []byte, err error) string {
+	if len(output) > maxOutputExcerpt {
+		output = output[len(output)-maxOutputExcerpt:]
+	}
+	excerpt := strings.ToValidUTF8(string(output), "")
+	if err != nil {
+		excerpt = strings.TrimSpace(err.Error() + "\n" + excerpt)
+	}
+	return excerpt
+}
+
 // checkForOutdatedReviews detects PRs with new commits and resets them to pending
-func (p *Poller) checkForOutdatedReviews(ctx context.Context) (int, error) {
+func (p *Poller) checkForOutdatedReviews(acct github.Account, states map[string]*github.PRState) (int, error) {
 	// Get all PRs from database
 	allPRs, err := p.db.GetAllPRs()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get PRs from database: %w", err)
 	}
+	allPRs = filterAccountPRs(allPRs, acct)
 
 	outdated := 0
 	checkedCount := 0
@@ -497,61 +697,20 @@ func (p *Poller) checkForOutdatedReviews(ctx context.Context) (int, error) {
 
 		checkedCount++
 
-		// Fetch current HEAD SHA from GitHub
-		currentSHA, err := p.ghClient.GetPRHeadSHA(ctx, pr.RepoOwner, pr.RepoName, pr.PRNumber)
-		if err != nil {
-			log.Printf("[OUTDATED] Warning: Could not fetch current HEAD SHA for %s/%s#%d: %v",
-				pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+		// Current HEAD SHA from GitHub
+		state, ok := states[fmt.Sprintf("%s/%s/%d", pr.RepoOwner, pr.RepoName, pr.PRNumber)]
+		if !ok || state.HeadSHA == "" {
+			log.Printf("[OUTDATED] Warning: Could not fetch current HEAD SHA for %s/%s#%d",
+				pr.RepoOwner, pr.RepoName, pr.PRNumber)
 			continue
 		}
+		currentSHA := state.HeadSHA
 
 		log.Printf("[OUTDATED] Checking %s/%s#%d: stored=%s current=%s status=%s",
 			pr.RepoOwner, pr.RepoName, pr.PRNumber, pr.LastCommitSHA[:7], currentSHA[:7], pr.Status)
 
 		// Compare commit SHAs
-		if currentSHA != pr.LastCommitSHA {
-			wasGenerating := pr.Status == "generating"
-			statusMsg := "completed"
-			if wasGenerating {
-				statusMsg = "generating (cancelling)"
-			}
-			log.Printf("[OUTDATED] PR %s/%s#%d (%s) has new commits (old: %s, new: %s), resetting to pending",
-				pr.RepoOwner, pr.RepoName, pr.PRNumber, statusMsg, pr.LastCommitSHA[:7], currentSHA[:7])
-
-			// Delete old HTML file if it exists
-			if pr.ReviewHTMLPath != "" {
-				oldHTMLPath := filepath.Join(p.reviewDir, pr.ReviewHTMLPath)
-				if err := os.Remove(oldHTMLPath); err != nil && !os.IsNotExist(err) {
-					log.Printf("[OUTDATED] Warning: Failed to delete old HTML file %s: %v", oldHTMLPath, err)
-				} else if err == nil {
-					log.Printf("[OUTDATED] Deleted old HTML file: %s", pr.ReviewHTMLPath)
-				}
-			}
-
-			// If the PR was actively generating, kill the process
-			if wasGenerating {
-				if p.killReview(pr.RepoOwner, pr.RepoName, pr.PRNumber) {
-					log.Printf("[OUTDATED] Killed active review process for %s/%s#%d",
-						pr.RepoOwner, pr.RepoName, pr.PRNumber)
-				}
-			}
-
-			// Reset PR to pending with new commit SHA and clear old review data
-			if err := p.db.ResetPRToOutdated(pr.RepoOwner, pr.RepoName, pr.PRNumber, currentSHA); err != nil {
-				log.Printf("[OUTDATED] ERROR: Failed to reset PR %s/%s#%d: %v",
-					pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
-				continue
-			}
-
-			// Voice notification for outdated review
-			var message string
-			if wasGenerating {
-				message = fmt.Sprintf("PR number %d has a new commit while generating. Cancelling old review and starting fresh.", pr.PRNumber)
-			} else {
-				message = fmt.Sprintf("PR number %d has a new commit. Removing stale review and generating a new one.", pr.PRNumber)
-			}
-			p.speak(message)
-
+		if currentSHA != pr.LastCommitSHA && p.resetOutdatedPR(pr, currentSHA) {
 			outdated++
 		}
 	}
@@ -563,6 +722,43 @@ func (p *Poller) checkForOutdatedReviews(ctx context.Context) (int, error) {
 	return outdated, nil
 }
 
+// resetOutdatedPR resets the PR to pending after new commits, cancelling any in-progress review.
+// The previous review's HTML stays in the PR's review history.
+func (p *Poller) resetOutdatedPR(pr db.PR, currentSHA string) bool {
+	wasGenerating := pr.Status == "generating"
+	statusMsg := pr.Status
+	if wasGenerating {
+		statusMsg = "generating (cancelling)"
+	}
+	log.Printf("[OUTDATED] PR %s/%s#%d (%s) has new commits (old: %s, new: %s), resetting to pending",
+		pr.RepoOwner, pr.RepoName, pr.PRNumber, statusMsg, pr.LastCommitSHA[:7], currentSHA[:7])
+
+	// If the PR was actively generating, kill the process
+	if wasGenerating {
+		if p.killReview(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber) {
+			log.Printf("[OUTDATED] Killed active review process for %s/%s#%d",
+				pr.RepoOwner, pr.RepoName, pr.PRNumber)
+		}
+	}
+
+	// Reset PR to pending with new commit SHA and clear old review data
+	if err := p.db.ResetPRToOutdated(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, currentSHA); err != nil {
+		log.Printf("[OUTDATED] ERROR: Failed to reset PR %s/%s#%d: %v",
+			pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+		return false
+	}
+
+	// Voice notification for outdated review
+	var message string
+	if wasGenerating {
+		message = fmt.Sprintf("PR number %d has a new commit while generating. Cancelling old review and starting fresh.", pr.PRNumber)
+	} else {
+		message = fmt.Sprintf("PR number %d has a new commit. Generating a new review.", pr.PRNumber)
+	}
+	p.speak(message)
+	return true
+}
+
 func (p *Poller) poll(ctx context.Context) {
 	startTime := time.Now()
 
@@ -573,9 +769,9 @@ func (p *Poller) poll(ctx context.Context) {
 
 	log.Printf("[POLL] Starting poll at %s", startTime.Format("15:04:05"))
 
-	// Reset any PRs stuck in "generating" for more than 2 minutes
+	// Reset PRs left "generating" with no review job running, e.g. after a crash or restart
 	log.Printf("[POLL] Checking for stale PRs...")
-	resetCount, err := p.db.ResetStaleGeneratingPRs(2)
+	resetCount, err := p.resetStaleGeneratingPRs()
 	if err != nil {
 		log.Printf("[POLL] ERROR: Failed to reset stale PRs: %v", err)
 	} else if resetCount > 0 {
@@ -584,9 +780,9 @@ func (p *Poller) poll(ctx context.Context) {
 		log.Printf("[POLL] No stale PRs found")
 	}
 
-	// Reset PRs in error state that are older than 5 minutes (self-healing)
+	// Reset PRs in error state whose retry backoff has elapsed (self-healing)
 	log.Printf("[POLL] Checking for error PRs to retry...")
-	errorResetCount, err := p.db.ResetErrorPRs(5)
+	errorResetCount, err := p.db.ResetDueErrorPRs(time.Now())
 	if err != nil {
 		log.Printf("[POLL] ERROR: Failed to reset error PRs: %v", err)
 	} else if errorResetCount > 0 {
@@ -595,62 +791,97 @@ func (p *Poller) poll(ctx context.Context) {
 		log.Printf("[POLL] No error PRs to retry")
 	}
 
-	// Clean up closed PRs (self-healing)
-	log.Printf("[POLL] Checking for closed PRs to remove...")
-	removedCount, err := p.cleanupClosedPRs(ctx)
+	// Delete archived PRs past their retention
+	prunedCount, err := p.pruneArchivedPRs()
 	if err != nil {
-		log.Printf("[POLL] ERROR: Failed to cleanup closed PRs: %v", err)
-	} else if removedCount > 0 {
-		log.Printf("[POLL] CLEANUP: Removed %d closed PRs from system", removedCount)
-	} else {
-		log.Printf("[POLL] No closed PRs to remove")
+		log.Printf("[POLL] ERROR: Failed to prune archived PRs: %v", err)
+	} else if prunedCount > 0 {
+		log.Printf("[POLL] CLEANUP: Deleted %d archived PRs past the %v retention", prunedCount, p.cfg.ArchiveRetention)
+	}
+
+	// Fetch each account's PRs first so the dashboard cache is refreshed before the slower review work
+	accountPolls := make([]accountPoll, 0, len(p.accounts))
+	var allPRs []github.PullRequest
+	for _, acct := range p.accounts {
+		budget := p.planAccountPoll(ctx, acct)
+		if !budget.deferUntil.IsZero() {
+			p.deferAccount(acct, budget.deferUntil)
+			continue
+		}
+		p.clearDeferral(acct)
+
+		ap := p.fetchAccountPRs(ctx, acct, budget.skipNonCritical)
+		accountPolls = append(accountPolls, ap)
+		allPRs = append(allPRs, ap.reviewPRs...)
+		allPRs = append(allPRs, ap.myPRs...)
 	}
 
-	// Backfill missing PR metadata (self-healing)
-	log.Printf("[POLL] Checking for PRs with missing metadata...")
-	backfilledCount, err := p.backfillPRMetadata(ctx)
-	if err != nil {
-		log.Printf("[POLL] ERROR: Failed to backfill metadata: %v", err)
-	} else if backfilledCount > 0 {
-		log.Printf("[POLL] BACKFILL: Updated metadata for %d PRs", backfilledCount)
-	} else {
-		log.Printf("[POLL] No PRs need metadata backfill")
+	// Update cache for fast dashboard loading
+	if p.cacheUpdateFunc != nil {
+		p.cacheUpdateFunc(allPRs)
 	}
 
-	// Backfill missing created_at timestamps (self-healing)
-	log.Printf("[POLL] Checking for PRs with missing created_at...")
-	timestampBackfilledCount, err := p.backfillPRCreatedAt(ctx)
-	if err != nil {
-		log.Printf("[POLL] ERROR: Failed to backfill created_at: %v", err)
-	} else if timestampBackfilledCount > 0 {
-		log.Printf("[POLL] BACKFILL: Updated created_at for %d PRs", timestampBackfilledCount)
-	} else {
-		log.Printf("[POLL] No PRs need created_at backfill")
+	for _, ap := range accountPolls {
+		p.syncAccountPRs(ctx, ap)
 	}
 
-	// Check for outdated reviews (PRs with new commits)
-	log.Printf("[POLL] Checking for outdated reviews...")
-	outdatedCount, err := p.checkForOutdatedReviews(ctx)
+	duration := time.Since(startTime)
+	log.Printf("[POLL] Poll completed in %v", duration)
+}
+
+// accountPoll holds the PRs fetched for one account during a poll
+type accountPoll struct {
+	acct      github.Account
+	reviewPRs []github.PullRequest
+	myPRs     []github.PullRequest
+}
+
+// stampAccount records which account and host fetched each PR
+func stampAccount(prs []github.PullRequest, acct github.Account) {
+	for i := range prs {
+		prs[i].Host = acct.Host
+		prs[i].Account = acct.Name
+	}
+}
+
+// fetchAccountPRs runs the self-healing checks for one account and fetches its open PRs from GitHub.
+// skipNonCritical leaves out the created_at backfill when the account is short on API requests.
+func (p *Poller) fetchAccountPRs(ctx context.Context, acct github.Account, skipNonCritical bool) accountPoll {
+	log.Printf("[POLL] Polling account %s", acct.Name)
+
+	// One query per repository fetches the state of every tracked PR for cleanup, backfill and outdated checks
+	log.Printf("[POLL] Fetching state of tracked PRs...")
+	states, err := p.fetchPRStates(ctx, acct)
 	if err != nil {
-		log.Printf("[POLL] ERROR: Failed to check for outdated reviews: %v", err)
-	} else if outdatedCount > 0 {
-		log.Printf("[POLL] OUTDATED: Reset %d PRs with new commits to pending", outdatedCount)
+		log.Printf("[POLL] ERROR: Failed to fetch state of tracked PRs: %v", err)
 	} else {
-		log.Printf("[POLL] No outdated reviews found")
+		p.runSelfHealing(ctx, acct, states, skipNonCritical)
+
+		// Check for outdated reviews (PRs with new commits)
+		log.Printf("[POLL] Checking for outdated reviews...")
+		outdatedCount, err := p.checkForOutdatedReviews(acct, states)
+		if err != nil {
+			log.Printf("[POLL] ERROR: Failed to check for outdated reviews: %v", err)
+		} else if outdatedCount > 0 {
+			log.Printf("[POLL] OUTDATED: Reset %d PRs with new commits to pending", outdatedCount)
+		} else {
+			log.Printf("[POLL] No outdated reviews found")
+		}
 	}
 
 	log.Printf("[POLL] Fetching PRs requesting review from GitHub...")
-	reviewPRs, err := p.ghClient.GetPRsRequestingReview(ctx)
+	reviewPRs, err := acct.Client.GetPRsRequestingReview(ctx)
 	if err != nil {
 		log.Printf("[POLL] ERROR: Failed to fetch PRs requesting review: %v", err)
 		// Continue even if this fails - we can still process "my PRs"
 		reviewPRs = []github.PullRequest{}
 	} else {
 		log.Printf("[POLL] Found %d PRs requesting review", len(reviewPRs))
+		stampAccount(reviewPRs, acct)
 
 		// Check for new PRs (not in database yet) and announce them
 		for _, pr := range reviewPRs {
-			existingPR, err := p.db.GetPR(pr.Owner, pr.Repo, pr.Number)
+			existingPR, err := p.db.GetPR(acct.Host, pr.Owner, pr.Repo, pr.Number)
 			if err == nil && existingPR == nil {
 				// This is a new PR
 				message := fmt.Sprintf("Your review is newly requested on PR number %d", pr.Number)
@@ -661,25 +892,79 @@ func (p *Poller) poll(ctx context.Context) {
 	}
 
 	log.Printf("[POLL] Fetching my own open PRs from GitHub...")
-	myPRs, err := p.ghClient.GetMyOpenPRs(ctx)
+	myPRs, err := acct.Client.GetMyOpenPRs(ctx)
 	if err != nil {
 		log.Printf("[POLL] ERROR: Failed to fetch my open PRs: %v", err)
 		// Continue even if this fails
 		myPRs = []github.PullRequest{}
 	}
 	log.Printf("[POLL] Found %d of my own open PRs", len(myPRs))
+	stampAccount(myPRs, acct)
 
-	// Combine all PRs for cache
-	allPRs := append(reviewPRs, myPRs...)
+	return accountPoll{acct: acct, reviewPRs: reviewPRs, myPRs: myPRs}
+}
 
-	// Update cache for fast dashboard loading
-	if p.cacheUpdateFunc != nil {
-		p.cacheUpdateFunc(allPRs)
+// fetchPRStates fetches the current state of every PR the account tracks
+func (p *Poller) fetchPRStates(ctx context.Context, acct github.Account) (map[string]*github.PRState, error) {
+	allPRs, err := p.db.GetAllPRs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PRs from database: %w", err)
+	}
+	return acct.Client.BatchGetPRStates(ctx, toPullRequests(filterAccountPRs(allPRs, acct)))
+}
+
+// runSelfHealing archives closed PRs and backfills missing metadata for one account.
+// skipNonCritical leaves out the created_at backfill, the only phase that needs its own queries.
+func (p *Poller) runSelfHealing(ctx context.Context, acct github.Account, states map[string]*github.PRState, skipNonCritical bool) {
+	// Archive closed PRs (self-healing)
+	log.Printf("[POLL] Checking for closed PRs to archive...")
+	archivedCount, err := p.archiveClosedPRs(acct, states)
+	if err != nil {
+		log.Printf("[POLL] ERROR: Failed to archive closed PRs: %v", err)
+	} else if archivedCount > 0 {
+		log.Printf("[POLL] CLEANUP: Archived %d closed PRs", archivedCount)
+	} else {
+		log.Printf("[POLL] No closed PRs to archive")
+	}
+
+	// Backfill missing PR metadata (self-healing)
+	log.Printf("[POLL] Checking for PRs with missing metadata...")
+	backfilledCount, err := p.backfillPRMetadata(acct, states)
+	if err != nil {
+		log.Printf("[POLL] ERROR: Failed to backfill metadata: %v", err)
+	} else if backfilledCount > 0 {
+		log.Printf("[POLL] BACKFILL: Updated metadata for %d PRs", backfilledCount)
+	} else {
+		log.Printf("[POLL] No PRs need metadata backfill")
 	}
 
+	if skipNonCritical {
+		log.Printf("[POLL] Skipping created_at backfill to conserve rate limit")
+		return
+	}
+
+	// Backfill missing created_at timestamps (self-healing)
+	log.Printf("[POLL] Checking for PRs with missing created_at...")
+	timestampBackfilledCount, err := p.backfillPRCreatedAt(ctx, acct)
+	if err != nil {
+		log.Printf("[POLL] ERROR: Failed to backfill created_at: %v", err)
+	} else if timestampBackfilledCount > 0 {
+		log.Printf("[POLL] BACKFILL: Updated created_at for %d PRs", timestampBackfilledCount)
+	} else {
+		log.Printf("[POLL] No PRs need created_at backfill")
+	}
+}
+
+// syncAccountPRs refreshes review and CI data for one account's PRs and generates pending reviews
+func (p *Poller) syncAccountPRs(ctx context.Context, ap accountPoll) {
+	acct, reviewPRs, myPRs := ap.acct, ap.reviewPRs, ap.myPRs
+	reviewPRs, myPRs = p.reopenArchivedPRs(ctx, acct, reviewPRs, myPRs)
+	allPRs := append(append([]github.PullRequest{}, reviewPRs...), myPRs...)
+
 	// CRITICAL: Also add ALL database PRs to ensure we update review data even for PRs
 	// that are no longer in GitHub search (e.g., you've already reviewed them)
 	dbPRsForReviewUpdate, err := p.db.GetAllPRs()
+	dbPRsForReviewUpdate = filterAccountPRs(dbPRsForReviewUpdate, acct)
 	if err != nil {
 		log.Printf("[POLL] WARNING: Failed to get database PRs for review update: %v", err)
 	} else {
@@ -696,13 +981,15 @@ func (p *Poller) poll(ctx context.Context) {
 			if _, exists := prMap[key]; !exists {
 				// Add this PR from database
 				allPRs = append(allPRs, github.PullRequest{
+					Host:      dbPR.Host,
+					Account:   acct.Name,
 					Owner:     dbPR.RepoOwner,
 					Repo:      dbPR.RepoName,
 					Number:    dbPR.PRNumber,
 					CommitSHA: dbPR.LastCommitSHA,
 					Title:     dbPR.Title,
 					Author:    dbPR.Author,
-					URL:       fmt.Sprintf("https://github.com/%s/%s/pull/%d", dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber),
+					URL:       github.PRWebURL(acct.WebURL, dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber),
 					CreatedAt: dbPR.CreatedAt, // Preserve created_at from database
 					Draft:     dbPR.Draft,
 				})
@@ -723,7 +1010,7 @@ func (p *Poller) poll(ctx context.Context) {
 			}
 		}
 
-		reviewDataMap, err := p.ghClient.BatchGetPRReviewData(ctx, allPRs)
+		reviewDataMap, err := acct.Client.BatchGetPRReviewData(ctx, allPRs)
 		if err != nil {
 			log.Printf("[POLL] WARNING: Failed to batch fetch review data: %v", err)
 		} else {
@@ -738,27 +1025,12 @@ func (p *Poller) poll(ctx context.Context) {
 						continue
 					}
 
-					// Determine if this is my PR
-					isMine := existingPR.IsMine
-
-					// Update approval count, my review status, and draft status (always use fresh value from GitHub)
-					prToUpdate := &db.PR{
-						RepoOwner:      pr.Owner,
-						RepoName:       pr.Repo,
-						PRNumber:       pr.Number,
-						LastCommitSHA:  existingPR.LastCommitSHA,
-						ReviewHTMLPath: existingPR.ReviewHTMLPath,
-						Status:         existingPR.Status,
-						Title:          existingPR.Title,
-						Author:         existingPR.Author,
-						IsMine:         isMine,
-						ApprovalCount:  reviewData.ApprovalCount,
-						MyReviewStatus: reviewData.MyReviewStatus,
-						CreatedAt:      pr.CreatedAt,
-						Draft:          pr.Draft, // IMPORTANT: Always use fresh draft status from GitHub, never cached value
-						Notes:          existingPR.Notes, // IMPORTANT: Preserve user notes
+					// Only approvals, my review status and draft state are written, so a review or
+					// notes saved since the PRs were read aren't overwritten
+					err = p.db.UpdateReviewData(existingPR.Host, pr.Owner, pr.Repo, pr.Number, reviewData.ApprovalCount, reviewData.MyReviewStatus)
+					if err == nil {
+						err = p.db.UpdatePRDraft(existingPR.Host, pr.Owner, pr.Repo, pr.Number, pr.Draft) // Always use fresh draft status from GitHub
 					}
-					err = p.db.UpsertPR(prToUpdate)
 					if err != nil {
 						log.Printf("[POLL] ERROR: Failed to update review data for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
 					} else {
@@ -774,17 +1046,9 @@ func (p *Poller) poll(ctx context.Context) {
 	log.Printf("[POLL] Batch fetching CI status for %d PRs using GraphQL...", len(allPRs))
 	if len(allPRs) > 0 {
 		// Prepare PR list with commit SHAs for CI status check
-		var prsWithSHA []struct {
-			Owner, Repo string
-			Number      int
-			CommitSHA   string
-		}
+		var prsWithSHA []github.CommitRef
 		for _, pr := range allPRs {
-			prsWithSHA = append(prsWithSHA, struct {
-				Owner, Repo string
-				Number      int
-				CommitSHA   string
-			}{
+			prsWithSHA = append(prsWithSHA, github.CommitRef{
 				Owner:     pr.Owner,
 				Repo:      pr.Repo,
 				Number:    pr.Number,
@@ -792,7 +1056,7 @@ func (p *Poller) poll(ctx context.Context) {
 			})
 		}
 
-		ciStatusMap, err := p.ghClient.BatchGetCIStatus(ctx, prsWithSHA)
+		ciStatusMap, err := acct.Client.BatchGetCIStatus(ctx, prsWithSHA)
 		if err != nil {
 			log.Printf("[POLL] WARNING: Failed to batch fetch CI status: %v", err)
 		} else {
@@ -801,25 +1065,7 @@ func (p *Poller) poll(ctx context.Context) {
 			for _, pr := range allPRs {
 				key := fmt.Sprintf("%s/%s/%d", pr.Owner, pr.Repo, pr.Number)
 				if ciStatus, exists := ciStatusMap[key]; exists {
-					// Get existing PR data from database
-					existingPR, err := p.db.GetPR(pr.Owner, pr.Repo, pr.Number)
-					if err != nil || existingPR == nil {
-						log.Printf("[POLL] ERROR: Could not get PR %s from database: %v", key, err)
-						continue
-					}
-
-					// Serialize failed checks to JSON
-					failedChecksJSON := "[]"
-					if len(ciStatus.FailedChecks) > 0 {
-						if jsonBytes, err := json.Marshal(ciStatus.FailedChecks); err == nil {
-							failedChecksJSON = string(jsonBytes)
-						}
-					}
-
-					// Update CI status in database
-					existingPR.CIState = ciStatus.State
-					existingPR.CIFailedChecks = failedChecksJSON
-					err = p.db.UpsertPR(existingPR)
+					err := p.db.UpdateCIStatus(acct.Host, pr.Owner, pr.Repo, pr.Number, ciStatus.State, failedChecksJSON(ciStatus.FailedChecks))
 					if err != nil {
 						log.Printf("[POLL] ERROR: Failed to update CI status for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
 					} else {
@@ -834,29 +1080,22 @@ func (p *Poller) poll(ctx context.Context) {
 	// CRITICAL: Also check database for pending PRs that need processing
 	// This ensures we process PRs even when GitHub API fails
 	log.Printf("[POLL] Checking database for pending PRs...")
+	var pendingReviewPRs, pendingMyPRs []github.PullRequest
 	dbPRs, err := p.db.GetAllPRs()
 	if err != nil {
 		log.Printf("[POLL] ERROR: Failed to get PRs from database: %v", err)
 	} else {
 		pendingCount := 0
-		for _, dbPR := range dbPRs {
+		for _, dbPR := range filterAccountPRs(dbPRs, acct) {
 			if dbPR.Status == "pending" {
 				// Convert DB PR to GitHub PR format for processing
-				ghPR := github.PullRequest{
-					Owner:     dbPR.RepoOwner,
-					Repo:      dbPR.RepoName,
-					Number:    dbPR.PRNumber,
-					CommitSHA: dbPR.LastCommitSHA,
-					Title:     dbPR.Title,
-					Author:    dbPR.Author,
-					URL:       fmt.Sprintf("https://github.com/%s/%s/pull/%d", dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber),
-				}
+				ghPR := p.pullRequestFor(dbPR)
 
 				// Add to appropriate list based on is_mine flag
 				if dbPR.IsMine {
-					myPRs = append(myPRs, ghPR)
+					pendingMyPRs = append(pendingMyPRs, ghPR)
 				} else {
-					reviewPRs = append(reviewPRs, ghPR)
+					pendingReviewPRs = append(pendingReviewPRs, ghPR)
 				}
 				pendingCount++
 			}
@@ -866,109 +1105,125 @@ func (p *Poller) poll(ctx context.Context) {
 		}
 	}
 
-	// Group review PRs by repository for batch processing
-	reviewPRsByRepo := make(map[string][]github.PullRequest)
-	for _, pr := range reviewPRs {
-		repoKey := fmt.Sprintf("%s/%s", pr.Owner, pr.Repo)
-		reviewPRsByRepo[repoKey] = append(reviewPRsByRepo[repoKey], pr)
+	// Queue reviews; workers generate them without holding up the poll
+	log.Printf("[POLL] Queueing %d review PRs and %d my PRs", len(reviewPRs)+len(pendingReviewPRs), len(myPRs)+len(pendingMyPRs))
+	var requested map[string]bool
+	if generatesReviews(p.generator) {
+		requested = p.requestedOfMe(ctx, acct, reviewPRs)
 	}
+	p.queueReviews(reviewPRs, false, true, requested)
+	p.queueReviews(myPRs, true, true, nil)
+	p.queueReviews(pendingReviewPRs, false, false, requested)
+	p.queueReviews(pendingMyPRs, true, false, nil)
+}
 
-	// Group my PRs by repository for batch processing
-	myPRsByRepo := make(map[string][]github.PullRequest)
-	for _, pr := range myPRs {
-		repoKey := fmt.Sprintf("%s/%s", pr.Owner, pr.Repo)
-		myPRsByRepo[repoKey] = append(myPRsByRepo[repoKey], pr)
+// requestedOfMe returns the prKeys of the review-requested PRs that ask for my review directly
+// rather than through a team. Only PRs the prioritizer hasn't scored yet are looked up, so a new
+// request goes to the front of the queue without waiting for the next prioritization run.
+func (p *Poller) requestedOfMe(ctx context.Context, acct github.Account, prs []github.PullRequest) map[string]bool {
+	var unscored []github.PullRequest
+	for _, pr := range prs {
+		if p.reviews.priority != nil {
+			if _, scored := p.reviews.priority(pr.Host, pr.Owner, pr.Repo, pr.Number); scored {
+				continue
+			}
+		}
+		unscored = append(unscored, pr)
 	}
-
-	// Process review PRs in smaller batches
-	log.Printf("[POLL] Processing %d repositories for review PRs", len(reviewPRsByRepo))
-	for repoKey, repoPRs := range reviewPRsByRepo {
-		log.Printf("[POLL] Processing review PRs for repository %s with %d PRs", repoKey, len(repoPRs))
-		// Split into smaller batches of 5 PRs to avoid timeout
-		p.processInBatches(ctx, repoPRs, false, 5)
+	if len(unscored) == 0 {
+		return nil
 	}
 
-	// Process my PRs in smaller batches
-	log.Printf("[POLL] Processing %d repositories for my PRs", len(myPRsByRepo))
-	for repoKey, repoPRs := range myPRsByRepo {
-		log.Printf("[POLL] Processing my PRs for repository %s with %d PRs", repoKey, len(repoPRs))
-		// Split into smaller batches of 5 PRs to avoid timeout
-		p.processInBatches(ctx, repoPRs, true, 5)
+	details, err := acct.Client.BatchGetPRDetails(ctx, unscored)
+	if err != nil {
+		log.Printf("[POLL] WARNING: Failed to fetch review requests for new PRs: %v", err)
+		return nil
 	}
-
-	duration := time.Since(startTime)
-	log.Printf("[POLL] Poll completed in %v", duration)
+	requested := make(map[string]bool)
+	for _, pr := range unscored {
+		if detail, ok := details[fmt.Sprintf("%s/%s/%d", pr.Owner, pr.Repo, pr.Number)]; ok && detail.RequestedMe {
+			requested[prKey(pr.Host, pr.Owner, pr.Repo, pr.Number)] = true
+		}
+	}
+	return requested
 }
 
-func (p *Poller) processInBatches(ctx context.Context, prs []github.PullRequest, isMine bool, batchSize int) {
-	for i := 0; i < len(prs); i += batchSize {
-		end := i + batchSize
-		if end > len(prs) {
-			end = len(prs)
-		}
-		batch := prs[i:end]
-		log.Printf("[POLL] Processing batch %d-%d of %d PRs", i+1, end, len(prs))
-		if err := p.processPRBatch(ctx, batch, isMine); err != nil {
-			log.Printf("[POLL] ERROR: Batch %d-%d failed: %v", i+1, end, err)
-		} else {
-			log.Printf("[POLL] Successfully processed batch %d-%d", i+1, end)
+// reopenArchivedPRs takes the search results for archived PRs out of the lists and refreshes them
+// instead, so they are only unarchived once their live state says they are open again. The search
+// may have run before a webhook archived them.
+func (p *Poller) reopenArchivedPRs(ctx context.Context, acct github.Account, reviewPRs, myPRs []github.PullRequest) ([]github.PullRequest, []github.PullRequest) {
+	archivedPRs, err := p.db.GetArchivedPRs()
+	if err != nil {
+		log.Printf("[POLL] WARNING: Failed to get archived PRs: %v", err)
+		return reviewPRs, myPRs
+	}
+	archived := make(map[string]bool)
+	for _, pr := range filterAccountPRs(archivedPRs, acct) {
+		archived[prKey(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber)] = true
+	}
+	if len(archived) == 0 {
+		return reviewPRs, myPRs
+	}
+
+	var refs []prRef
+	split := func(prs []github.PullRequest) []github.PullRequest {
+		open := prs[:0:0]
+		for _, pr := range prs {
+			if archived[prKey(pr.Host, pr.Owner, pr.Repo, pr.Number)] {
+				refs = append(refs, prRef{host: pr.Host, owner: pr.Owner, repo: pr.Repo, number: pr.Number})
+				continue
+			}
+			open = append(open, pr)
 		}
+		return open
+	}
+	reviewPRs, myPRs = split(reviewPRs), split(myPRs)
+
+	if len(refs) > 0 {
+		log.Printf("[POLL] %d archived PRs are in the search results, checking whether they were reopened", len(refs))
+		p.refreshAccountPRs(ctx, acct, refs)
 	}
+	return reviewPRs, myPRs
 }
 
-func (p *Poller) processPRBatch(ctx context.Context, prs []github.PullRequest, isMine bool) error {
+// queueReviews queues review generation for the PRs that need it. With fromGitHub the PRs are fresh
+// search or refresh results and are stored as pending first, so they show on the dashboard while
+// they wait for a worker; PRs read back from the database are queued as they are. requested holds
+// the prKeys of PRs known to request my review directly (see requestedOfMe); it may be nil.
+func (p *Poller) queueReviews(prs []github.PullRequest, isMine bool, fromGitHub bool, requested map[string]bool) {
 	if len(prs) == 0 {
-		return nil
+		return
 	}
 
 	prType := "review"
 	if isMine {
 		prType = "my"
 	}
-	log.Printf("[BATCH] Processing %d %s PRs", len(prs), prType)
 
-	// If cbpr is not enabled, just update PR metadata without generating reviews
-	if !p.cfg.CbprEnabled {
-		for _, pr := range prs {
-			// Get existing PR data to avoid overwriting fields like 'Notes' and 'ApprovalCount'
-			existingPR, err := p.db.GetPR(pr.Owner, pr.Repo, pr.Number)
-			if err != nil {
-				// Log the error but continue; we can still try to upsert the basic data
-				log.Printf("[BATCH] WARNING: Could not get existing PR for %s/%s#%d: %v. Metadata may be incomplete.", pr.Owner, pr.Repo, pr.Number, err)
-			}
-
-			if existingPR == nil {
-				// This is a new PR
-				existingPR = &db.PR{Status: "pending"}
-			}
-
-			// Update with fresh data from GitHub (preserve existing fields like Notes, ApprovalCount, etc.)
-			existingPR.RepoOwner = pr.Owner
-			existingPR.RepoName = pr.Repo
-			existingPR.PRNumber = pr.Number
-			existingPR.LastCommitSHA = pr.CommitSHA
-			existingPR.Title = pr.Title
-			existingPR.Author = pr.Author
-			existingPR.IsMine = isMine
-			existingPR.CreatedAt = pr.CreatedAt
-			existingPR.Draft = pr.Draft
-
-			if err := p.db.UpsertPR(existingPR); err != nil {
-				log.Printf("[BATCH] ERROR: Failed to upsert PR metadata for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
+	// If review generation is disabled, just update PR metadata without generating reviews
+	if !generatesReviews(p.generator) {
+		if fromGitHub {
+			for _, pr := range prs {
+				p.upsertPRMetadata(pr, isMine, false)
 			}
 		}
-		return nil
+		return
 	}
 
-	// Filter PRs that need review
-	var prsToReview []github.PullRequest
+	queued := 0
 	for _, pr := range prs {
-		existingPR, err := p.db.GetPR(pr.Owner, pr.Repo, pr.Number)
+		existingPR, err := p.db.GetPR(pr.Host, pr.Owner, pr.Repo, pr.Number)
 		if err != nil {
 			log.Printf("Error checking PR %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
 			continue
 		}
 
+		// Archived PRs come back only through ReopenPR, once GitHub reports them open
+		if existingPR != nil && existingPR.Archived() {
+			log.Printf("PR %s/%s#%d is archived, skipping", pr.Owner, pr.Repo, pr.Number)
+			continue
+		}
+
 		// Check if this is a new commit for an existing PR (outdated review)
 		// This is a safeguard against commits pushed after checkForOutdatedReviews() ran at poll start
 		// but before this batch processing began. Ensures we don't regenerate stale reviews.
@@ -980,7 +1235,7 @@ func (p *Poller) processPRBatch(ctx context.Context, prs []github.PullRequest, i
 			if wasGenerating {
 				message = fmt.Sprintf("PR number %d has a new commit while generating. Cancelling old review and starting fresh.", pr.Number)
 			} else {
-				message = fmt.Sprintf("PR number %d has a new commit. Removing stale review and generating a new one.", pr.Number)
+				message = fmt.Sprintf("PR number %d has a new commit. Generating a new review.", pr.Number)
 			}
 			p.speak(message)
 		}
@@ -1009,292 +1264,261 @@ func (p *Poller) processPRBatch(ctx context.Context, prs []github.PullRequest, i
 			continue
 		}
 
-		prsToReview = append(prsToReview, pr)
-	}
-
-	if len(prsToReview) == 0 {
-		return nil
-	}
-
-	// Mark all PRs as generating
-	log.Printf("[BATCH] Marking %d %s PRs as 'generating'", len(prsToReview), prType)
-	for _, pr := range prsToReview {
-		if err := p.db.SetPRGenerating(pr.Owner, pr.Repo, pr.Number, pr.CommitSHA, pr.Title, pr.Author, isMine, pr.CreatedAt, pr.Draft); err != nil {
-			log.Printf("[BATCH] ERROR: Failed to set generating status for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
+		// Skip failed reviews at this commit until their retry is due (see ResetDueErrorPRs)
+		if existingPR != nil && existingPR.LastCommitSHA == pr.CommitSHA && (existingPR.Status == "error" || existingPR.Status == "failed") {
+			log.Printf("PR %s/%s#%d is %q at commit %s, waiting for retry", pr.Owner, pr.Repo, pr.Number, existingPR.Status, pr.CommitSHA)
+			continue
 		}
-	}
 
-	owner := prsToReview[0].Owner
-	repo := prsToReview[0].Repo
-	prNumbers := getPRNumbers(prsToReview)
-	log.Printf("[BATCH] Starting cbpr batch for %s/%s PRs: %v", owner, repo, prNumbers)
-
-	startTime := time.Now()
-	// Generate reviews using cbpr (batch)
-	batchErr := p.generateReviewsBatch(ctx, prsToReview, isMine)
-	duration := time.Since(startTime)
-
-	if batchErr != nil {
-		log.Printf("[BATCH] ERROR: cbpr batch failed after %v: %v", duration, batchErr)
-		// Don't mark all as error immediately - check which files were actually created
-		// This provides resilience against partial failures
-	} else {
-		log.Printf("[BATCH] cbpr batch completed in %v", duration)
-	}
-
-	// Check each PR individually to see if its file exists
-	// This allows partial success recovery when cbpr is killed mid-execution
-	absReviewDir, _ := filepath.Abs(p.reviewDir)
-	completedCount := 0
-	errorCount := 0
-
-	for _, pr := range prsToReview {
-		filename := fmt.Sprintf("%s_%s_%d.html", pr.Owner, pr.Repo, pr.Number)
-		htmlPath := filepath.Join(absReviewDir, filename)
+		// Skip cancelled reviews until a new commit or a regenerate
+		if existingPR != nil && existingPR.LastCommitSHA == pr.CommitSHA && existingPR.Status == "cancelled" {
+			log.Printf("PR %s/%s#%d review was cancelled at commit %s, skipping", pr.Owner, pr.Repo, pr.Number, pr.CommitSHA)
+			continue
+		}
 
-		if _, err := os.Stat(htmlPath); err == nil {
-			// File exists - mark as completed (review data will be updated in batch later)
-			if err := p.upsertPRPreservingReviewData(ctx, pr.Owner, pr.Repo, pr.Number, pr.CommitSHA, filename, "completed", pr.Title, pr.Author, isMine, pr.CreatedAt, pr.Draft); err != nil {
-				log.Printf("[BATCH] ERROR: Failed to update DB for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
-			} else {
-				completedCount++
-			}
-		} else {
-			// File doesn't exist - mark as error
-			p.db.UpdatePRStatus(pr.Owner, pr.Repo, pr.Number, "error")
-			errorCount++
+		if fromGitHub {
+			p.upsertPRMetadata(pr, isMine, true)
 		}
-	}
 
-	log.Printf("[BATCH] Results: %d completed, %d errors (out of %d %s PRs)", completedCount, errorCount, len(prsToReview), prType)
+		// Search results don't always carry created_at; the queue orders by it
+		if pr.CreatedAt == nil && existingPR != nil {
+			pr.CreatedAt = existingPR.CreatedAt
+		}
 
-	if batchErr != nil && completedCount == 0 {
-		return fmt.Errorf("failed to generate reviews: %w", batchErr)
+		if p.reviews.push(&reviewJob{pr: pr, isMine: isMine, requestedMe: requested[prKey(pr.Host, pr.Owner, pr.Repo, pr.Number)]}) {
+			queued++
+		}
 	}
 
-	log.Printf("[BATCH] Successfully generated reviews for %s/%s PRs: %v", owner, repo, prNumbers)
-	return nil
-}
-
-func getPRNumbers(prs []github.PullRequest) []int {
-	nums := make([]int, len(prs))
-	for i, pr := range prs {
-		nums[i] = pr.Number
+	if queued > 0 {
+		log.Printf("[QUEUE] Queued %d %s PRs (%d waiting)", queued, prType, p.reviews.waitingCount())
 	}
-	return nums
 }
 
-func (p *Poller) processPR(ctx context.Context, pr github.PullRequest, isMine bool) error {
-	// Check if we've already reviewed this commit
-	existingPR, err := p.db.GetPR(pr.Owner, pr.Repo, pr.Number)
+// upsertPRMetadata stores fresh PR data from GitHub, preserving fields like Notes and ApprovalCount.
+// With pending, the PR is set back to pending; a new commit also drops the old review.
+func (p *Poller) upsertPRMetadata(pr github.PullRequest, isMine bool, pending bool) {
+	err := p.db.SyncPRWithMetadata(&db.PR{
+		Host:          pr.Host,
+		Account:       pr.Account,
+		RepoOwner:     pr.Owner,
+		RepoName:      pr.Repo,
+		PRNumber:      pr.Number,
+		LastCommitSHA: pr.CommitSHA,
+		Title:         pr.Title,
+		Author:        pr.Author,
+		IsMine:        isMine,
+		CreatedAt:     pr.CreatedAt,
+		Draft:         pr.Draft,
+	}, pending)
 	if err != nil {
-		return fmt.Errorf("failed to get PR from DB: %w", err)
+		log.Printf("[QUEUE] ERROR: Failed to upsert PR metadata for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
 	}
+}
 
-	// If we've already reviewed this commit SHA and it's completed, skip
-	if existingPR != nil && existingPR.LastCommitSHA == pr.CommitSHA && existingPR.Status == "completed" {
-		log.Printf("PR %s/%s#%d already reviewed at commit %s", pr.Owner, pr.Repo, pr.Number, pr.CommitSHA)
-		return nil
+// failedChecksJSON serializes failed check names for the ci_failed_checks column
+func failedChecksJSON(checks []string) string {
+	if len(checks) == 0 {
+		return "[]"
 	}
-
-	// Skip if currently generating
-	if existingPR != nil && existingPR.Status == "generating" {
-		log.Printf("PR %s/%s#%d is currently being reviewed, skipping", pr.Owner, pr.Repo, pr.Number)
-		return nil
+	jsonBytes, err := json.Marshal(checks)
+	if err != nil {
+		return "[]"
 	}
+	return string(jsonBytes)
+}
 
-	log.Printf("Generating review for %s/%s#%d (commit: %s)", pr.Owner, pr.Repo, pr.Number, pr.CommitSHA)
-
-	// Set status to generating
-	if err := p.db.SetPRGenerating(pr.Owner, pr.Repo, pr.Number, pr.CommitSHA, pr.Title, pr.Author, isMine, pr.CreatedAt, pr.Draft); err != nil {
-		return fmt.Errorf("failed to set PR generating status: %w", err)
-	}
+// runReviewJob generates the review for one queued PR and records the result
+func (p *Poller) runReviewJob(ctx context.Context, job *reviewJob) {
+	pr := job.pr
 
-	// Generate review using cbpr
-	htmlPath, err := p.generateReview(ctx, pr)
+	// The PR may have been closed, reviewed or updated to a new commit while it waited
+	currentPR, err := p.db.GetPR(pr.Host, pr.Owner, pr.Repo, pr.Number)
 	if err != nil {
-		p.db.UpdatePRStatus(pr.Owner, pr.Repo, pr.Number, "error")
-		return fmt.Errorf("failed to generate review: %w", err)
+		log.Printf("[REVIEW] ERROR: Failed to fetch PR %s/%s#%d from DB: %v", pr.Owner, pr.Repo, pr.Number, err)
+		return
 	}
-
-	// Update database with completed status (review data will be updated in batch later)
-	if err := p.upsertPRPreservingReviewData(ctx, pr.Owner, pr.Repo, pr.Number, pr.CommitSHA, htmlPath, "completed", pr.Title, pr.Author, isMine, pr.CreatedAt, pr.Draft); err != nil {
-		return fmt.Errorf("failed to update DB: %w", err)
+	if currentPR == nil || currentPR.Archived() || currentPR.Status != "pending" || currentPR.LastCommitSHA != pr.CommitSHA {
+		log.Printf("[REVIEW] Skipping %s/%s#%d, no longer pending at %s", pr.Owner, pr.Repo, pr.Number, pr.CommitSHA)
+		return
 	}
-
-	log.Printf("Successfully generated review for %s/%s#%d", pr.Owner, pr.Repo, pr.Number)
-	return nil
-}
-
-func (p *Poller) generateReview(ctx context.Context, pr github.PullRequest) (string, error) {
-	// Create filename for the review
-	filename := fmt.Sprintf("%s_%s_%d.html", pr.Owner, pr.Repo, pr.Number)
+	priorAttempts := currentPR.ReviewAttempts
 
 	// Use absolute path for output
 	absReviewDir, err := filepath.Abs(p.reviewDir)
-	if err != nil {
-		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	if err == nil {
+		err = os.MkdirAll(absReviewDir, 0755)
 	}
-	outputPath := filepath.Join(absReviewDir, filename)
-
-	// Ensure reviews directory exists
-	if err := os.MkdirAll(absReviewDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create reviews directory: %w", err)
-	}
-
-	// Build cbpr command
-	repoName := fmt.Sprintf("%s/%s", pr.Owner, pr.Repo)
-	cmd := exec.CommandContext(ctx,
-		p.cfg.CbprPath,
-		"review",
-		fmt.Sprintf("--repo-name=%s", repoName),
-		"-n", "3",
-		"-p", fmt.Sprintf("%d", pr.Number),
-		fmt.Sprintf("--output=%s", outputPath), // Specify output file directly
-	)
-
-	log.Printf("Running cbpr: %s %v", p.cfg.CbprPath, cmd.Args)
-	log.Printf("Output path: %s", outputPath)
-
-	// Capture output for debugging
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("cbpr command failed with error: %v", err)
-		if len(output) > 0 {
-			log.Printf("cbpr output: %s", string(output))
-		}
-		return "", fmt.Errorf("cbpr command failed: %w", err)
+		log.Printf("[REVIEW] ERROR: Failed to prepare reviews directory: %v", err)
+		p.recordReviewFailure(pr, priorAttempts, err)
+		return
 	}
 
-	// Verify file was created
-	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("cbpr succeeded but file not created at %s", outputPath)
+	if started, err := p.db.SetPRGenerating(pr.Host, pr.Owner, pr.Repo, pr.Number, pr.CommitSHA); err != nil {
+		log.Printf("[REVIEW] ERROR: Failed to set generating status for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
+		return
+	} else if !started {
+		log.Printf("[REVIEW] Skipping %s/%s#%d, no longer pending at %s", pr.Owner, pr.Repo, pr.Number, pr.CommitSHA)
+		return
 	}
 
-	return filename, nil
-}
+	filename := reviewArtifactFilename(pr.Host, pr.Owner, pr.Repo, pr.Number, pr.CommitSHA)
+	outputPath := filepath.Join(absReviewDir, filename)
 
-func (p *Poller) generateReviewsBatch(ctx context.Context, prs []github.PullRequest, isMine bool) error {
-	if len(prs) == 0 {
-		return nil
+	// The generator's full output is kept per run so failures can be diagnosed from the dashboard
+	logPath, logFile := createReviewLog(absReviewDir, filename)
+	var logWriter io.Writer
+	if logFile != nil {
+		logWriter = logFile
 	}
 
-	// Use absolute path for output directory
-	absReviewDir, err := filepath.Abs(p.reviewDir)
+	reviewID, err := p.db.StartReview(currentPR.ID, pr.CommitSHA, p.generator.Name(), logPath)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		// History is best effort; the review itself can still be generated
+		log.Printf("[REVIEW] WARNING: Failed to record review start for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
+	}
+
+	// The deadline cancels the generator, killing its process group
+	jobCtx := ctx
+	if p.cfg.ReviewTimeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, p.cfg.ReviewTimeout)
+		defer cancel()
+	}
+
+	execStart := time.Now()
+	artifact, err := p.generator.Generate(jobCtx, ReviewRequest{
+		PR:         pr,
+		OutputPath: outputPath,
+		OnStart: func(pid int) {
+			// Track this review for cancellation
+			p.trackReview(pr, pid)
+		},
+		Log: logWriter,
+	})
+	execDuration := time.Since(execStart)
+	if err != nil && errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("review timed out after %v (REVIEW_TIMEOUT): %w", p.cfg.ReviewTimeout, err)
+	}
+	if artifact == nil {
+		artifact = &ReviewArtifact{}
+	}
+	if logFile != nil {
+		if err != nil {
+			fmt.Fprintf(logFile, "\n[review failed after %v: %v]\n", execDuration.Round(time.Second), err)
+		}
+		logFile.Close()
 	}
 
-	// Ensure reviews directory exists
-	if err := os.MkdirAll(absReviewDir, 0755); err != nil {
-		return fmt.Errorf("failed to create reviews directory: %w", err)
+	finishReview := func(status, artifactPath string, genErr error) {
+		if reviewID == 0 {
+			return
+		}
+		if err := p.db.FinishReview(reviewID, status, artifactPath, outputExcerpt(artifact.Output, genErr), artifact.ExitCode); err != nil {
+			log.Printf("[REVIEW] WARNING: Failed to record review result for %s/%s#%d: %v", pr.Owner, pr.Repo, pr.Number, err)
+		}
 	}
 
-	// Process each PR individually since cbpr doesn't write to cwd in batch mode
-	// cbpr writes to temp dir when using --html --no-open, so we must use --output
-	for i, pr := range prs {
-		log.Printf("[CBPR] Processing PR %d/%d: %s/%s#%d", i+1, len(prs), pr.Owner, pr.Repo, pr.Number)
-
-		filename := fmt.Sprintf("%s_%s_%d.html", pr.Owner, pr.Repo, pr.Number)
-		outputPath := filepath.Join(absReviewDir, filename)
-
-		// Build cbpr command with --output flag
-		repoName := fmt.Sprintf("%s/%s", pr.Owner, pr.Repo)
-		cmd := exec.CommandContext(ctx,
-			p.cfg.CbprPath,
-			"review",
-			fmt.Sprintf("--repo-name=%s", repoName),
-			"-n", "3",
-			"-p", fmt.Sprintf("%d", pr.Number),
-			fmt.Sprintf("--output=%s", outputPath),
-		)
-
-		log.Printf("[CBPR] Executing: cbpr review --repo-name=%s -n 3 -p %d --output=%s", repoName, pr.Number, outputPath)
-
-		execStart := time.Now()
+	// Untrack after all DB operations complete (prevents race with checkForOutdatedReviews)
+	defer p.untrackReview(pr.Host, pr.Owner, pr.Repo, pr.Number)
 
-		// Track cbpr process
-		if err := cmd.Start(); err != nil {
-			log.Printf("[CBPR] ERROR: Failed to start command for PR %d: %v", pr.Number, err)
-			continue // Skip to next PR
+	if err != nil {
+		log.Printf("[REVIEW] ERROR: %s failed for PR %d after %v: %v", p.generator.Name(), pr.Number, execDuration, err)
+
+		// Before marking as error, check if the review was cancelled: outdated by a new commit, cancelled
+		// or regenerated from the dashboard. Whoever did that has already set the status, so keep it.
+		currentPR, dbErr := p.db.GetPR(pr.Host, pr.Owner, pr.Repo, pr.Number)
+		if dbErr == nil && (currentPR == nil || currentPR.Status != "generating" || currentPR.LastCommitSHA != pr.CommitSHA) {
+			log.Printf("[REVIEW] Review for PR %d was cancelled (outdated, cancelled or regenerated), leaving its status alone", pr.Number)
+			finishReview("cancelled", "", err)
+		} else {
+			// Mark as error only for genuine failures
+			p.recordReviewFailure(pr, priorAttempts, err)
+			finishReview("error", "", err)
 		}
+		return
+	}
 
-		pid := cmd.Process.Pid
-
-		p.cbprMutex.Lock()
-		p.cbprPID = pid
-		p.cbprStartTime = execStart
-		p.cbprMutex.Unlock()
-
-		// Track this review for cancellation
-		p.trackReview(pr.Owner, pr.Repo, pr.Number, pid)
-
-		log.Printf("[CBPR] Process started with PID %d", pid)
-
-		// Wait for command to complete
-		err := cmd.Wait()
-		execDuration := time.Since(execStart)
+	log.Printf("[REVIEW] Review completed successfully for PR %d in %v", pr.Number, execDuration)
 
-		// Clear tracked process
-		p.cbprMutex.Lock()
-		p.cbprPID = 0
-		p.cbprMutex.Unlock()
+	// Verify file was created and update status immediately
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		log.Printf("[REVIEW] ERROR: File not created for PR %d: %s", pr.Number, outputPath)
+		// Mark as error immediately
+		err := fmt.Errorf("review file not created at %s", outputPath)
+		p.recordReviewFailure(pr, priorAttempts, err)
+		finishReview("error", "", err)
+	} else {
+		log.Printf("[REVIEW] Verified file exists: %s", filename)
 
+		// Before marking as completed, verify the commit SHA hasn't changed
+		// Protects against race condition where a new commit is pushed AFTER the generator starts generating
+		// but BEFORE it finishes. The review stays in the PR's history as a review of the commit it ran
+		// on, and the outdated review detection on the next poll cycle regenerates with the latest commit.
+		currentPR, err := p.db.GetPR(pr.Host, pr.Owner, pr.Repo, pr.Number)
 		if err != nil {
-			log.Printf("[CBPR] ERROR: Command failed for PR %d after %v: %v", pr.Number, execDuration, err)
-
-			// Before marking as error, check if the PR was cancelled due to being outdated.
-			// If so, another poll cycle has already handled it, and we should not overwrite the status.
-			currentPR, dbErr := p.db.GetPR(pr.Owner, pr.Repo, pr.Number)
-			if dbErr == nil && currentPR != nil && currentPR.Status == "pending" && currentPR.LastCommitSHA != pr.CommitSHA {
-				log.Printf("[CBPR] Review for PR %d was cancelled because it became outdated. The PR is already re-queued.", pr.Number)
-			} else {
-				// Mark as error only for genuine failures
-				p.db.UpdatePRStatus(pr.Owner, pr.Repo, pr.Number, "error")
-				log.Printf("[CBPR] Marked PR %d as 'error' in database", pr.Number)
+			log.Printf("[REVIEW] ERROR: Failed to fetch PR from DB: %v", err)
+			finishReview("completed", filename, nil)
+		} else if currentPR == nil {
+			// Deleted while generating - don't bring it back (its history was deleted with it)
+			log.Printf("[REVIEW] PR %d was removed during generation, discarding result", pr.Number)
+			os.Remove(outputPath)
+			if logPath != "" {
+				os.Remove(filepath.Join(absReviewDir, logPath))
 			}
-
-			// Untrack after DB operation completes
-			p.untrackReview(pr.Owner, pr.Repo, pr.Number)
-			continue // Skip to next PR
-		}
-
-		log.Printf("[CBPR] Command completed successfully for PR %d in %v", pr.Number, execDuration)
-
-		// Verify file was created and update status immediately
-		if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-			log.Printf("[CBPR] ERROR: File not created for PR %d: %s", pr.Number, outputPath)
-			// Mark as error immediately
-			p.db.UpdatePRStatus(pr.Owner, pr.Repo, pr.Number, "error")
-			log.Printf("[CBPR] Marked PR %d as 'error' in database", pr.Number)
+		} else if currentPR.LastCommitSHA != pr.CommitSHA {
+			// Commit has changed since we started - keep the review in history only
+			log.Printf("[REVIEW] STALE REVIEW: PR %d commit changed during generation (reviewed: %s, current: %s), keeping it in history only",
+				pr.Number, pr.CommitSHA[:7], currentPR.LastCommitSHA[:7])
+			finishReview("completed", filename, nil)
 		} else {
-			log.Printf("[CBPR] Verified file exists: %s", filename)
-
-			// Before marking as completed, verify the commit SHA hasn't changed
-			// Protects against race condition where a new commit is pushed AFTER cbpr starts generating
-			// but BEFORE it finishes. In this case, we discard the stale review and let the outdated
-			// review detection on the next poll cycle regenerate with the latest commit.
-			currentPR, err := p.db.GetPR(pr.Owner, pr.Repo, pr.Number)
-			if err != nil {
-				log.Printf("[CBPR] ERROR: Failed to fetch PR from DB: %v", err)
-			} else if currentPR != nil && currentPR.LastCommitSHA != pr.CommitSHA {
-				// Commit has changed since we started - discard this stale review
-				log.Printf("[CBPR] STALE REVIEW: PR %d commit changed during generation (reviewed: %s, current: %s), discarding result and deleting file",
-					pr.Number, pr.CommitSHA[:7], currentPR.LastCommitSHA[:7])
-				os.Remove(outputPath) // Clean up the stale review file
+			// Commit matches - mark as completed unless a commit arrives in the meantime
+			finishReview("completed", filename, nil)
+			if completed, err := p.db.CompletePRReview(pr.Host, pr.Owner, pr.Repo, pr.Number, pr.CommitSHA, filename); err != nil {
+				log.Printf("[REVIEW] ERROR: Failed to update DB for PR %d: %v", pr.Number, err)
+			} else if completed {
+				log.Printf("[REVIEW] Marked PR %d as 'completed' in database", pr.Number)
 			} else {
-				// Commit matches - safe to mark as completed (review data updated in batch later)
-				if err := p.upsertPRPreservingReviewData(ctx, pr.Owner, pr.Repo, pr.Number, pr.CommitSHA, filename, "completed", pr.Title, pr.Author, isMine, pr.CreatedAt, pr.Draft); err != nil {
-					log.Printf("[CBPR] ERROR: Failed to update DB for PR %d: %v", pr.Number, err)
-				} else {
-					log.Printf("[CBPR] Marked PR %d as 'completed' in database", pr.Number)
-				}
+				log.Printf("[REVIEW] PR %d moved on during generation, keeping the review in history only", pr.Number)
 			}
 		}
+	}
+}
 
-		// Untrack after all DB operations complete (prevents race with checkForOutdatedReviews)
-		p.untrackReview(pr.Owner, pr.Repo, pr.Number)
+// recordReviewFailure marks a failed review attempt. The PR is retried after a backoff that doubles
+// with each attempt, until ReviewMaxAttempts is reached and it is marked "failed" for good.
+func (p *Poller) recordReviewFailure(pr github.PullRequest, priorAttempts int, genErr error) {
+	maxAttempts := p.cfg.ReviewMaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = config.DefaultReviewMaxAttempts
+	}
+	attempts := priorAttempts + 1
+	status := "error"
+	var nextRetryAt *time.Time
+	if attempts >= maxAttempts {
+		status = "failed"
+	} else {
+		retryAt := time.Now().Add(retryBackoff(p.cfg.ReviewRetryBackoff, attempts))
+		nextRetryAt = &retryAt
 	}
 
-	return nil
+	if err := p.db.RecordReviewFailure(pr.Host, pr.Owner, pr.Repo, pr.Number, pr.CommitSHA, status, genErr.Error(), attempts, nextRetryAt); err != nil {
+		log.Printf("[REVIEW] ERROR: Failed to record failure for PR %d: %v", pr.Number, err)
+		return
+	}
+	if nextRetryAt != nil {
+		log.Printf("[REVIEW] Marked PR %d as 'error' (attempt %d of %d), retrying at %s", pr.Number, attempts, maxAttempts, nextRetryAt.Format("15:04:05"))
+	} else {
+		log.Printf("[REVIEW] Marked PR %d as 'failed' after %d attempts, not retrying until a new commit or a manual retry", pr.Number, attempts)
+	}
+}
+
+// retryBackoff is how long to wait before retrying a review that has failed attempts times
+func retryBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < 24*time.Hour; i++ {
+		backoff *= 2
+	}
+	return backoff
 }
@@ -0,0 +1,111 @@
+package poller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pr-review-server/config"
+	"pr-review-server/db"
+	"pr-review-server/github"
+	"pr-review-server/github/githubtest"
+)
+
+// fakeCbprScript writes an HTML file to the --output path, standing in for the real cbpr binary
+const fakeCbprScript = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+		--output=*) out="${arg#--output=}" ;;
+	esac
+done
+echo "<html>review</html>" > "$out"
+`
+
+// newIntegrationPoller wires a Poller to a real github.Client talking to a githubtest server,
+// with cbpr replaced by a shell script so full poll cycles run offline.
+func newIntegrationPoller(t *testing.T) (*Poller, *db.DB, *github.Fake) {
+	t.Helper()
+
+	dir := t.TempDir()
+	cbprPath := filepath.Join(dir, "cbpr")
+	if err := os.WriteFile(cbprPath, []byte(fakeCbprScript), 0755); err != nil {
+		t.Fatalf("Failed to write fake cbpr: %v", err)
+	}
+
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	fake := github.NewFake("me")
+	srv := githubtest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client, err := github.NewClientWithEndpoints("test-token", "me", srv.APIURL(), srv.GraphQLURL())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	cfg := &config.Config{
+		GitHubUsername:  "me",
+		PollingInterval: time.Minute,
+		ReviewsDir:      filepath.Join(dir, "reviews"),
+		CbprPath:        cbprPath,
+		CbprEnabled:     true,
+	}
+	accounts := []github.Account{{Name: "me@github.com", Host: "github.com", Username: "me", WebURL: "https://github.com", Client: client}}
+	p := New(cfg, database, accounts)
+	startTestWorkers(t, p)
+	return p, database, fake
+}
+
+// TestPollIntegration_Lifecycle walks a PR through discovery, a pushed commit and closing
+func TestPollIntegration_Lifecycle(t *testing.T) {
+	p, database, fake := newIntegrationPoller(t)
+	ctx := context.Background()
+
+	// New PR appears and gets reviewed
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 7, CommitSHA: "1111111aaaa", Title: "Add caching", Author: "alice"}, "me")
+	p.poll(ctx)
+	p.reviews.waitIdle()
+
+	pr, err := database.GetPR("github.com", "acme", "api", 7)
+	if err != nil || pr == nil {
+		t.Fatalf("Expected PR to be stored, got %v (err: %v)", pr, err)
+	}
+	if pr.Status != "completed" || pr.LastCommitSHA != "1111111aaaa" {
+		t.Fatalf("Expected completed review at first commit, got status=%s sha=%s", pr.Status, pr.LastCommitSHA)
+	}
+	if pr.Title != "Add caching" || pr.Author != "alice" {
+		t.Errorf("Unexpected metadata: title=%q author=%q", pr.Title, pr.Author)
+	}
+	if _, err := os.Stat(filepath.Join(p.reviewDir, pr.ReviewHTMLPath)); err != nil {
+		t.Errorf("Expected review HTML to exist: %v", err)
+	}
+
+	// Commit pushed: review is regenerated for the new HEAD
+	fake.PushCommit("acme", "api", 7, "2222222bbbb")
+	fake.AddReview("acme", "api", 7, "bob", "APPROVED")
+	p.poll(ctx)
+	p.reviews.waitIdle()
+
+	pr, _ = database.GetPR("github.com", "acme", "api", 7)
+	if pr.Status != "completed" || pr.LastCommitSHA != "2222222bbbb" {
+		t.Fatalf("Expected completed review at new commit, got status=%s sha=%s", pr.Status, pr.LastCommitSHA)
+	}
+	if pr.ApprovalCount != 1 {
+		t.Errorf("Expected 1 approval, got %d", pr.ApprovalCount)
+	}
+
+	// PR merged: archived with its review
+	fake.ClosePR("acme", "api", 7, true)
+	p.poll(ctx)
+	p.reviews.waitIdle()
+
+	if pr, _ := database.GetPR("github.com", "acme", "api", 7); pr == nil || pr.MergedAt == nil || pr.Status != "completed" {
+		t.Errorf("Expected merged PR to be archived with its review, got %+v", pr)
+	}
+}
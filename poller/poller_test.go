@@ -0,0 +1,348 @@
+package poller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pr-review-server/config"
+	"pr-review-server/db"
+	"pr-review-server/db/dbtest"
+	"pr-review-server/github"
+)
+
+// newTestPoller creates a Poller backed by a fresh SQLite database and an in-memory GitHub fake.
+// cbpr is disabled so poll cycles only sync metadata.
+func newTestPoller(t *testing.T) (*Poller, *db.DB, *github.Fake) {
+	t.Helper()
+
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	cfg := &config.Config{
+		GitHubUsername:  "me",
+		PollingInterval: time.Minute,
+		ReviewsDir:      filepath.Join(dir, "reviews"),
+	}
+	fake := github.NewFake("me")
+	return New(cfg, database, []github.Account{testAccount("github.com", "me", fake)}), database, fake
+}
+
+// testAccount builds a github.Account on host backed by client
+func testAccount(host, username string, client github.Provider) github.Account {
+	return github.Account{
+		Name:     username + "@" + host,
+		Host:     host,
+		Username: username,
+		WebURL:   "https://" + host,
+		Client:   client,
+	}
+}
+
+// TestPoll_DiscoversPRs tests that a poll cycle stores review-requested PRs and my PRs
+func TestPoll_DiscoversPRs(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "web", Number: 2, CommitSHA: "bbbbbbb1", Title: "My change", Author: "me"})
+	fake.AddReview("acme", "api", 1, "bob", "APPROVED")
+	fake.SetCheckState("acme", "api", "aaaaaaa1", "failure", "lint")
+
+	p.poll(ctx)
+
+	reviewPR, err := database.GetPR("github.com", "acme", "api", 1)
+	if err != nil || reviewPR == nil {
+		t.Fatalf("Expected review PR in database, got %v (err: %v)", reviewPR, err)
+	}
+	if reviewPR.IsMine {
+		t.Error("Expected review PR to not be marked as mine")
+	}
+	if reviewPR.Title != "Add feature" || reviewPR.Author != "alice" {
+		t.Errorf("Unexpected metadata: title=%q author=%q", reviewPR.Title, reviewPR.Author)
+	}
+
+	myPR, err := database.GetPR("github.com", "acme", "web", 2)
+	if err != nil || myPR == nil {
+		t.Fatalf("Expected my PR in database, got %v (err: %v)", myPR, err)
+	}
+	if !myPR.IsMine {
+		t.Error("Expected my PR to be marked as mine")
+	}
+
+	// Review data and CI status are written on the following cycle, once the PR exists in the DB
+	p.poll(ctx)
+
+	reviewPR, _ = database.GetPR("github.com", "acme", "api", 1)
+	if reviewPR.ApprovalCount != 1 {
+		t.Errorf("Expected 1 approval, got %d", reviewPR.ApprovalCount)
+	}
+	if reviewPR.CIState != "failure" || reviewPR.CIFailedChecks != `["lint"]` {
+		t.Errorf("Expected failing CI with lint, got %s %s", reviewPR.CIState, reviewPR.CIFailedChecks)
+	}
+}
+
+// TestPoll_PendingPRKeepsDraft tests that a pending PR missing from this cycle's searches keeps its
+// draft flag and creation time when it is queued from the database
+func TestPoll_PendingPRKeepsDraft(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	draftPR := github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "WIP", Author: "alice", Draft: true, CreatedAt: &created}
+	fake.AddPR(draftPR, "me")
+	p.poll(ctx)
+
+	// My review is no longer requested, so only the database still lists it as pending
+	fake.AddPR(draftPR)
+	p.poll(ctx)
+
+	pr, err := database.GetPR("github.com", "acme", "api", 1)
+	if err != nil || pr == nil {
+		t.Fatalf("Expected PR in database, got %v (err: %v)", pr, err)
+	}
+	if pr.Status != "pending" || !pr.Draft || pr.CreatedAt == nil || !pr.CreatedAt.Equal(created) {
+		t.Errorf("Expected a pending draft PR created at %v, got %+v", created, pr)
+	}
+}
+
+// TestPoll_ClosedPRArchived tests that PRs closed on GitHub are archived on the next poll, come back
+// if reopened, and are deleted once past the archive retention
+func TestPoll_ClosedPRArchived(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(ctx)
+
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil {
+		t.Fatal("Expected PR to be stored after first poll")
+	}
+
+	fake.ClosePR("acme", "api", 1, false)
+	p.poll(ctx)
+
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+	if pr == nil || !pr.Archived() || pr.MergedAt != nil || pr.Title != "Add feature" {
+		t.Fatalf("Expected closed PR to be archived unmerged, got %+v", pr)
+	}
+	if open, _ := database.GetAllPRs(); len(open) != 0 {
+		t.Errorf("Expected no open PRs, got %+v", open)
+	}
+
+	fake.ReopenPR("acme", "api", 1)
+	p.poll(ctx)
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.Archived() {
+		t.Fatalf("Expected reopened PR to be unarchived, got %+v", pr)
+	}
+
+	fake.ClosePR("acme", "api", 1, true)
+	p.poll(ctx)
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.MergedAt == nil {
+		t.Fatalf("Expected merged PR to be archived as merged, got %+v", pr)
+	}
+
+	// Kept until it has been closed longer than the retention
+	p.cfg.ArchiveRetention = time.Hour
+	p.poll(ctx)
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil {
+		t.Fatal("Expected a recently closed PR to be kept")
+	}
+	if _, err := database.ArchivePR("github.com", "acme", "api", 1, time.Now(), nil); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+	p.cfg.ArchiveRetention = time.Nanosecond
+	p.poll(ctx)
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr != nil {
+		t.Errorf("Expected archived PR past the retention to be deleted, got %+v", pr)
+	}
+}
+
+// TestPoll_StaleSearchKeepsArchivedPR tests that search results fetched before a PR was archived
+// don't bring it back: it is only reopened once its live state says it is open
+func TestPoll_StaleSearchKeepsArchivedPR(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	p.poll(ctx)
+	ap := p.fetchAccountPRs(ctx, p.accounts[0], false)
+
+	// A webhook archives the PR between the search and the sync
+	fake.ClosePR("acme", "api", 1, true)
+	if _, err := database.ArchivePR("github.com", "acme", "api", 1, time.Now(), nil); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+	p.syncAccountPRs(ctx, ap)
+
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+	if pr == nil || !pr.Archived() {
+		t.Fatalf("Expected the PR to stay archived, got %+v", pr)
+	}
+	events, _ := database.GetPREvents(pr.ID)
+	for _, e := range events {
+		if e.Type == db.EventReopened {
+			t.Errorf("Expected no reopened event, got %+v", e)
+		}
+	}
+
+	// Once GitHub reports it open again, the next sync reopens it
+	fake.ReopenPR("acme", "api", 1)
+	p.syncAccountPRs(ctx, p.fetchAccountPRs(ctx, p.accounts[0], false))
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.Archived() {
+		t.Fatalf("Expected the reopened PR to be unarchived, got %+v", pr)
+	}
+}
+
+// TestPoll_MultipleAccounts tests that PRs with the same owner/repo/number on different hosts are tracked separately
+func TestPoll_MultipleAccounts(t *testing.T) {
+	p, database, dotcom := newTestPoller(t)
+	ctx := context.Background()
+
+	ghes := github.NewFake("me-corp")
+	p.accounts = append(p.accounts, testAccount("ghe.example.com", "me-corp", ghes))
+
+	dotcom.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Public change", Author: "alice"}, "me")
+	ghes.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "bbbbbbb2", Title: "Internal change", Author: "bob"}, "me-corp")
+	p.poll(ctx)
+
+	dotcomPR, _ := database.GetPR("github.com", "acme", "api", 1)
+	if dotcomPR == nil || dotcomPR.Title != "Public change" || dotcomPR.Account != "me@github.com" {
+		t.Fatalf("Expected github.com PR from me@github.com, got %+v", dotcomPR)
+	}
+	ghesPR, _ := database.GetPR("ghe.example.com", "acme", "api", 1)
+	if ghesPR == nil || ghesPR.Title != "Internal change" || ghesPR.Account != "me-corp@ghe.example.com" {
+		t.Fatalf("Expected GHES PR from me-corp@ghe.example.com, got %+v", ghesPR)
+	}
+
+	// Closing the PR on one host leaves the other untouched
+	ghes.ClosePR("acme", "api", 1, false)
+	p.poll(ctx)
+
+	if pr, _ := database.GetPR("ghe.example.com", "acme", "api", 1); pr == nil || !pr.Archived() {
+		t.Errorf("Expected closed GHES PR to be archived")
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.Archived() {
+		t.Errorf("Expected github.com PR to remain open")
+	}
+}
+
+// TestPoll_LowRateLimitSkipsBackfill tests that the created_at backfill waits when the account can't afford it this cycle
+func TestPoll_LowRateLimitSkipsBackfill(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	// Tracked but no longer in either search, so only the backfill can fill in created_at
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"})
+	dbtest.AddPR(t, database, db.PR{Host: "github.com", Account: "me@github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1,
+		LastCommitSHA: "aaaaaaa1", Status: "pending", Title: "Add feature", Author: "alice"})
+
+	// Barely above the reserve with an hour of polls left: nothing to spare for backfill
+	fake.SetRateLimit(github.RateLimitInfo{Limit: 5000, Remaining: rateLimitReserve + 10, ResetTime: time.Now().Add(time.Hour)})
+	p.poll(ctx)
+
+	if got := fake.CallCount("BatchGetPRDetails"); got != 0 {
+		t.Errorf("Expected created_at backfill to be skipped, got %d BatchGetPRDetails calls", got)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.CreatedAt != nil {
+		t.Fatalf("Expected PR to still be missing created_at, got %+v", pr)
+	}
+
+	// Once the limit resets the backfill catches up
+	fake.SetRateLimit(github.RateLimitInfo{Limit: 5000, Remaining: 5000, ResetTime: time.Now().Add(time.Hour)})
+	p.poll(ctx)
+
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.CreatedAt == nil {
+		t.Errorf("Expected created_at to be backfilled after the limit reset, got %+v", pr)
+	}
+}
+
+// TestPoll_LowGraphQLLimitDefersAccount tests that the GraphQL limit counts even when REST requests remain
+func TestPoll_LowGraphQLLimitDefersAccount(t *testing.T) {
+	p, _, fake := newTestPoller(t)
+
+	fake.SetRateLimit(github.RateLimitInfo{
+		Limit: 5000, Remaining: 5000, ResetTime: time.Now().Add(time.Hour),
+		GraphQL: &github.RateLimitInfo{Limit: 5000, Remaining: 20, ResetTime: time.Now().Add(10 * time.Minute)},
+	})
+	p.poll(context.Background())
+
+	if got := fake.CallCount("GetPRsRequestingReview"); got != 0 {
+		t.Errorf("Expected no searches with the GraphQL limit exhausted, got %d", got)
+	}
+	if _, ok := p.GetDeferredAccounts()["me@github.com"]; !ok {
+		t.Error("Expected account to be deferred")
+	}
+}
+
+// TestPoll_BatchedStateChecks tests that closed and outdated checks share one state query per poll
+func TestPoll_BatchedStateChecks(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 2, CommitSHA: "bbbbbbb1", Title: "Fix bug", Author: "bob"}, "me")
+	p.poll(ctx)
+
+	if err := database.UpdatePRStatus("github.com", "acme", "api", 2, "completed"); err != nil {
+		t.Fatalf("UpdatePRStatus failed: %v", err)
+	}
+	fake.ClosePR("acme", "api", 1, true)
+	fake.PushCommit("acme", "api", 2, "bbbbbbb2")
+
+	before := fake.CallCount("BatchGetPRStates")
+	p.poll(ctx)
+
+	if got := fake.CallCount("BatchGetPRStates") - before; got != 1 {
+		t.Errorf("Expected one batched state query per poll, got %d", got)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.MergedAt == nil {
+		t.Errorf("Expected merged PR to be archived")
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 2); pr == nil || pr.LastCommitSHA != "bbbbbbb2" {
+		t.Errorf("Expected PR with a new commit to be reset to the new head, got %+v", pr)
+	}
+}
+
+// TestPoll_ExhaustedRateLimitDefersAccount tests that accounts out of requests are not polled until the reset
+func TestPoll_ExhaustedRateLimitDefersAccount(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	reset := time.Now().Add(30 * time.Minute)
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	fake.SetRateLimit(github.RateLimitInfo{Limit: 5000, Remaining: 5, ResetTime: reset})
+	p.poll(ctx)
+
+	if got := fake.CallCount("GetPRsRequestingReview"); got != 0 {
+		t.Errorf("Expected no searches for a deferred account, got %d", got)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr != nil {
+		t.Errorf("Expected PR not to be discovered while deferred")
+	}
+	if until, ok := p.GetDeferredAccounts()["me@github.com"]; !ok || !until.Equal(reset) {
+		t.Errorf("Expected account deferred until %v, got %v (deferred: %v)", reset, until, ok)
+	}
+
+	// GitHub-requested backoffs defer the account too, and clear once polling resumes
+	fake.SetRateLimit(github.RateLimitInfo{Limit: 5000, Remaining: 5000, ResetTime: reset})
+	fake.SetBackoff(time.Now().Add(time.Minute))
+	p.poll(ctx)
+	if got := fake.CallCount("GetPRsRequestingReview"); got != 0 {
+		t.Errorf("Expected no searches during a backoff, got %d", got)
+	}
+
+	fake.SetBackoff(time.Time{})
+	p.poll(ctx)
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil {
+		t.Error("Expected PR to be discovered once the account is pollable")
+	}
+	if _, ok := p.GetDeferredAccounts()["me@github.com"]; ok {
+		t.Error("Expected deferral to be cleared after a successful poll")
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package poller
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startInProcessGroup is a no-op where process groups aren't available; only the generator
+// process itself is killed
+func startInProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the process pid
+func killProcessGroup(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
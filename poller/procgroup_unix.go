@@ -0,0 +1,22 @@
+//go:build unix
+
+package poller
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInProcessGroup makes cmd the leader of a new process group, so that killing it also kills
+// the processes it spawns (cbpr runs gh, git and model clients as children)
+func startInProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd.Process.Pid)
+	}
+}
+
+// killProcessGroup kills the process group led by pid
+func killProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package poller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// processAlive reports whether pid is running; zombies waiting to be reaped count as dead
+func processAlive(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	// The state follows the parenthesised command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+// TestCommandGenerator_TimeoutKillsProcessGroup tests that cancelling a review kills the processes
+// the generator started, not just the generator itself
+func TestCommandGenerator_TimeoutKillsProcessGroup(t *testing.T) {
+	req := testReviewRequest(t)
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+
+	g := &CommandGenerator{Argv: []string{"sh", "-c", `sleep 30 & echo $! > "$1"; wait`, "sh", pidFile}}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := g.Generate(ctx, req); err == nil {
+		t.Fatal("Expected an error from a cancelled review")
+	}
+	if elapsed := time.Since(start); elapsed > reviewWaitDelay {
+		t.Errorf("Expected Generate to return soon after the deadline, took %v", elapsed)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("Expected child PID to be written: %v", err)
+	}
+	childPID, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(childPID) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if processAlive(childPID) {
+		t.Errorf("Expected child process %d to be killed with the generator", childPID)
+	}
+}
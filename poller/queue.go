@@ -0,0 +1,265 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"pr-review-server/github"
+)
+
+// reviewJob is a PR waiting for, or undergoing, review generation
+type reviewJob struct {
+	pr          github.PullRequest
+	isMine      bool
+	requestedMe bool // Known when queued to request my review directly, before the prioritizer scores it
+	queuedAt    time.Time
+	rerun       bool // Run even if the same commit is being reviewed, e.g. one being cancelled
+}
+
+func (j *reviewJob) key() string {
+	return prKey(j.pr.Host, j.pr.Owner, j.pr.Repo, j.pr.Number)
+}
+
+func (j *reviewJob) repoKey() string {
+	return fmt.Sprintf("%s/%s/%s", j.pr.Host, j.pr.Owner, j.pr.Repo)
+}
+
+// ReviewPriority is how urgently a PR's review is needed, as scored by the prioritizer
+type ReviewPriority struct {
+	Score       int
+	RequestedMe bool // Review explicitly requested from me rather than a team
+}
+
+// PriorityFunc looks up a PR's priority; ok is false for PRs that haven't been scored
+type PriorityFunc func(host, owner, repo string, number int) (priority ReviewPriority, ok bool)
+
+// QueuedReview is a PR waiting for a review worker
+type QueuedReview struct {
+	Host     string
+	Owner    string
+	Repo     string
+	Number   int
+	QueuedAt time.Time
+}
+
+// reviewQueue hands review jobs to workers in priority order (see jobBefore). At most repoLimit
+// jobs run per repository, and two jobs for the same PR never run at once.
+type reviewQueue struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	waiting   []*reviewJob
+	running   map[string]*reviewJob // prKey -> job
+	repoCount map[string]int        // repoKey -> running jobs
+	repoLimit int
+	closed    bool
+	priority  PriorityFunc // Looked up at dispatch so rescored PRs move without requeueing; may be nil
+}
+
+func newReviewQueue(repoLimit int) *reviewQueue {
+	if repoLimit < 1 {
+		repoLimit = 1
+	}
+	q := &reviewQueue{
+		running:   make(map[string]*reviewJob),
+		repoCount: make(map[string]int),
+		repoLimit: repoLimit,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a job, replacing a waiting job for the same PR. It returns false if the PR is
+// already waiting, or running at the same commit and the job isn't a rerun.
+func (q *reviewQueue) push(job *reviewJob) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := job.key()
+	if running, ok := q.running[key]; ok && running.pr.CommitSHA == job.pr.CommitSHA && !job.rerun {
+		return false
+	}
+	for i, waiting := range q.waiting {
+		if waiting.key() != key {
+			continue
+		}
+		if waiting.pr.CommitSHA == job.pr.CommitSHA {
+			waiting.requestedMe = waiting.requestedMe || job.requestedMe
+			return false
+		}
+		// New commit: keep the PR's place in line but review the latest head
+		job.queuedAt = waiting.queuedAt
+		job.requestedMe = job.requestedMe || waiting.requestedMe
+		q.waiting[i] = job
+		return true
+	}
+
+	job.queuedAt = time.Now()
+	q.waiting = append(q.waiting, job)
+	q.cond.Broadcast()
+	return true
+}
+
+// next blocks until a waiting job can run, or returns false once the queue is closed
+func (q *reviewQueue) next() (*reviewJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.closed {
+			return nil, false
+		}
+		for _, job := range q.ordered() {
+			if _, busy := q.running[job.key()]; busy || q.repoCount[job.repoKey()] >= q.repoLimit {
+				continue
+			}
+			q.removeWaiting(job.key())
+			q.running[job.key()] = job
+			q.repoCount[job.repoKey()]++
+			return job, true
+		}
+		q.cond.Wait()
+	}
+}
+
+// done releases the job's slot so jobs waiting on its PR or repository can run
+func (q *reviewQueue) done(job *reviewJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.running, job.key())
+	if q.repoCount[job.repoKey()]--; q.repoCount[job.repoKey()] <= 0 {
+		delete(q.repoCount, job.repoKey())
+	}
+	q.cond.Broadcast()
+}
+
+// remove drops a waiting job, e.g. for a PR that was closed
+func (q *reviewQueue) remove(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.removeWaiting(key)
+}
+
+func (q *reviewQueue) removeWaiting(key string) {
+	for i, job := range q.waiting {
+		if job.key() == key {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// ordered returns the waiting jobs in the order they should run. Callers hold q.mu.
+func (q *reviewQueue) ordered() []*reviewJob {
+	jobs := append([]*reviewJob(nil), q.waiting...)
+	priorities := make(map[*reviewJob]ReviewPriority, len(jobs))
+	for _, job := range jobs {
+		var priority ReviewPriority
+		if q.priority != nil {
+			priority, _ = q.priority(job.pr.Host, job.pr.Owner, job.pr.Repo, job.pr.Number)
+		}
+		priority.RequestedMe = priority.RequestedMe || job.requestedMe
+		priorities[job] = priority
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobBefore(jobs[i], priorities[jobs[i]], jobs[j], priorities[jobs[j]])
+	})
+	return jobs
+}
+
+// jobBefore orders reviews: explicitly requested PRs first, then by prioritizer score, then oldest
+// PR first. PRs that haven't been scored count as 0, but still go first if the poller saw the
+// request when queueing them. Ties keep the order they were queued in.
+func jobBefore(a *reviewJob, pa ReviewPriority, b *reviewJob, pb ReviewPriority) bool {
+	if pa.RequestedMe != pb.RequestedMe {
+		return pa.RequestedMe
+	}
+	if pa.Score != pb.Score {
+		return pa.Score > pb.Score
+	}
+	if a.pr.CreatedAt != nil && b.pr.CreatedAt != nil && !a.pr.CreatedAt.Equal(*b.pr.CreatedAt) {
+		return a.pr.CreatedAt.Before(*b.pr.CreatedAt)
+	}
+	if (a.pr.CreatedAt == nil) != (b.pr.CreatedAt == nil) {
+		return a.pr.CreatedAt != nil
+	}
+	return a.queuedAt.Before(b.queuedAt)
+}
+
+// snapshot returns the waiting jobs in the order they will run
+func (q *reviewQueue) snapshot() []QueuedReview {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := q.ordered()
+	queued := make([]QueuedReview, len(jobs))
+	for i, job := range jobs {
+		queued[i] = QueuedReview{Host: job.pr.Host, Owner: job.pr.Owner, Repo: job.pr.Repo, Number: job.pr.Number, QueuedAt: job.queuedAt}
+	}
+	return queued
+}
+
+// close stops workers once their current jobs finish
+func (q *reviewQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// waitIdle blocks until no jobs are waiting or running
+func (q *reviewQueue) waitIdle() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.waiting) > 0 || len(q.running) > 0 {
+		q.cond.Wait()
+	}
+}
+
+// isRunning reports whether a worker is currently running the PR's job
+func (q *reviewQueue) isRunning(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.running[key]
+	return ok
+}
+
+// waitingCount returns the number of jobs not yet started
+func (q *reviewQueue) waitingCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting)
+}
+
+// startReviewWorkers starts the review worker pool; workers exit when ctx is cancelled
+func (p *Poller) startReviewWorkers(ctx context.Context) {
+	workers := p.cfg.ReviewConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 1; i <= workers; i++ {
+		go p.reviewWorker(ctx, i)
+	}
+	go func() {
+		<-ctx.Done()
+		p.reviews.close()
+	}()
+	log.Printf("[QUEUE] Started %d review workers (at most %d per repository)", workers, p.reviews.repoLimit)
+}
+
+// reviewWorker generates queued reviews one at a time
+func (p *Poller) reviewWorker(ctx context.Context, id int) {
+	for {
+		job, ok := p.reviews.next()
+		if !ok {
+			return
+		}
+		log.Printf("[QUEUE] Worker %d reviewing %s (queued %v ago)", id, job.key(), time.Since(job.queuedAt).Round(time.Second))
+		p.runReviewJob(ctx, job)
+		p.reviews.done(job)
+	}
+}
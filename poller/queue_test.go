@@ -0,0 +1,256 @@
+package poller
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"pr-review-server/db"
+	"pr-review-server/github"
+)
+
+// startTestWorkers runs the poller's review workers for the rest of the test
+func startTestWorkers(t *testing.T, p *Poller) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	p.startReviewWorkers(ctx)
+}
+
+func testJob(repo string, number int, sha string) *reviewJob {
+	return &reviewJob{pr: github.PullRequest{Host: "github.com", Owner: "acme", Repo: repo, Number: number, CommitSHA: sha}}
+}
+
+// tryNext returns the next runnable job, or nil if next would block
+func tryNext(q *reviewQueue) *reviewJob {
+	result := make(chan *reviewJob, 1)
+	go func() {
+		job, _ := q.next()
+		result <- job
+	}()
+	select {
+	case job := <-result:
+		return job
+	case <-time.After(50 * time.Millisecond):
+		q.close() // Unblock the goroutine
+		return nil
+	}
+}
+
+// TestReviewQueue_RepoLimit tests that jobs beyond the per-repository limit wait while other repositories run
+func TestReviewQueue_RepoLimit(t *testing.T) {
+	q := newReviewQueue(1)
+	q.push(testJob("api", 1, "a1"))
+	q.push(testJob("api", 2, "b1"))
+	q.push(testJob("web", 3, "c1"))
+
+	first := tryNext(q)
+	if first == nil || first.pr.Number != 1 {
+		t.Fatalf("Expected PR 1 first, got %+v", first)
+	}
+	second := tryNext(q)
+	if second == nil || second.pr.Number != 3 {
+		t.Fatalf("Expected PR 3 from another repository while api is busy, got %+v", second)
+	}
+
+	q.done(first)
+	if third := tryNext(q); third == nil || third.pr.Number != 2 {
+		t.Fatalf("Expected PR 2 once api is free, got %+v", third)
+	}
+}
+
+// TestReviewQueue_Dedup tests that a PR is queued once per commit and a new commit replaces the waiting job
+func TestReviewQueue_Dedup(t *testing.T) {
+	q := newReviewQueue(2)
+	if !q.push(testJob("api", 1, "a1")) {
+		t.Fatal("Expected first push to queue the job")
+	}
+	if q.push(testJob("api", 1, "a1")) {
+		t.Error("Expected duplicate push at the same commit to be ignored")
+	}
+	if !q.push(testJob("api", 1, "a2")) || q.waitingCount() != 1 {
+		t.Errorf("Expected new commit to replace the waiting job, have %d waiting", q.waitingCount())
+	}
+
+	running := tryNext(q)
+	if running == nil || running.pr.CommitSHA != "a2" {
+		t.Fatalf("Expected the latest commit to run, got %+v", running)
+	}
+	if q.push(testJob("api", 1, "a2")) {
+		t.Error("Expected push of the running commit to be ignored")
+	}
+
+	// A newer commit waits until the running review of the same PR finishes
+	q.push(testJob("api", 1, "a3"))
+	if job := tryNext(q); job != nil {
+		t.Errorf("Expected no second job for a PR that is already running, got %+v", job)
+	}
+}
+
+// blockingGenerator holds each review until released, counting how many run at once
+type blockingGenerator struct {
+	mu      sync.Mutex
+	current int
+	peak    int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (g *blockingGenerator) Name() string {
+	return "blocking"
+}
+
+func (g *blockingGenerator) Generate(ctx context.Context, req ReviewRequest) (*ReviewArtifact, error) {
+	g.mu.Lock()
+	g.current++
+	if g.current > g.peak {
+		g.peak = g.current
+	}
+	g.mu.Unlock()
+
+	g.started <- struct{}{}
+	<-g.release
+
+	g.mu.Lock()
+	g.current--
+	g.mu.Unlock()
+	if err := os.WriteFile(req.OutputPath, []byte("<html>review</html>"), 0644); err != nil {
+		return nil, err
+	}
+	return &ReviewArtifact{Path: req.OutputPath}, nil
+}
+
+// TestPoll_ConcurrentReviews tests that reviews run in parallel up to REVIEW_CONCURRENCY without blocking the poll
+func TestPoll_ConcurrentReviews(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	p.cfg.ReviewConcurrency = 2
+	p.reviews = newReviewQueue(2)
+	generator := &blockingGenerator{started: make(chan struct{}, 4), release: make(chan struct{})}
+	p.SetReviewGenerator(generator)
+	startTestWorkers(t, p)
+
+	for i := 1; i <= 4; i++ {
+		fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: i, CommitSHA: "sha" + string(rune('0'+i)), Title: "PR", Author: "alice"}, "me")
+	}
+	p.poll(context.Background()) // Returns while reviews are still running
+	<-generator.started
+	<-generator.started
+
+	if got := p.GetQueuedReviewCount(); got != 2 {
+		t.Errorf("Expected 2 reviews waiting for a worker, got %d", got)
+	}
+	close(generator.release)
+	p.reviews.waitIdle()
+
+	if generator.peak != 2 {
+		t.Errorf("Expected 2 reviews to run at once, peak was %d", generator.peak)
+	}
+	for i := 1; i <= 4; i++ {
+		if pr, _ := database.GetPR("github.com", "acme", "api", i); pr == nil || pr.Status != "completed" {
+			t.Errorf("Expected PR %d to be completed, got %+v", i, pr)
+		}
+	}
+}
+
+// TestReviewQueue_PriorityOrder tests that explicitly requested, then higher-scored, then older PRs run first
+func TestReviewQueue_PriorityOrder(t *testing.T) {
+	q := newReviewQueue(10)
+	scores := map[int]ReviewPriority{
+		1: {Score: 10},
+		2: {Score: 80},
+		3: {Score: 10, RequestedMe: true},
+	}
+	q.priority = func(host, owner, repo string, number int) (ReviewPriority, bool) {
+		priority, ok := scores[number]
+		return priority, ok
+	}
+
+	older := time.Now().Add(-72 * time.Hour)
+	newer := time.Now().Add(-time.Hour)
+	for _, job := range []*reviewJob{testJob("a", 1, "s1"), testJob("b", 2, "s2"), testJob("c", 3, "s3"), testJob("d", 4, "s4"), testJob("e", 5, "s5")} {
+		switch job.pr.Number {
+		case 4:
+			job.pr.CreatedAt = &newer
+		case 5:
+			job.pr.CreatedAt = &older
+		}
+		q.push(job)
+	}
+
+	var order []int
+	for _, queued := range q.snapshot() {
+		order = append(order, queued.Number)
+	}
+	// 3 is requested; 2 outscores 1; unscored 4 and 5 count as 0, oldest first
+	want := []int{3, 2, 1, 5, 4}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("Expected queue order %v, got %v", want, order)
+		}
+	}
+	if job := tryNext(q); job == nil || job.pr.Number != 3 {
+		t.Errorf("Expected the requested PR to run first, got %+v", job)
+	}
+}
+
+// TestPoll_NewRequestQueuedFirst tests that a PR requesting my review directly goes ahead of a
+// scored PR before the prioritizer has scored it
+func TestPoll_NewRequestQueuedFirst(t *testing.T) {
+	p, _, fake := newTestPoller(t)
+	p.SetReviewGenerator(&recordingGenerator{})
+	p.SetPriorityFunc(func(host, owner, repo string, number int) (ReviewPriority, bool) {
+		if number == 1 {
+			return ReviewPriority{Score: 80}, true
+		}
+		return ReviewPriority{}, false
+	})
+
+	older := time.Now().Add(-72 * time.Hour)
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Author: "alice", CreatedAt: &older}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "web", Number: 2, CommitSHA: "bbbbbbb1", Author: "bob"}, "me")
+	p.poll(context.Background())
+
+	var order []int
+	for _, queued := range p.reviews.snapshot() {
+		order = append(order, queued.Number)
+	}
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Expected the new direct request ahead of the scored PR, got %v", order)
+	}
+}
+
+// TestResetStaleGeneratingPRs tests that only PRs without a running review job are reset to pending
+func TestResetStaleGeneratingPRs(t *testing.T) {
+	p, database, _ := newTestPoller(t)
+	for _, number := range []int{1, 2} {
+		if err := database.SyncPR(&db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: number, LastCommitSHA: "a1"}, true); err != nil {
+			t.Fatalf("SyncPR failed: %v", err)
+		}
+		if _, err := database.SetPRGenerating("github.com", "acme", "api", number, "a1"); err != nil {
+			t.Fatalf("SetPRGenerating failed: %v", err)
+		}
+	}
+
+	// PR 1 is being reviewed by a worker; PR 2 was left generating by a previous run
+	p.reviews.push(testJob("api", 1, "a1"))
+	running := tryNext(p.reviews)
+	if running == nil {
+		t.Fatal("Expected PR 1 to start running")
+	}
+
+	count, err := p.resetStaleGeneratingPRs()
+	if err != nil {
+		t.Fatalf("resetStaleGeneratingPRs failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 PR reset, got %d", count)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr.Status != "generating" {
+		t.Errorf("Expected running PR 1 to stay generating, got %s", pr.Status)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 2); pr.Status != "pending" {
+		t.Errorf("Expected PR 2 to be reset to pending, got %s", pr.Status)
+	}
+}
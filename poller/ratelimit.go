@@ -0,0 +1,124 @@
+package poller
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"pr-review-server/github"
+)
+
+// rateLimitReserve is the number of requests per account kept back for the dashboard and manual actions
+const rateLimitReserve = 100
+
+// accountBudget decides how much of a poll an account can afford
+type accountBudget struct {
+	deferUntil      time.Time // Non-zero when the account must not be polled at all this cycle
+	skipNonCritical bool      // Skip the created_at backfill, which can wait for the limit to reset
+}
+
+// planAccountPoll checks the account's rate limits before polling it. The remaining requests (minus
+// a reserve) are spread over the poll cycles left until the limit resets; when a full poll would
+// overspend this cycle's share, the self-healing phases that can wait are skipped. REST and GraphQL
+// limits are counted separately by GitHub, so the tighter of the two decides.
+func (p *Poller) planAccountPoll(ctx context.Context, acct github.Account) accountBudget {
+	// GitHub already told us to back off (secondary limit or an exhausted primary limit)
+	if until := acct.Client.BackoffUntil(); !until.IsZero() {
+		return accountBudget{deferUntil: until}
+	}
+
+	info, err := acct.Client.GetRateLimitInfo(ctx)
+	if err != nil {
+		log.Printf("[RATE_LIMIT] Warning: Failed to check rate limit for %s: %v", acct.Name, err)
+		return accountBudget{} // Poll normally; the transport still backs off if GitHub rejects us
+	}
+
+	limits := map[string]*github.RateLimitInfo{"REST": info}
+	if info.GraphQL != nil {
+		limits["GraphQL"] = info.GraphQL
+	}
+
+	budget := math.MaxInt
+	for resource, limit := range limits {
+		if limit.Remaining <= rateLimitReserve && time.Now().Before(limit.ResetTime) {
+			log.Printf("[RATE_LIMIT] %s has %d/%d %s requests left (reserve %d), deferring until %s",
+				acct.Name, limit.Remaining, limit.Limit, resource, rateLimitReserve, limit.ResetTime.Format("15:04:05 MST"))
+			return accountBudget{deferUntil: limit.ResetTime}
+		}
+		if b := p.cycleBudget(limit); b < budget {
+			budget = b
+		}
+	}
+
+	cost := p.estimatePollCost(acct)
+	if cost > budget {
+		log.Printf("[RATE_LIMIT] %s: poll needs ~%d requests but budget is %d/cycle, skipping created_at backfill",
+			acct.Name, cost, budget)
+		return accountBudget{skipNonCritical: true}
+	}
+	return accountBudget{}
+}
+
+// cycleBudget spreads the requests left above the reserve over the polls remaining until the limit resets
+func (p *Poller) cycleBudget(limit *github.RateLimitInfo) int {
+	cycles := 1
+	if p.cfg.PollingInterval > 0 {
+		cycles = int(math.Ceil(float64(time.Until(limit.ResetTime)) / float64(p.cfg.PollingInterval)))
+		if cycles < 1 {
+			cycles = 1
+		}
+	}
+	return (limit.Remaining - rateLimitReserve) / cycles
+}
+
+// estimatePollCost approximates the requests a full poll of the account makes: the two searches plus
+// batched state, review data and CI queries for each repository with tracked PRs
+func (p *Poller) estimatePollCost(acct github.Account) int {
+	prs, err := p.db.GetAllPRs()
+	if err != nil {
+		return 0
+	}
+	repos := make(map[string]bool)
+	for _, pr := range filterAccountPRs(prs, acct) {
+		repos[pr.RepoOwner+"/"+pr.RepoName] = true
+	}
+	return 2 + 3*len(repos)
+}
+
+// deferAccount records that the account is skipped until the given time and schedules a poll for then
+func (p *Poller) deferAccount(acct github.Account, until time.Time) {
+	p.deferredMutex.Lock()
+	defer p.deferredMutex.Unlock()
+
+	if existing, ok := p.deferredUntil[acct.Name]; ok && !existing.Before(until) {
+		return // A poll is already scheduled for then
+	}
+	p.deferredUntil[acct.Name] = until
+	log.Printf("[RATE_LIMIT] Deferring %s until %s", acct.Name, until.Format("15:04:05 MST"))
+
+	// Poll again as soon as the limit resets instead of waiting for the next tick
+	time.AfterFunc(time.Until(until)+time.Second, p.Trigger)
+}
+
+// clearDeferral marks the account as pollable again
+func (p *Poller) clearDeferral(acct github.Account) {
+	p.deferredMutex.Lock()
+	defer p.deferredMutex.Unlock()
+	delete(p.deferredUntil, acct.Name)
+}
+
+// GetDeferredAccounts returns accounts skipped because of rate limits, with when they'll be polled again
+func (p *Poller) GetDeferredAccounts() map[string]time.Time {
+	p.deferredMutex.Lock()
+	defer p.deferredMutex.Unlock()
+
+	result := make(map[string]time.Time, len(p.deferredUntil))
+	now := time.Now()
+	for name, until := range p.deferredUntil {
+		if until.After(now) {
+			result[name] = until
+		}
+	}
+	return result
+}
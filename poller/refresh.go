@@ -0,0 +1,284 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"pr-review-server/db"
+	"pr-review-server/github"
+)
+
+// prRef identifies a PR queued for a targeted refresh
+type prRef struct {
+	host   string
+	owner  string
+	repo   string
+	number int
+}
+
+// RefreshPR queues one PR to be synced with GitHub without waiting for the next poll. Used by the
+// webhook receiver; refreshes run between polls so they never race a poll cycle.
+func (p *Poller) RefreshPR(host, owner, repo string, number int) {
+	p.refreshMutex.Lock()
+	p.refreshQueue[prKey(host, owner, repo, number)] = prRef{host: host, owner: owner, repo: repo, number: number}
+	p.refreshMutex.Unlock()
+
+	p.signalRefresh()
+}
+
+// signalRefresh wakes the poller loop if PRs are waiting to be refreshed
+func (p *Poller) signalRefresh() {
+	p.refreshMutex.Lock()
+	pending := len(p.refreshQueue)
+	p.refreshMutex.Unlock()
+	if pending == 0 {
+		return
+	}
+
+	// Non-blocking send, like Trigger
+	select {
+	case p.refreshChan <- struct{}{}:
+	default:
+	}
+}
+
+// startRefresh drains the refresh queue unless a poll or refresh is already running. A running
+// cycle re-signals when it finishes, so queued PRs are picked up then.
+func (p *Poller) startRefresh(ctx context.Context) {
+	p.pollMutex.Lock()
+	if p.polling {
+		p.pollMutex.Unlock()
+		return
+	}
+	p.polling = true
+	p.pollMutex.Unlock()
+
+	p.refreshMutex.Lock()
+	refs := make([]prRef, 0, len(p.refreshQueue))
+	for _, ref := range p.refreshQueue {
+		refs = append(refs, ref)
+	}
+	p.refreshQueue = make(map[string]prRef)
+	p.refreshMutex.Unlock()
+
+	go func() {
+		defer func() {
+			p.pollMutex.Lock()
+			p.polling = false
+			p.pollMutex.Unlock()
+			p.signalRefresh()
+		}()
+		p.refreshPRs(ctx, refs)
+	}()
+}
+
+// refreshPRs syncs the given PRs, grouped by the account that tracks (or would track) them
+func (p *Poller) refreshPRs(ctx context.Context, refs []prRef) {
+	byAccount := make(map[string][]prRef)
+	accounts := make(map[string]github.Account)
+	for _, ref := range refs {
+		acct, ok := p.accountForPR(ref)
+		if !ok {
+			log.Printf("[REFRESH] No account configured for host %s, ignoring %s/%s#%d", ref.host, ref.owner, ref.repo, ref.number)
+			continue
+		}
+		byAccount[acct.Name] = append(byAccount[acct.Name], ref)
+		accounts[acct.Name] = acct
+	}
+
+	for name, accountRefs := range byAccount {
+		p.refreshAccountPRs(ctx, accounts[name], accountRefs)
+	}
+}
+
+// accountForPR returns the account that discovered a tracked PR, or the first account on its host
+func (p *Poller) accountForPR(ref prRef) (github.Account, bool) {
+	if existing, err := p.db.GetPR(ref.host, ref.owner, ref.repo, ref.number); err == nil && existing != nil {
+		for _, acct := range p.accounts {
+			if acct.Name == existing.Account {
+				return acct, true
+			}
+		}
+	}
+	for _, acct := range p.accounts {
+		if acct.Host == ref.host {
+			return acct, true
+		}
+	}
+	return github.Account{}, false
+}
+
+// refreshAccountPRs runs the poll phases for just these PRs: closed and outdated checks, discovery of
+// newly requested or authored PRs, review data, CI status and review generation for pending PRs
+func (p *Poller) refreshAccountPRs(ctx context.Context, acct github.Account, refs []prRef) {
+	if until := acct.Client.BackoffUntil(); !until.IsZero() {
+		log.Printf("[REFRESH] %s is rate limited until %s, leaving %d PRs for the next poll",
+			acct.Name, until.Format("15:04:05 MST"), len(refs))
+		return
+	}
+
+	requested := make([]github.PullRequest, 0, len(refs))
+	for _, ref := range refs {
+		requested = append(requested, github.PullRequest{Host: ref.host, Owner: ref.owner, Repo: ref.repo, Number: ref.number})
+	}
+	log.Printf("[REFRESH] Refreshing %d PRs for %s", len(refs), acct.Name)
+
+	states, err := acct.Client.BatchGetPRStates(ctx, requested)
+	if err != nil {
+		log.Printf("[REFRESH] ERROR: Failed to fetch PR state: %v", err)
+		return
+	}
+
+	// Untracked PRs are only added if they're ours: authored by or requesting review from the account
+	var untracked []github.PullRequest
+	existing := make(map[string]*db.PR)
+	for _, pr := range requested {
+		key := fmt.Sprintf("%s/%s/%d", pr.Owner, pr.Repo, pr.Number)
+		dbPR, err := p.db.GetPR(acct.Host, pr.Owner, pr.Repo, pr.Number)
+		if err != nil {
+			log.Printf("[REFRESH] ERROR: Failed to get %s from database: %v", key, err)
+			continue
+		}
+		if dbPR != nil {
+			existing[key] = dbPR
+		} else if state, ok := states[key]; ok && state.IsOpen() {
+			untracked = append(untracked, pr)
+		}
+	}
+	details := make(map[string]*github.PRDetails)
+	if len(untracked) > 0 {
+		if details, err = acct.Client.BatchGetPRDetails(ctx, untracked); err != nil {
+			log.Printf("[REFRESH] ERROR: Failed to fetch details for new PRs: %v", err)
+		}
+	}
+
+	var synced []github.PullRequest
+	requestedMe := make(map[string]bool)
+	for _, req := range requested {
+		key := fmt.Sprintf("%s/%s/%d", req.Owner, req.Repo, req.Number)
+		state, ok := states[key]
+		if !ok {
+			log.Printf("[REFRESH] Warning: Could not fetch state of %s", key)
+			continue
+		}
+
+		pr := github.PullRequest{
+			Host:      acct.Host,
+			Account:   acct.Name,
+			Owner:     req.Owner,
+			Repo:      req.Repo,
+			Number:    req.Number,
+			CommitSHA: state.HeadSHA,
+			Title:     state.Title,
+			Author:    state.Author,
+			URL:       github.PRWebURL(acct.WebURL, req.Owner, req.Repo, req.Number),
+			Draft:     state.Draft,
+		}
+
+		if dbPR, tracked := existing[key]; tracked {
+			if !state.IsOpen() {
+				if !dbPR.Archived() {
+					p.archiveClosedPR(*dbPR, state)
+				}
+				continue
+			}
+			if dbPR.Archived() {
+				log.Printf("[REFRESH] %s was reopened", key)
+				if _, err := p.db.ReopenPR(dbPR.Host, dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber); err != nil {
+					log.Printf("[REFRESH] ERROR: Failed to unarchive %s: %v", key, err)
+					continue
+				}
+			}
+			// Errored and failed reviews get a fresh set of attempts at the new commit
+			if state.HeadSHA != dbPR.LastCommitSHA && dbPR.Status != "pending" {
+				p.resetOutdatedPR(*dbPR, state.HeadSHA)
+			}
+			pr.CreatedAt = dbPR.CreatedAt
+		} else {
+			detail, ok := details[key]
+			if !ok || !state.IsOpen() || (!detail.RequestedMe && detail.Author != acct.Username) {
+				continue
+			}
+			log.Printf("[REFRESH] Tracking new PR %s", key)
+			requestedMe[prKey(pr.Host, pr.Owner, pr.Repo, pr.Number)] = detail.RequestedMe
+			createdAt := detail.CreatedAt
+			pr.CreatedAt = &createdAt
+			if err := p.db.SyncPR(&db.PR{
+				Host:          pr.Host,
+				Account:       pr.Account,
+				RepoOwner:     pr.Owner,
+				RepoName:      pr.Repo,
+				PRNumber:      pr.Number,
+				LastCommitSHA: pr.CommitSHA,
+				IsMine:        detail.Author == acct.Username,
+				Title:         pr.Title,
+				Author:        pr.Author,
+				CreatedAt:     pr.CreatedAt,
+				Draft:         pr.Draft,
+			}, true); err != nil {
+				log.Printf("[REFRESH] ERROR: Failed to add %s: %v", key, err)
+				continue
+			}
+		}
+		synced = append(synced, pr)
+	}
+
+	if len(synced) == 0 {
+		return
+	}
+
+	p.refreshReviewAndCI(ctx, acct, synced)
+
+	// Queue reviews for PRs left pending
+	var reviewPRs, myPRs []github.PullRequest
+	for _, pr := range synced {
+		dbPR, err := p.db.GetPR(pr.Host, pr.Owner, pr.Repo, pr.Number)
+		if err != nil || dbPR == nil || dbPR.Status != "pending" {
+			continue
+		}
+		if dbPR.IsMine {
+			myPRs = append(myPRs, pr)
+		} else {
+			reviewPRs = append(reviewPRs, pr)
+		}
+	}
+	p.queueReviews(reviewPRs, false, true, requestedMe)
+	p.queueReviews(myPRs, true, true, nil)
+}
+
+// refreshReviewAndCI updates approval counts, my review status and CI state for the given PRs
+func (p *Poller) refreshReviewAndCI(ctx context.Context, acct github.Account, prs []github.PullRequest) {
+	reviewData, err := acct.Client.BatchGetPRReviewData(ctx, prs)
+	if err != nil {
+		log.Printf("[REFRESH] WARNING: Failed to fetch review data: %v", err)
+	}
+
+	refs := make([]github.CommitRef, 0, len(prs))
+	for _, pr := range prs {
+		refs = append(refs, github.CommitRef{Owner: pr.Owner, Repo: pr.Repo, Number: pr.Number, CommitSHA: pr.CommitSHA})
+	}
+	ciStatus, err := acct.Client.BatchGetCIStatus(ctx, refs)
+	if err != nil {
+		log.Printf("[REFRESH] WARNING: Failed to fetch CI status: %v", err)
+	}
+
+	// Each update writes only its own columns, so a review finishing meanwhile isn't overwritten
+	for _, pr := range prs {
+		key := fmt.Sprintf("%s/%s/%d", pr.Owner, pr.Repo, pr.Number)
+		if err := p.db.UpdateGitHubMetadata(pr.Host, pr.Owner, pr.Repo, pr.Number, pr.Title, pr.Author, pr.Draft); err != nil {
+			log.Printf("[REFRESH] ERROR: Failed to update %s: %v", key, err)
+			continue
+		}
+		if data, ok := reviewData[key]; ok {
+			if err := p.db.UpdateReviewData(pr.Host, pr.Owner, pr.Repo, pr.Number, data.ApprovalCount, data.MyReviewStatus); err != nil {
+				log.Printf("[REFRESH] ERROR: Failed to update review data for %s: %v", key, err)
+			}
+		}
+		if status, ok := ciStatus[key]; ok {
+			if err := p.db.UpdateCIStatus(pr.Host, pr.Owner, pr.Repo, pr.Number, status.State, failedChecksJSON(status.FailedChecks)); err != nil {
+				log.Printf("[REFRESH] ERROR: Failed to update CI status for %s: %v", key, err)
+			}
+		}
+	}
+}
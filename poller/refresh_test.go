@@ -0,0 +1,50 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"pr-review-server/github"
+)
+
+// TestRefreshPRs tests that a targeted refresh syncs only the given PRs without running the searches
+func TestRefreshPRs(t *testing.T) {
+	p, database, fake := newTestPoller(t)
+	ctx := context.Background()
+
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 1, CommitSHA: "aaaaaaa1", Title: "Add feature", Author: "alice"}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "api", Number: 2, CommitSHA: "bbbbbbb1", Title: "Fix bug", Author: "bob"}, "me")
+	p.poll(ctx)
+
+	if err := database.UpdatePRStatus("github.com", "acme", "api", 1, "completed"); err != nil {
+		t.Fatalf("UpdatePRStatus failed: %v", err)
+	}
+	fake.PushCommit("acme", "api", 1, "aaaaaaa2")
+	fake.ClosePR("acme", "api", 2, false)
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "web", Number: 3, CommitSHA: "ccccccc1", Title: "New page", Author: "carol"}, "me")
+	fake.AddPR(github.PullRequest{Owner: "acme", Repo: "web", Number: 4, CommitSHA: "ddddddd1", Title: "Not for me", Author: "dave"}, "someone-else")
+
+	searchesBefore := fake.CallCount("GetPRsRequestingReview") + fake.CallCount("GetMyOpenPRs")
+	p.refreshPRs(ctx, []prRef{
+		{host: "github.com", owner: "acme", repo: "api", number: 1},
+		{host: "github.com", owner: "acme", repo: "api", number: 2},
+		{host: "github.com", owner: "acme", repo: "web", number: 3},
+		{host: "github.com", owner: "acme", repo: "web", number: 4},
+	})
+
+	if got := fake.CallCount("GetPRsRequestingReview") + fake.CallCount("GetMyOpenPRs") - searchesBefore; got != 0 {
+		t.Errorf("Expected no searches during a refresh, got %d", got)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 1); pr == nil || pr.LastCommitSHA != "aaaaaaa2" {
+		t.Errorf("Expected PR with a new commit to be reset to the new head, got %+v", pr)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "api", 2); pr == nil || !pr.Archived() {
+		t.Errorf("Expected closed PR to be archived")
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "web", 3); pr == nil || pr.Title != "New page" {
+		t.Errorf("Expected newly requested PR to be tracked, got %+v", pr)
+	}
+	if pr, _ := database.GetPR("github.com", "acme", "web", 4); pr != nil {
+		t.Errorf("Expected PR not requesting my review to be ignored, got %+v", pr)
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 // PrioritizedPR represents a PR with its calculated priority score
 type PrioritizedPR struct {
+	Host           string    `json:"host"`
 	Owner          string    `json:"owner"`
 	Repo           string    `json:"repo"`
 	Number         int       `json:"number"`
@@ -29,6 +30,7 @@ type PrioritizedPR struct {
 	ReviewCount    int       `json:"review_count"`
 	ApprovalCount  int       `json:"approval_count"`
 	MyReviewStatus string    `json:"my_review_status"`
+	RequestedMe    bool      `json:"requested_me"`
 	GitHubURL      string    `json:"github_url"`
 	ReviewURL      string    `json:"review_url"`
 	CreatedAt      time.Time `json:"created_at"`
@@ -46,18 +48,31 @@ type Result struct {
 
 // Prioritizer calculates priority scores for PRs
 type Prioritizer struct {
-	db       *db.DB
-	ghClient *github.Client
-	username string
+	db       db.Store
+	accounts []github.Account
+	username string // Primary account's username
 }
 
 // New creates a new Prioritizer
-func New(database *db.DB, ghClient *github.Client, username string) *Prioritizer {
-	return &Prioritizer{
+func New(database db.Store, accounts []github.Account) *Prioritizer {
+	p := &Prioritizer{
 		db:       database,
-		ghClient: ghClient,
-		username: username,
+		accounts: accounts,
 	}
+	if len(accounts) > 0 {
+		p.username = accounts[0].Username
+	}
+	return p
+}
+
+// accountForHost returns the first account on host; PR details are identical whichever account fetches them
+func (p *Prioritizer) accountForHost(host string) (github.Account, bool) {
+	for _, acct := range p.accounts {
+		if acct.Host == host {
+			return acct, true
+		}
+	}
+	return github.Account{}, false
 }
 
 // Calculate runs the prioritization algorithm and returns scored PRs
@@ -90,11 +105,11 @@ func (p *Prioritizer) Calculate(ctx context.Context) (*Result, error) {
 
 	log.Printf("[PRIORITIZATION] Analyzing %d PRs...", len(filteredPRs))
 
-	// Convert to github.PullRequest format for batch fetching
-	var ghPRs []github.PullRequest
+	// Convert to github.PullRequest format for batch fetching, grouped by host
+	ghPRsByHost := make(map[string][]github.PullRequest)
 	for _, pr := range filteredPRs {
 		now := time.Now()
-		ghPRs = append(ghPRs, github.PullRequest{
+		ghPRsByHost[pr.Host] = append(ghPRsByHost[pr.Host], github.PullRequest{
 			Owner:     pr.RepoOwner,
 			Repo:      pr.RepoName,
 			Number:    pr.PRNumber,
@@ -103,17 +118,28 @@ func (p *Prioritizer) Calculate(ctx context.Context) (*Result, error) {
 	}
 
 	// Batch fetch PR details using GraphQL (additions, deletions, review counts, etc.)
-	prDetails, err := p.ghClient.BatchGetPRDetails(ctx, ghPRs)
-	if err != nil {
-		log.Printf("[PRIORITIZATION] Warning: Failed to fetch some PR details: %v", err)
-		// Continue with what we have
+	prDetails := make(map[string]*github.PRDetails)
+	for host, ghPRs := range ghPRsByHost {
+		acct, ok := p.accountForHost(host)
+		if !ok {
+			log.Printf("[PRIORITIZATION] Warning: No account configured for host %s, skipping %d PRs", host, len(ghPRs))
+			continue
+		}
+		hostDetails, err := acct.Client.BatchGetPRDetails(ctx, ghPRs)
+		if err != nil {
+			log.Printf("[PRIORITIZATION] Warning: Failed to fetch some PR details from %s: %v", host, err)
+			// Continue with what we have
+		}
+		for key, details := range hostDetails {
+			prDetails[host+":"+key] = details
+		}
 	}
 
 	// Score each PR
 	var scoredPRs []PrioritizedPR
 	for _, pr := range filteredPRs {
 		key := fmt.Sprintf("%s/%s/%d", pr.RepoOwner, pr.RepoName, pr.PRNumber)
-		details, hasDetails := prDetails[key]
+		details, hasDetails := prDetails[pr.Host+":"+key]
 
 		if !hasDetails {
 			log.Printf("[PRIORITIZATION] Skipping PR %s (no details available)", key)
@@ -231,7 +257,11 @@ func (p *Prioritizer) scorePR(pr *db.PR, details *github.PRDetails) PrioritizedP
 		priorityEmoji = "🟢"
 	}
 
-	githubURL := fmt.Sprintf("https://github.com/%s/%s/pull/%d", pr.RepoOwner, pr.RepoName, pr.PRNumber)
+	var webURL string
+	if acct, ok := p.accountForHost(pr.Host); ok {
+		webURL = acct.WebURL
+	}
+	githubURL := github.PRWebURL(webURL, pr.RepoOwner, pr.RepoName, pr.PRNumber)
 	reviewURL := fmt.Sprintf("/reviews/%s", pr.ReviewHTMLPath)
 
 	title := pr.Title
@@ -245,6 +275,7 @@ func (p *Prioritizer) scorePR(pr *db.PR, details *github.PRDetails) PrioritizedP
 	}
 
 	return PrioritizedPR{
+		Host:           pr.Host,
 		Owner:          pr.RepoOwner,
 		Repo:           pr.RepoName,
 		Number:         pr.PRNumber,
@@ -261,6 +292,7 @@ func (p *Prioritizer) scorePR(pr *db.PR, details *github.PRDetails) PrioritizedP
 		ReviewCount:    details.ReviewCount,
 		ApprovalCount:  pr.ApprovalCount,
 		MyReviewStatus: pr.MyReviewStatus,
+		RequestedMe:    details.RequestedMe,
 		GitHubURL:      githubURL,
 		ReviewURL:      reviewURL,
 		CreatedAt:      details.CreatedAt,
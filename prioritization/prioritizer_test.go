@@ -1,10 +1,13 @@
 package prioritization
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"pr-review-server/db"
+	"pr-review-server/db/dbtest"
 	"pr-review-server/github"
 )
 
@@ -282,3 +285,48 @@ func TestScorePR_EmptyDetails(t *testing.T) {
 		t.Errorf("Expected non-negative base score, got %d", scored.Score)
 	}
 }
+
+// TestCalculate_WithFake tests the full prioritization flow against the in-memory GitHub fake
+func TestCalculate_WithFake(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	createdAt := time.Now().Add(-10 * 24 * time.Hour)
+	fake := github.NewFake("testuser")
+	fake.AddPR(github.PullRequest{Owner: "owner", Repo: "repo", Number: 1, CommitSHA: "abc1234", CreatedAt: &createdAt}, "testuser")
+	fake.AddPR(github.PullRequest{Owner: "owner", Repo: "repo", Number: 2, CommitSHA: "def5678"})
+
+	for _, pr := range []db.PR{
+		{Host: "ghe.example.com", RepoOwner: "owner", RepoName: "repo", PRNumber: 1, LastCommitSHA: "abc1234", Status: "completed"},
+		{Host: "ghe.example.com", RepoOwner: "owner", RepoName: "repo", PRNumber: 2, LastCommitSHA: "def5678", Status: "completed"},
+		{Host: "ghe.example.com", RepoOwner: "owner", RepoName: "repo", PRNumber: 3, LastCommitSHA: "0000000", Status: "completed", IsMine: true},
+	} {
+		dbtest.AddPR(t, database, pr)
+	}
+
+	p := New(database, []github.Account{{
+		Name:     "testuser@ghe.example.com",
+		Host:     "ghe.example.com",
+		Username: "testuser",
+		WebURL:   "https://ghe.example.com",
+		Client:   fake,
+	}})
+	result, err := p.Calculate(context.Background())
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+
+	// My PR is excluded, the other two are scored
+	if result.TotalPRsScored != 2 {
+		t.Fatalf("Expected 2 PRs scored, got %d", result.TotalPRsScored)
+	}
+	if result.TopPRs[0].Number != 1 || result.TopPRs[0].Priority != "HIGH" {
+		t.Errorf("Expected PR #1 to rank first with HIGH priority, got #%d (%s)", result.TopPRs[0].Number, result.TopPRs[0].Priority)
+	}
+	if result.TopPRs[0].GitHubURL != "https://ghe.example.com/owner/repo/pull/1" {
+		t.Errorf("Expected PR link on configured web host, got %s", result.TopPRs[0].GitHubURL)
+	}
+}
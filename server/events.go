@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pr-review-server/db"
+)
+
+const (
+	// defaultEventsWindow is how far back /api/events looks without ?since=
+	defaultEventsWindow = 24 * time.Hour
+	// maxEventsResponse bounds how many events /api/events returns at once; ask again with the last
+	// one's created_at and id as since and after_id for more
+	maxEventsResponse = 1000
+)
+
+// EventResponse is one state transition of a PR
+type EventResponse struct {
+	ID        int    `json:"id"`
+	PRID      int    `json:"pr_id"`
+	Host      string `json:"host"`
+	Owner     string `json:"owner"`
+	Repo      string `json:"repo"`
+	Number    int    `json:"number"`
+	Type      string `json:"type"`       // "discovered", "review_requested", "commit_pushed", "generating", "completed", "error", "cancelled", "ci_changed", "approved", "closed", "reopened"
+	CommitSHA string `json:"commit_sha"` // Head commit when it happened, empty if not known
+	Detail    string `json:"detail"`
+	CreatedAt string `json:"created_at"` // RFC 3339 with fractional seconds, usable as ?since= with the ID as ?after_id=
+}
+
+// TimelineResponse is a PR with every transition it has been through, oldest first
+type TimelineResponse struct {
+	ID       int             `json:"id"`
+	Host     string          `json:"host"`
+	Owner    string          `json:"owner"`
+	Repo     string          `json:"repo"`
+	Number   int             `json:"number"`
+	Title    string          `json:"title"`
+	Author   string          `json:"author"`
+	Status   string          `json:"status"`
+	ClosedAt *string         `json:"closed_at"` // When the PR was closed or merged, null while open
+	MergedAt *string         `json:"merged_at"` // When the PR was merged, null unless merged
+	Events   []EventResponse `json:"events"`
+}
+
+func eventResponse(event db.Event) EventResponse {
+	return EventResponse{
+		ID:        event.ID,
+		PRID:      event.PRID,
+		Host:      event.Host,
+		Owner:     event.RepoOwner,
+		Repo:      event.RepoName,
+		Number:    event.PRNumber,
+		Type:      event.Type,
+		CommitSHA: event.CommitSHA,
+		Detail:    event.Detail,
+		CreatedAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func eventResponses(events []db.Event) []EventResponse {
+	response := make([]EventResponse, 0, len(events))
+	for _, event := range events {
+		response = append(response, eventResponse(event))
+	}
+	return response
+}
+
+// handleGetTimeline returns a PR's life at a glance: GET /api/prs/{id}/timeline
+func (s *Server) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid PR ID", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.db.GetPRByID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get PR: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if pr == nil {
+		http.Error(w, "PR not found", http.StatusNotFound)
+		return
+	}
+
+	events, err := s.db.GetPREvents(pr.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := TimelineResponse{
+		ID:     pr.ID,
+		Host:   pr.Host,
+		Owner:  pr.RepoOwner,
+		Repo:   pr.RepoName,
+		Number: pr.PRNumber,
+		Title:  pr.Title,
+		Author: pr.Author,
+		Status: pr.Status,
+		Events: eventResponses(events),
+	}
+	if pr.ClosedAt != nil {
+		formatted := pr.ClosedAt.UTC().Format("2006-01-02T15:04:05Z")
+		response.ClosedAt = &formatted
+	}
+	if pr.MergedAt != nil {
+		formatted := pr.MergedAt.UTC().Format("2006-01-02T15:04:05Z")
+		response.MergedAt = &formatted
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleGetEvents lists every PR's transitions from a time, oldest first, for the last day if since
+// isn't given. Events at since itself are skipped up to after_id, so a page can pick up where the
+// last one ended: GET /api/events?since=<RFC 3339 time>&after_id=<event id>
+func (s *Server) handleGetEvents(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().Add(-defaultEventsWindow)
+	if param := r.URL.Query().Get("since"); param != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, param)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid since time %q, expected RFC 3339", param), http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+	afterID := 0
+	if param := r.URL.Query().Get("after_id"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid after_id %q", param), http.StatusBadRequest)
+			return
+		}
+		afterID = parsed
+	}
+
+	events, err := s.db.GetEventsSince(since, afterID, maxEventsResponse)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(eventResponses(events))
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"pr-review-server/db"
+)
+
+// TestGetTimeline tests that a PR's timeline lists its transitions in order
+func TestGetTimeline(t *testing.T) {
+	s, database := newTestServer(t)
+	if err := database.SyncPR(&db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "abc1234", Title: "Add widgets"}, true); err != nil {
+		t.Fatalf("SyncPR failed: %v", err)
+	}
+	if _, err := database.SetPRGenerating("github.com", "acme", "api", 1, "abc1234"); err != nil {
+		t.Fatalf("SetPRGenerating failed: %v", err)
+	}
+	if _, err := database.CompletePRReview("github.com", "acme", "api", 1, "abc1234", "acme_api_1.html"); err != nil {
+		t.Fatalf("CompletePRReview failed: %v", err)
+	}
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/prs/"+id+"/timeline", nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		s.handleGetTimeline(rec, req)
+		return rec
+	}
+
+	rec := get(strconv.Itoa(pr.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var timeline TimelineResponse
+	if err := json.NewDecoder(rec.Body).Decode(&timeline); err != nil {
+		t.Fatalf("Failed to decode timeline: %v", err)
+	}
+	if timeline.Number != 1 || timeline.Title != "Add widgets" || timeline.Status != "completed" || timeline.ClosedAt != nil {
+		t.Errorf("Expected PR 1's details, got %+v", timeline)
+	}
+	var types []string
+	for _, event := range timeline.Events {
+		types = append(types, event.Type)
+	}
+	if len(types) != 3 || types[0] != db.EventReviewRequested || types[1] != db.EventGenerating || types[2] != db.EventCompleted {
+		t.Errorf("Expected review requested, generating and completed, got %v", types)
+	}
+
+	if code := get(strconv.Itoa(pr.ID + 1)).Code; code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown PR, got %d", code)
+	}
+	if code := get("abc").Code; code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid ID, got %d", code)
+	}
+}
+
+// TestGetEvents tests that the events endpoint returns the transitions after ?since=
+func TestGetEvents(t *testing.T) {
+	s, database := newTestServer(t)
+	for _, number := range []int{1, 2} {
+		if err := database.SyncPR(&db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: number, LastCommitSHA: "abc1234"}, true); err != nil {
+			t.Fatalf("SyncPR failed: %v", err)
+		}
+	}
+	if err := database.UpdateCIStatus("github.com", "acme", "api", 2, "failure", `["lint"]`); err != nil {
+		t.Fatalf("UpdateCIStatus failed: %v", err)
+	}
+
+	get := func(target string) (int, []EventResponse) {
+		rec := httptest.NewRecorder()
+		s.handleGetEvents(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var events []EventResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&events); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return rec.Code, events
+	}
+
+	_, events := get("/api/events")
+	if len(events) != 3 || events[0].Number != 1 || events[2].Type != db.EventCIChanged || events[2].Detail != "unknown → failure" {
+		t.Fatalf("Expected both discoveries then PR 2's CI change, got %+v", events)
+	}
+	if _, later := get("/api/events?since=" + events[0].CreatedAt + "&after_id=" + strconv.Itoa(events[0].ID)); len(later) != 2 || later[0].Number != 2 {
+		t.Errorf("Expected the 2 events after the first, got %+v", later)
+	}
+	if _, from := get("/api/events?since=" + events[0].CreatedAt); len(from) != 3 {
+		t.Errorf("Expected the events from the first's time without after_id, got %+v", from)
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if _, none := get("/api/events?since=" + future); len(none) != 0 {
+		t.Errorf("Expected no events in the future, got %+v", none)
+	}
+	if code, _ := get("/api/events?since=yesterday"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid time, got %d", code)
+	}
+	if code, _ := get("/api/events?after_id=last"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid after_id, got %d", code)
+	}
+}
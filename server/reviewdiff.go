@@ -0,0 +1,124 @@
+package server
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// diffLine is one line of a line diff between two reviews
+type diffLine struct {
+	Op   string `json:"op"` // " " unchanged, "-" only in the older review, "+" only in the newer one
+	Text string `json:"text"`
+}
+
+// maxDiffEdits bounds the work done diffing two reviews. Reviews that differ by more lines than
+// this are shown as entirely replaced.
+const maxDiffEdits = 2000
+
+var (
+	htmlHiddenRe = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBlockRe  = regexp.MustCompile(`(?i)<(br|/?(p|div|li|ul|ol|tr|table|pre|h[1-6]|section|article|blockquote))\b[^>]*>`)
+	htmlTagRe    = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// reviewText extracts the visible text of a review's HTML as trimmed, non-empty lines, so that
+// reviews are diffed on what they say rather than on their markup
+func reviewText(doc string) []string {
+	doc = htmlHiddenRe.ReplaceAllString(doc, "")
+	doc = htmlBlockRe.ReplaceAllString(doc, "\n")
+	doc = html.UnescapeString(htmlTagRe.ReplaceAllString(doc, ""))
+
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// diffLines returns a minimal line diff turning a into b (Myers' algorithm)
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v[-d..d] as it was before round d, for walking the edit path back
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return replaceAll(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Insertion from diagonal k+1
+			} else {
+				x = v[offset+k-1] + 1 // Deletion from diagonal k-1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrackDiff walks the edit path recorded by diffLines from the end back to the start
+func backtrackDiff(trace [][]int, a, b []string) []diffLine {
+	var reversed []diffLine
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d] // v[-d..d] after round d-1
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffLine{Op: " ", Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, diffLine{Op: "+", Text: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, diffLine{Op: "-", Text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, diffLine{Op: " ", Text: a[x]})
+	}
+
+	lines := make([]diffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
+
+func replaceAll(a, b []string) []diffLine {
+	lines := make([]diffLine, 0, len(a)+len(b))
+	for _, line := range a {
+		lines = append(lines, diffLine{Op: "-", Text: line})
+	}
+	for _, line := range b {
+		lines = append(lines, diffLine{Op: "+", Text: line})
+	}
+	return lines
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pr-review-server/db"
+)
+
+// ReviewResponse is one entry in a PR's review history
+type ReviewResponse struct {
+	ID            int     `json:"id"`
+	CommitSHA     string  `json:"commit_sha"`
+	Generator     string  `json:"generator"`
+	StartedAt     string  `json:"started_at"`
+	FinishedAt    *string `json:"finished_at"`
+	Status        string  `json:"status"`     // "generating", "completed", "error", "cancelled"
+	ReviewURL     string  `json:"review_url"` // Empty if the run produced no review
+	StderrExcerpt string  `json:"stderr_excerpt"`
+	ExitCode      *int    `json:"exit_code"` // Null if no process ran or it hasn't finished
+	HasLog        bool    `json:"has_log"`   // Full output is available from the logs endpoint
+	Current       bool    `json:"current"`   // true for the review the dashboard links to
+}
+
+// ReviewLogResponse is one review run with its full generator output
+type ReviewLogResponse struct {
+	ReviewResponse
+	Log          string `json:"log"`
+	LogTruncated bool   `json:"log_truncated"` // Only the last maxLogResponse bytes are included
+}
+
+// maxLogResponse bounds how much of a run's log is returned, keeping the end where errors are
+const maxLogResponse = 1 << 20
+
+// ReviewDiffResponse is a line diff of the text of two reviews of the same PR
+type ReviewDiffResponse struct {
+	From  ReviewResponse `json:"from"`
+	To    ReviewResponse `json:"to"`
+	Lines []diffLine     `json:"lines"`
+}
+
+// reviewResponse converts a review for the API; currentPath is the PR's current review HTML, if known
+func reviewResponse(review db.Review, currentPath string) ReviewResponse {
+	resp := ReviewResponse{
+		ID:            review.ID,
+		CommitSHA:     review.CommitSHA,
+		Generator:     review.Generator,
+		StartedAt:     review.StartedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Status:        review.Status,
+		StderrExcerpt: review.StderrExcerpt,
+		ExitCode:      review.ExitCode,
+		HasLog:        review.LogPath != "",
+		Current:       review.ArtifactPath != "" && review.ArtifactPath == currentPath,
+	}
+	if review.FinishedAt != nil {
+		formatted := review.FinishedAt.UTC().Format("2006-01-02T15:04:05Z")
+		resp.FinishedAt = &formatted
+	}
+	if review.ArtifactPath != "" {
+		resp.ReviewURL = filepath.Join("/reviews", review.ArtifactPath)
+	}
+	return resp
+}
+
+// handleGetReviews lists a PR's review history, newest first:
+// GET /api/prs/reviews?host=&owner=&repo=&number=
+func (s *Server) handleGetReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	host := query.Get("host")
+	if host == "" {
+		host = "github.com"
+	}
+	number, err := strconv.Atoi(query.Get("number"))
+	if err != nil {
+		http.Error(w, "Invalid PR number", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.db.GetPR(host, query.Get("owner"), query.Get("repo"), number)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get PR: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if pr == nil {
+		http.Error(w, "PR not found", http.StatusNotFound)
+		return
+	}
+
+	reviews, err := s.db.GetReviews(pr.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get reviews: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		response = append(response, reviewResponse(review, pr.ReviewHTMLPath))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleDiffReviews diffs the text of two completed reviews of the same PR:
+// GET /api/prs/reviews/diff?from=<review id>&to=<review id>
+func (s *Server) handleDiffReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, status, err := s.completedReview(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	to, status, err := s.completedReview(r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if from.PRID != to.PRID {
+		http.Error(w, "Reviews belong to different PRs", http.StatusBadRequest)
+		return
+	}
+
+	fromHTML, err := os.ReadFile(filepath.Join(s.cfg.ReviewsDir, from.ArtifactPath))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read review %d: %v", from.ID, err), http.StatusNotFound)
+		return
+	}
+	toHTML, err := os.ReadFile(filepath.Join(s.cfg.ReviewsDir, to.ArtifactPath))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read review %d: %v", to.ID, err), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReviewDiffResponse{
+		From:  reviewResponse(*from, ""),
+		To:    reviewResponse(*to, ""),
+		Lines: diffLines(reviewText(string(fromHTML)), reviewText(string(toHTML))),
+	})
+}
+
+// completedReview looks up a review by the ID in a query parameter, returning an HTTP status to
+// report if it can't be diffed
+func (s *Server) completedReview(idParam string) (*db.Review, int, error) {
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid review ID %q", idParam)
+	}
+	review, err := s.db.GetReview(id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to get review %d: %v", id, err)
+	}
+	if review == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("review %d not found", id)
+	}
+	if review.Status != "completed" || review.ArtifactPath == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("review %d has no completed review to compare", id)
+	}
+	return review, http.StatusOK, nil
+}
+
+// handleGetReviewLogs returns the generator output of a PR's latest review run, or of the run given
+// by ?review=<id>: GET /api/prs/{owner}/{repo}/{number}/logs?host=
+func (s *Server) handleGetReviewLogs(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Query().Get("host")
+	if host == "" {
+		host = "github.com"
+	}
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		http.Error(w, "Invalid PR number", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.db.GetPR(host, r.PathValue("owner"), r.PathValue("repo"), number)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get PR: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if pr == nil {
+		http.Error(w, "PR not found", http.StatusNotFound)
+		return
+	}
+
+	reviews, err := s.db.GetReviews(pr.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get reviews: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var review *db.Review
+	if idParam := r.URL.Query().Get("review"); idParam != "" {
+		for i := range reviews {
+			if strconv.Itoa(reviews[i].ID) == idParam {
+				review = &reviews[i]
+			}
+		}
+	} else if len(reviews) > 0 {
+		review = &reviews[0]
+	}
+	if review == nil {
+		http.Error(w, "No review run found", http.StatusNotFound)
+		return
+	}
+
+	response := ReviewLogResponse{ReviewResponse: reviewResponse(*review, pr.ReviewHTMLPath)}
+	if review.LogPath != "" {
+		response.Log, response.LogTruncated, err = readLogTail(filepath.Join(s.cfg.ReviewsDir, review.LogPath), maxLogResponse)
+		if err != nil && !os.IsNotExist(err) {
+			http.Error(w, fmt.Sprintf("Failed to read log: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// readLogTail returns up to the last max bytes of a log file, and whether the start was cut off
+func readLogTail(path string, max int64) (string, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", false, err
+	}
+	truncated := info.Size() > max
+	if truncated {
+		if _, err := f.Seek(-max, io.SeekEnd); err != nil {
+			return "", false, err
+		}
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", false, err
+	}
+	return strings.ToValidUTF8(string(data), ""), truncated, nil
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"pr-review-server/db"
+	"pr-review-server/db/dbtest"
+)
+
+// addTestReview records a completed review of commitSHA whose HTML is body
+func addTestReview(t *testing.T, s *Server, database *db.DB, prID int, commitSHA, body string) int {
+	t.Helper()
+	id, err := database.StartReview(prID, commitSHA, "cbpr", "")
+	if err != nil {
+		t.Fatalf("StartReview failed: %v", err)
+	}
+	filename := "acme_api_1_" + commitSHA + ".html"
+	if err := os.MkdirAll(s.cfg.ReviewsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.cfg.ReviewsDir, filename), []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.FinishReview(id, "completed", filename, "", nil); err != nil {
+		t.Fatalf("FinishReview failed: %v", err)
+	}
+	return id
+}
+
+// TestReviewHistory tests listing a PR's past reviews and diffing two of them
+func TestReviewHistory(t *testing.T) {
+	s, database := newTestServer(t)
+	dbtest.AddPR(t, database, db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "bbbbbbb", Status: "completed", ReviewHTMLPath: "acme_api_1_bbbbbbb.html"})
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+
+	oldID := addTestReview(t, s, database, pr.ID, "aaaaaaa", "<html><head><style>p{}</style></head><body><h1>Review</h1><p>Missing null check</p><p>Typo in &quot;name&quot;</p></body></html>")
+	newID := addTestReview(t, s, database, pr.ID, "bbbbbbb", "<html><body><h1>Review</h1><p>Typo in &quot;name&quot;</p><p>Add a test</p></body></html>")
+
+	rec := httptest.NewRecorder()
+	s.handleGetReviews(rec, httptest.NewRequest(http.MethodGet, "/api/prs/reviews?owner=acme&repo=api&number=1", nil))
+	var reviews []ReviewResponse
+	if err := json.NewDecoder(rec.Body).Decode(&reviews); err != nil {
+		t.Fatalf("Failed to decode reviews: %v", err)
+	}
+	if len(reviews) != 2 || reviews[0].ID != newID || reviews[1].ID != oldID {
+		t.Fatalf("Expected reviews %d then %d, got %+v", newID, oldID, reviews)
+	}
+	if !reviews[0].Current || reviews[1].Current {
+		t.Errorf("Expected only the latest review to be current, got %+v", reviews)
+	}
+	if reviews[1].ReviewURL != "/reviews/acme_api_1_aaaaaaa.html" {
+		t.Errorf("Unexpected review URL %q", reviews[1].ReviewURL)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleDiffReviews(rec, httptest.NewRequest(http.MethodGet, "/api/prs/reviews/diff?from=1&to=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var diff ReviewDiffResponse
+	if err := json.NewDecoder(rec.Body).Decode(&diff); err != nil {
+		t.Fatalf("Failed to decode diff: %v", err)
+	}
+	want := []diffLine{
+		{Op: " ", Text: "Review"},
+		{Op: "-", Text: "Missing null check"},
+		{Op: " ", Text: `Typo in "name"`},
+		{Op: "+", Text: "Add a test"},
+	}
+	if !reflect.DeepEqual(diff.Lines, want) {
+		t.Errorf("Unexpected diff:\n got %+v\nwant %+v", diff.Lines, want)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleDiffReviews(rec, httptest.NewRequest(http.MethodGet, "/api/prs/reviews/diff?from=1&to=99", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown review, got %d", rec.Code)
+	}
+}
+
+// TestDiffLines tests the line diff against edits at the start, middle and end
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"x", "b", "d", "e"}
+	want := []diffLine{
+		{Op: "-", Text: "a"},
+		{Op: "+", Text: "x"},
+		{Op: " ", Text: "b"},
+		{Op: "-", Text: "c"},
+		{Op: " ", Text: "d"},
+		{Op: "+", Text: "e"},
+	}
+	if got := diffLines(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected diff:\n got %+v\nwant %+v", got, want)
+	}
+	if got := diffLines(nil, nil); len(got) != 0 {
+		t.Errorf("Expected empty diff, got %+v", got)
+	}
+}
+
+// TestGetReviewLogs tests that the logs endpoint returns the output and exit code of the latest run
+func TestGetReviewLogs(t *testing.T) {
+	s, database := newTestServer(t)
+	dbtest.AddPR(t, database, db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "aaaaaaa", Status: "error"})
+	pr, _ := database.GetPR("github.com", "acme", "api", 1)
+
+	logPath := filepath.Join("logs", "acme_api_1_aaaaaaa_1.log")
+	if err := os.MkdirAll(filepath.Join(s.cfg.ReviewsDir, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.cfg.ReviewsDir, logPath), []byte("fetching diff\nError: GEMINI_API_KEY not set\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	id, err := database.StartReview(pr.ID, "aaaaaaa", "cbpr", logPath)
+	if err != nil {
+		t.Fatalf("StartReview failed: %v", err)
+	}
+	exitCode := 1
+	if err := database.FinishReview(id, "error", "", "Error: GEMINI_API_KEY not set", &exitCode); err != nil {
+		t.Fatalf("FinishReview failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/prs/acme/api/1/logs", nil)
+	req.SetPathValue("owner", "acme")
+	req.SetPathValue("repo", "api")
+	req.SetPathValue("number", "1")
+	rec := httptest.NewRecorder()
+	s.handleGetReviewLogs(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var logs ReviewLogResponse
+	if err := json.NewDecoder(rec.Body).Decode(&logs); err != nil {
+		t.Fatalf("Failed to decode logs: %v", err)
+	}
+	if logs.ID != id || logs.Status != "error" || logs.ExitCode == nil || *logs.ExitCode != 1 {
+		t.Errorf("Expected failed run %d with exit code 1, got %+v", id, logs.ReviewResponse)
+	}
+	if !strings.Contains(logs.Log, "GEMINI_API_KEY not set") || logs.LogTruncated {
+		t.Errorf("Expected the full log, got %q (truncated=%v)", logs.Log, logs.LogTruncated)
+	}
+}
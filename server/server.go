@@ -4,10 +4,10 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -16,6 +16,7 @@ import (
 	"pr-review-server/config"
 	"pr-review-server/db"
 	"pr-review-server/github"
+	"pr-review-server/poller"
 	"pr-review-server/prioritization"
 )
 
@@ -23,32 +24,44 @@ import (
 var reactDist embed.FS
 
 type PollerInterface interface {
-	GetCbprStatus() (running bool, duration time.Duration)
+	GetRunningReviews() []poller.RunningReview
+	GetQueuedReviewCount() int
+	GetReviewQueue() []poller.QueuedReview
 	GetLastPollTime() time.Time
 	GetPollingInterval() time.Duration
 	GetSecondsUntilNextPoll() int
+	GetDeferredAccounts() map[string]time.Time
+	GetReviewGenerator() string
+	RegenerateReview(host, owner, repo string, number int) (bool, error)
+	CancelReview(host, owner, repo string, number int) (bool, error)
+	ArchivePR(host, owner, repo string, number int, closedAt time.Time, mergedAt *time.Time) (bool, error)
 }
 
 type Server struct {
 	cfg            *config.Config
-	db             *db.DB
-	ghClient       *github.Client
+	db             db.Store
+	accounts       []github.Account
 	prCache        []github.PullRequest
 	prCacheMux     sync.RWMutex
 	pollTriggerFunc func()
+	prRefreshFunc  func(host, owner, repo string, number int)
 	poller         PollerInterface
 	startTime      time.Time
 	// Cache for rate limit info to avoid calling GitHub API on every status request
-	rateLimitCache    *github.RateLimitInfo
+	rateLimitCache    map[string]*github.RateLimitInfo // account name -> rate limit info
 	rateLimitCacheMux sync.RWMutex
 	rateLimitCacheTime time.Time
 	// Prioritization cache
 	priorityResult    *prioritization.Result
+	priorityByPR      map[string]prioritization.PrioritizedPR // "host:owner/repo/number" -> scored PR
 	priorityResultMux sync.RWMutex
 	prioritizer       *prioritization.Prioritizer
 }
 
 type PRResponse struct {
+	ID              int      `json:"id"`      // Database ID, for /api/prs/{id}/timeline
+	Host            string   `json:"host"`    // GitHub host, e.g. "github.com"
+	Account         string   `json:"account"` // Account that discovered the PR ("username@host")
 	Owner           string   `json:"owner"`
 	Repo            string   `json:"repo"`
 	Number          int      `json:"number"`
@@ -57,7 +70,7 @@ type PRResponse struct {
 	ReviewHTMLPath  string   `json:"review_html_path"`
 	GitHubURL       string   `json:"github_url"`
 	ReviewURL       string   `json:"review_url"`
-	Status          string   `json:"status"` // "pending", "generating", "completed", "error"
+	Status          string   `json:"status"` // "pending", "generating", "completed", "error", "failed", "cancelled"
 	Title           string   `json:"title"`
 	Author          string   `json:"author"`
 	GeneratingSince *string  `json:"generating_since"`
@@ -69,15 +82,21 @@ type PRResponse struct {
 	CIState         string   `json:"ci_state"`         // "success", "failure", "pending", "unknown"
 	CIFailedChecks  []string `json:"ci_failed_checks"` // Names of failed checks
 	CreatedAt       *string  `json:"created_at"`       // PR creation timestamp from GitHub
+	QueuePosition   *int     `json:"queue_position"`   // Position in the review queue (1 = next), null if not queued
+	ReviewAttempts  int      `json:"review_attempts"`  // Failed review attempts at commit_sha
+	LastError       string   `json:"last_error"`       // Why the last attempt failed
+	NextRetryAt     *string  `json:"next_retry_at"`    // When an errored review is retried, null if it isn't
+	ClosedAt        *string  `json:"closed_at"`        // When the PR was closed or merged, null while open
+	MergedAt        *string  `json:"merged_at"`        // When the PR was merged, null unless merged
 }
 
-func New(cfg *config.Config, database *db.DB, ghClient *github.Client) *Server {
-	prioritizer := prioritization.New(database, ghClient, cfg.GitHubUsername)
+func New(cfg *config.Config, database db.Store, accounts []github.Account) *Server {
+	prioritizer := prioritization.New(database, accounts)
 
 	return &Server{
 		cfg:         cfg,
 		db:          database,
-		ghClient:    ghClient,
+		accounts:    accounts,
 		startTime:   time.Now(),
 		prioritizer: prioritizer,
 	}
@@ -106,13 +125,26 @@ func (s *Server) SetPollTrigger(f func()) {
 	s.pollTriggerFunc = f
 }
 
+func (s *Server) SetPRRefresh(f func(host, owner, repo string, number int)) {
+	s.prRefreshFunc = f
+}
+
 func (s *Server) Start() error {
 	// API routes
 	http.HandleFunc("/api/prs", s.handleGetPRs)
 	http.HandleFunc("/api/prs/delete", s.handleDeletePR)
 	http.HandleFunc("/api/prs/notes", s.handleUpdatePRNotes)
+	http.HandleFunc("/api/prs/retry", s.handleRetryPR)
+	http.HandleFunc("/api/prs/regenerate", s.handleRegeneratePR)
+	http.HandleFunc("/api/prs/cancel", s.handleCancelPR)
+	http.HandleFunc("/api/prs/reviews", s.handleGetReviews)
+	http.HandleFunc("/api/prs/reviews/diff", s.handleDiffReviews)
+	http.HandleFunc("GET /api/prs/{owner}/{repo}/{number}/logs", s.handleGetReviewLogs)
+	http.HandleFunc("GET /api/prs/{id}/timeline", s.handleGetTimeline)
+	http.HandleFunc("GET /api/events", s.handleGetEvents)
 	http.HandleFunc("/api/status", s.handleStatus)
 	http.HandleFunc("/api/priorities", s.handleGetPriorities)
+	http.HandleFunc("/api/webhooks/github", s.handleGitHubWebhook)
 	http.Handle("/reviews/", http.StripPrefix("/reviews/", http.FileServer(http.Dir(s.cfg.ReviewsDir))))
 
 	// Frontend: Serve React app
@@ -155,21 +187,49 @@ func (s *Server) updatePriorities(ctx context.Context) {
 		return
 	}
 
+	byPR := make(map[string]prioritization.PrioritizedPR, len(result.TopPRs))
+	for _, pr := range result.TopPRs {
+		byPR[fmt.Sprintf("%s:%s/%s/%d", pr.Host, pr.Owner, pr.Repo, pr.Number)] = pr
+	}
+
 	s.priorityResultMux.Lock()
 	s.priorityResult = result
+	s.priorityByPR = byPR
 	s.priorityResultMux.Unlock()
 
 	log.Printf("[PRIORITIZATION] Updated priorities: %d PRs scored", result.TotalPRsScored)
 }
 
+// ReviewPriority returns a PR's latest prioritization score, used to order the review queue
+func (s *Server) ReviewPriority(host, owner, repo string, number int) (poller.ReviewPriority, bool) {
+	s.priorityResultMux.RLock()
+	defer s.priorityResultMux.RUnlock()
+
+	pr, ok := s.priorityByPR[fmt.Sprintf("%s:%s/%s/%d", host, owner, repo, number)]
+	if !ok {
+		return poller.ReviewPriority{}, false
+	}
+	return poller.ReviewPriority{Score: pr.Score, RequestedMe: pr.RequestedMe}, true
+}
+
 func (s *Server) handleGetPRs(w http.ResponseWriter, r *http.Request) {
 	// Prevent caching of API responses
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
 
-	// Fetch all PRs from database (source of truth)
-	dbPRs, err := s.db.GetAllPRs()
+	// Fetch PRs from database (source of truth): open ones, or closed and merged ones with ?state=archived
+	var dbPRs []db.PR
+	var err error
+	switch state := r.URL.Query().Get("state"); state {
+	case "", "open":
+		dbPRs, err = s.db.GetAllPRs()
+	case "archived":
+		dbPRs, err = s.db.GetArchivedPRs()
+	default:
+		http.Error(w, "state must be open or archived", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch PRs from database", http.StatusInternalServerError)
 		return
@@ -179,15 +239,26 @@ func (s *Server) handleGetPRs(w http.ResponseWriter, r *http.Request) {
 	githubPRs := s.GetCachedPRs()
 	githubMap := make(map[string]github.PullRequest)
 	for _, ghPR := range githubPRs {
-		key := fmt.Sprintf("%s/%s/%d", ghPR.Owner, ghPR.Repo, ghPR.Number)
+		key := fmt.Sprintf("%s:%s/%s/%d", ghPR.Host, ghPR.Owner, ghPR.Repo, ghPR.Number)
 		githubMap[key] = ghPR
 	}
 
+	// 1-based position of each PR waiting for a review worker
+	queuePositions := make(map[string]int)
+	if s.poller != nil {
+		for i, queued := range s.poller.GetReviewQueue() {
+			key := fmt.Sprintf("%s:%s/%s/%d", queued.Host, queued.Owner, queued.Repo, queued.Number)
+			queuePositions[key] = i + 1
+		}
+	}
+
 	response := make([]PRResponse, 0, len(dbPRs))
 	for _, dbPR := range dbPRs {
 		var reviewedAt *string
 		var generatingSince *string
 		var createdAt *string
+		var nextRetryAt *string
+		var closedAt, mergedAt *string
 
 		if dbPR.LastReviewedAt != nil {
 			formatted := dbPR.LastReviewedAt.UTC().Format("2006-01-02T15:04:05Z")
@@ -201,9 +272,21 @@ func (s *Server) handleGetPRs(w http.ResponseWriter, r *http.Request) {
 			formatted := dbPR.CreatedAt.UTC().Format("2006-01-02T15:04:05Z")
 			createdAt = &formatted
 		}
+		if dbPR.NextRetryAt != nil && dbPR.Status == "error" {
+			formatted := dbPR.NextRetryAt.UTC().Format("2006-01-02T15:04:05Z")
+			nextRetryAt = &formatted
+		}
+		if dbPR.ClosedAt != nil {
+			formatted := dbPR.ClosedAt.UTC().Format("2006-01-02T15:04:05Z")
+			closedAt = &formatted
+		}
+		if dbPR.MergedAt != nil {
+			formatted := dbPR.MergedAt.UTC().Format("2006-01-02T15:04:05Z")
+			mergedAt = &formatted
+		}
 
 		// Try to get GitHub URL from cache, fallback to constructed URL
-		key := fmt.Sprintf("%s/%s/%d", dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber)
+		key := fmt.Sprintf("%s:%s/%s/%d", dbPR.Host, dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber)
 		ghPR, hasCachedData := githubMap[key]
 
 		title := dbPR.Title
@@ -216,11 +299,16 @@ func (s *Server) handleGetPRs(w http.ResponseWriter, r *http.Request) {
 			author = "Unknown"
 		}
 
-		githubURL := fmt.Sprintf("https://github.com/%s/%s/pull/%d", dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber)
+		githubURL := github.PRWebURL(s.cfg.WebURLForHost(dbPR.Host), dbPR.RepoOwner, dbPR.RepoName, dbPR.PRNumber)
 		if hasCachedData {
 			githubURL = ghPR.URL
 		}
 
+		var queuePosition *int
+		if position, ok := queuePositions[key]; ok {
+			queuePosition = &position
+		}
+
 		// Parse CI failed checks JSON array
 		var ciFailedChecks []string
 		if dbPR.CIFailedChecks != "" && dbPR.CIFailedChecks != "[]" {
@@ -231,6 +319,9 @@ func (s *Server) handleGetPRs(w http.ResponseWriter, r *http.Request) {
 		}
 
 		response = append(response, PRResponse{
+			ID:              dbPR.ID,
+			Host:            dbPR.Host,
+			Account:         dbPR.Account,
 			Owner:           dbPR.RepoOwner,
 			Repo:            dbPR.RepoName,
 			Number:          dbPR.PRNumber,
@@ -251,6 +342,12 @@ func (s *Server) handleGetPRs(w http.ResponseWriter, r *http.Request) {
 			CIState:         dbPR.CIState,
 			CIFailedChecks:  ciFailedChecks,
 			CreatedAt:       createdAt,
+			QueuePosition:   queuePosition,
+			ReviewAttempts:  dbPR.ReviewAttempts,
+			LastError:       dbPR.LastError,
+			NextRetryAt:     nextRetryAt,
+			ClosedAt:        closedAt,
+			MergedAt:        mergedAt,
 		})
 	}
 
@@ -266,6 +363,7 @@ func (s *Server) handleDeletePR(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body
 	var req struct {
+		Host   string `json:"host"` // Optional, defaults to github.com
 		Owner  string `json:"owner"`
 		Repo   string `json:"repo"`
 		Number int    `json:"number"`
@@ -274,24 +372,24 @@ func (s *Server) handleDeletePR(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
 		return
 	}
+	if req.Host == "" {
+		req.Host = "github.com"
+	}
 
-	// Get PR from DB to find HTML file
-	pr, err := s.db.GetPR(req.Owner, req.Repo, req.Number)
+	// Get PR from DB to find its review files
+	pr, err := s.db.GetPR(req.Host, req.Owner, req.Repo, req.Number)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get PR: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Delete HTML file if it exists
-	if pr != nil && pr.ReviewHTMLPath != "" {
-		htmlPath := filepath.Join(s.cfg.ReviewsDir, pr.ReviewHTMLPath)
-		if err := os.Remove(htmlPath); err != nil && !os.IsNotExist(err) {
-			log.Printf("Warning: failed to delete HTML file %s: %v", htmlPath, err)
-		}
+	// Delete HTML files and logs of the current and past reviews
+	if pr != nil {
+		poller.RemoveReviewFiles(s.db, s.cfg.ReviewsDir, pr)
 	}
 
 	// Delete from database
-	if err := s.db.DeletePR(req.Owner, req.Repo, req.Number); err != nil {
+	if err := s.db.DeletePR(req.Host, req.Owner, req.Repo, req.Number); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete PR: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -307,6 +405,158 @@ func (s *Server) handleDeletePR(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
 }
 
+// handleRetryPR queues an errored or failed review again right away, without waiting for its
+// backoff and even if it has run out of attempts
+func (s *Server) handleRetryPR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Host   string `json:"host"` // Optional, defaults to github.com
+		Owner  string `json:"owner"`
+		Repo   string `json:"repo"`
+		Number int    `json:"number"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Host == "" {
+		req.Host = "github.com"
+	}
+
+	pr, err := s.db.GetPR(req.Host, req.Owner, req.Repo, req.Number)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get PR: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if pr == nil {
+		http.Error(w, "PR not found", http.StatusNotFound)
+		return
+	}
+
+	reset, err := s.db.RetryPRNow(req.Host, req.Owner, req.Repo, req.Number)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retry PR: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !reset {
+		http.Error(w, fmt.Sprintf("PR review is %s, only errored or failed reviews can be retried", pr.Status), http.StatusConflict)
+		return
+	}
+
+	log.Printf("Retrying review for %s/%s#%d after %d failed attempts", req.Owner, req.Repo, req.Number, pr.ReviewAttempts)
+
+	// Queue the review now rather than on the next poll
+	if s.prRefreshFunc != nil {
+		s.refreshPR(req.Host, req.Owner, req.Repo, req.Number)
+	} else if s.pollTriggerFunc != nil {
+		s.pollTriggerFunc()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// handleRegeneratePR discards a PR's review and queues a new one right away. Unlike deleting the PR
+// it keeps notes and other metadata, and the PR's review history.
+func (s *Server) handleRegeneratePR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Host   string `json:"host"` // Optional, defaults to github.com
+		Owner  string `json:"owner"`
+		Repo   string `json:"repo"`
+		Number int    `json:"number"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Host == "" {
+		req.Host = "github.com"
+	}
+	if s.poller == nil {
+		http.Error(w, "Poller not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	found, err := s.poller.RegenerateReview(req.Host, req.Owner, req.Repo, req.Number)
+	if errors.Is(err, poller.ErrReviewsDisabled) {
+		http.Error(w, "Review generation is disabled (REVIEW_GENERATOR=none)", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to regenerate review: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "PR not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Regenerating review for %s/%s#%d", req.Owner, req.Repo, req.Number)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// handleCancelPR stops a PR's queued or running review and marks it cancelled
+func (s *Server) handleCancelPR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Host   string `json:"host"` // Optional, defaults to github.com
+		Owner  string `json:"owner"`
+		Repo   string `json:"repo"`
+		Number int    `json:"number"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Host == "" {
+		req.Host = "github.com"
+	}
+	if s.poller == nil {
+		http.Error(w, "Poller not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	pr, err := s.db.GetPR(req.Host, req.Owner, req.Repo, req.Number)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get PR: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if pr == nil {
+		http.Error(w, "PR not found", http.StatusNotFound)
+		return
+	}
+
+	cancelled, err := s.poller.CancelReview(req.Host, req.Owner, req.Repo, req.Number)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to cancel review: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !cancelled {
+		http.Error(w, fmt.Sprintf("PR review is %s, only queued or generating reviews can be cancelled", pr.Status), http.StatusConflict)
+		return
+	}
+
+	log.Printf("Cancelled review for %s/%s#%d", req.Owner, req.Repo, req.Number)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
 func (s *Server) handleUpdatePRNotes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost && r.Method != http.MethodPatch {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -314,6 +564,7 @@ func (s *Server) handleUpdatePRNotes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
+		Host   string `json:"host"` // Optional, defaults to github.com
 		Owner  string `json:"owner"`
 		Repo   string `json:"repo"`
 		Number int    `json:"number"`
@@ -323,6 +574,9 @@ func (s *Server) handleUpdatePRNotes(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
 		return
 	}
+	if req.Host == "" {
+		req.Host = "github.com"
+	}
 
 	// Validate length
 	if len(req.Notes) > 15 {
@@ -331,7 +585,7 @@ func (s *Server) handleUpdatePRNotes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update database
-	if err := s.db.UpdatePRNotes(req.Owner, req.Repo, req.Number, req.Notes); err != nil {
+	if err := s.db.UpdatePRNotes(req.Host, req.Owner, req.Repo, req.Number, req.Notes); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update notes: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -363,19 +617,50 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		"generating": 0,
 		"pending":    0,
 		"error":      0,
+		"failed":     0,
+		"cancelled":  0,
 	}
 	for _, pr := range prs {
 		counts[pr.Status]++
 	}
 
-	// Get cbpr status from poller
-	var cbprRunning bool
+	// Get review worker status from poller
 	var cbprDuration time.Duration
 	var secondsUntilNextPoll int
+	var queuedReviews int
+	runningReviews := []map[string]interface{}{}
+	reviewGenerator := "none"
+	deferredAccounts := []map[string]interface{}{}
 	if s.poller != nil {
-		cbprRunning, cbprDuration = s.poller.GetCbprStatus()
+		for _, review := range s.poller.GetRunningReviews() {
+			elapsed := time.Since(review.StartedAt)
+			if elapsed > cbprDuration {
+				cbprDuration = elapsed
+			}
+			runningReviews = append(runningReviews, map[string]interface{}{
+				"host":             review.Host,
+				"owner":            review.Owner,
+				"repo":             review.Repo,
+				"number":           review.Number,
+				"pid":              review.PID,
+				"duration_seconds": int(elapsed.Seconds()),
+			})
+		}
+		queuedReviews = s.poller.GetQueuedReviewCount()
+		reviewGenerator = s.poller.GetReviewGenerator()
 		// Get accurate countdown based on ticker timing
 		secondsUntilNextPoll = s.poller.GetSecondsUntilNextPoll()
+
+		// Accounts skipped until their rate limit resets
+		deferred := s.poller.GetDeferredAccounts()
+		for _, acct := range s.accounts {
+			if until, ok := deferred[acct.Name]; ok {
+				deferredAccounts = append(deferredAccounts, map[string]interface{}{
+					"account": acct.Name,
+					"until":   until.Format(time.RFC3339),
+				})
+			}
+		}
 	}
 
 	// Get recent completions (last 3)
@@ -400,59 +685,115 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get GitHub API rate limit status (cached to avoid excessive API calls)
+	// Get GitHub API rate limit status for each account (cached to avoid excessive API calls)
 	// Web client polls every 1 second, so we cache for 30 seconds
-	s.rateLimitCacheMux.RLock()
-	cachedInfo := s.rateLimitCache
-	cacheAge := time.Since(s.rateLimitCacheTime)
-	s.rateLimitCacheMux.RUnlock()
-
-	// Refresh cache if older than 30 seconds or not set
-	if cachedInfo == nil || cacheAge > 30*time.Second {
-		ctx := r.Context()
-		freshInfo, err := s.ghClient.GetRateLimitInfo(ctx)
-		if err == nil {
-			s.rateLimitCacheMux.Lock()
-			s.rateLimitCache = freshInfo
-			s.rateLimitCacheTime = time.Now()
-			cachedInfo = freshInfo
-			s.rateLimitCacheMux.Unlock()
-		} else {
-			log.Printf("[STATUS] Warning: Failed to refresh rate limit info: %v", err)
-			// Keep using old cache if we have it
+	rateLimits := s.getRateLimits(r.Context())
+
+	// rate_limit reports the primary account; rate_limits lists every account
+	rateLimitData := map[string]interface{}{}
+	accountRateLimits := []map[string]interface{}{}
+	for i, acct := range s.accounts {
+		data := rateLimitJSON(acct.Name, rateLimits[acct.Name])
+		if i == 0 {
+			rateLimitData = data
 		}
+		accountRateLimits = append(accountRateLimits, data)
 	}
-
-	rateLimitData := map[string]interface{}{
-		"remaining": 0,
-		"limit":     5000,
-		"reset_at":  "",
-		"is_limited": true,
-		"error":     "",
+	if len(s.accounts) == 0 {
+		rateLimitData = rateLimitJSON("", nil)
 	}
-	if cachedInfo != nil {
-		rateLimitData["remaining"] = cachedInfo.Remaining
-		rateLimitData["limit"] = cachedInfo.Limit
-		rateLimitData["reset_at"] = cachedInfo.ResetTime.Format(time.RFC3339)
-		rateLimitData["is_limited"] = cachedInfo.Remaining < 10
+
+	// Search totals vs fetched counts, so truncated searches (GitHub caps results at 1000) are visible
+	searchStats := []map[string]interface{}{}
+	searchTruncated := false
+	for _, acct := range s.accounts {
+		for _, stats := range acct.Client.GetSearchStats() {
+			truncated := stats.Fetched < stats.Total
+			searchTruncated = searchTruncated || truncated
+			searchStats = append(searchStats, map[string]interface{}{
+				"account":   acct.Name,
+				"kind":      stats.Kind,
+				"query":     stats.Query,
+				"total":     stats.Total,
+				"fetched":   stats.Fetched,
+				"truncated": truncated,
+			})
+		}
 	}
 
 	response := map[string]interface{}{
 		"uptime_seconds":           int(time.Since(s.startTime).Seconds()),
-		"cbpr_running":             cbprRunning,
-		"cbpr_duration_seconds":    int(cbprDuration.Seconds()),
+		"cbpr_running":             len(runningReviews) > 0,
+		"cbpr_duration_seconds":    int(cbprDuration.Seconds()), // Longest running review
+		"running_reviews":          runningReviews,
+		"queued_reviews":           queuedReviews,
+		"review_generator":         reviewGenerator,
 		"counts":                   counts,
 		"recent_completions":       recentCompletions,
 		"missing_metadata_count":   missingMetadataCount,
 		"timestamp":                time.Now().Unix(),
 		"seconds_until_next_poll":  secondsUntilNextPoll,
 		"rate_limit":               rateLimitData,
+		"rate_limits":              accountRateLimits,
+		"search_stats":             searchStats,
+		"search_truncated":         searchTruncated,
+		"deferred_accounts":        deferredAccounts,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// getRateLimits returns rate limit info keyed by account name, refreshing the cache every 30 seconds
+func (s *Server) getRateLimits(ctx context.Context) map[string]*github.RateLimitInfo {
+	s.rateLimitCacheMux.RLock()
+	cached := s.rateLimitCache
+	cacheAge := time.Since(s.rateLimitCacheTime)
+	s.rateLimitCacheMux.RUnlock()
+
+	if cached != nil && cacheAge <= 30*time.Second {
+		return cached
+	}
+
+	fresh := make(map[string]*github.RateLimitInfo, len(s.accounts))
+	for _, acct := range s.accounts {
+		info, err := acct.Client.GetRateLimitInfo(ctx)
+		if err != nil {
+			log.Printf("[STATUS] Warning: Failed to refresh rate limit info for %s: %v", acct.Name, err)
+			// Keep using old cache if we have it
+			info = cached[acct.Name]
+		}
+		if info != nil {
+			fresh[acct.Name] = info
+		}
+	}
+
+	s.rateLimitCacheMux.Lock()
+	s.rateLimitCache = fresh
+	s.rateLimitCacheTime = time.Now()
+	s.rateLimitCacheMux.Unlock()
+	return fresh
+}
+
+// rateLimitJSON renders rate limit info for the status endpoint, reporting unknown limits as exhausted
+func rateLimitJSON(account string, info *github.RateLimitInfo) map[string]interface{} {
+	data := map[string]interface{}{
+		"account":    account,
+		"remaining":  0,
+		"limit":      5000,
+		"reset_at":   "",
+		"is_limited": true,
+		"error":      "",
+	}
+	if info != nil {
+		data["remaining"] = info.Remaining
+		data["limit"] = info.Limit
+		data["reset_at"] = info.ResetTime.Format(time.RFC3339)
+		data["is_limited"] = info.Remaining < 10
+	}
+	return data
+}
+
 func (s *Server) handleGetPriorities(w http.ResponseWriter, r *http.Request) {
 	// Prevent caching of API responses
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-review-server/config"
+	"pr-review-server/db"
+	"pr-review-server/db/dbtest"
+	"pr-review-server/poller"
+)
+
+// newTestServer returns a Server backed by a temporary database, with webhooks enabled
+func newTestServer(t *testing.T) (*Server, *db.DB) {
+	t.Helper()
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	cfg := &config.Config{WebhookSecret: testWebhookSecret, ReviewsDir: filepath.Join(dir, "reviews")}
+	return New(cfg, database, nil), database
+}
+
+// stubPoller reports a fixed review queue and records regenerate, cancel and archive requests
+type stubPoller struct {
+	queue       []poller.QueuedReview
+	regenerated []int
+	cancelled   []int
+	archived    []int
+}
+
+func (s *stubPoller) GetRunningReviews() []poller.RunningReview { return nil }
+func (s *stubPoller) GetQueuedReviewCount() int                 { return len(s.queue) }
+func (s *stubPoller) GetReviewQueue() []poller.QueuedReview     { return s.queue }
+func (s *stubPoller) GetLastPollTime() time.Time                { return time.Time{} }
+func (s *stubPoller) GetPollingInterval() time.Duration         { return time.Minute }
+func (s *stubPoller) GetSecondsUntilNextPoll() int              { return 0 }
+func (s *stubPoller) GetDeferredAccounts() map[string]time.Time { return nil }
+func (s *stubPoller) GetReviewGenerator() string                { return "none" }
+
+func (s *stubPoller) RegenerateReview(host, owner, repo string, number int) (bool, error) {
+	s.regenerated = append(s.regenerated, number)
+	return true, nil
+}
+
+func (s *stubPoller) CancelReview(host, owner, repo string, number int) (bool, error) {
+	s.cancelled = append(s.cancelled, number)
+	return number == 1, nil
+}
+
+func (s *stubPoller) ArchivePR(host, owner, repo string, number int, closedAt time.Time, mergedAt *time.Time) (bool, error) {
+	s.archived = append(s.archived, number)
+	return true, nil
+}
+
+// TestGetPRs_QueuePosition tests that /api/prs reports where pending PRs are in the review queue
+func TestGetPRs_QueuePosition(t *testing.T) {
+	s, database := newTestServer(t)
+	for _, number := range []int{1, 2, 3} {
+		dbtest.AddPR(t, database, db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: number, LastCommitSHA: "abc1234", Status: "pending"})
+	}
+	s.SetPoller(&stubPoller{queue: []poller.QueuedReview{
+		{Host: "github.com", Owner: "acme", Repo: "api", Number: 3},
+		{Host: "github.com", Owner: "acme", Repo: "api", Number: 1},
+	}})
+
+	rec := httptest.NewRecorder()
+	s.handleGetPRs(rec, httptest.NewRequest(http.MethodGet, "/api/prs", nil))
+
+	var prs []PRResponse
+	if err := json.NewDecoder(rec.Body).Decode(&prs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := map[int]int{3: 1, 1: 2}
+	for _, pr := range prs {
+		wantPos, queued := want[pr.Number]
+		switch {
+		case !queued && pr.QueuePosition != nil:
+			t.Errorf("PR %d: expected no queue position, got %d", pr.Number, *pr.QueuePosition)
+		case queued && (pr.QueuePosition == nil || *pr.QueuePosition != wantPos):
+			t.Errorf("PR %d: expected queue position %d, got %v", pr.Number, wantPos, pr.QueuePosition)
+		}
+	}
+}
+
+// TestGetPRs_Archived tests that /api/prs lists open PRs and ?state=archived closed and merged ones
+func TestGetPRs_Archived(t *testing.T) {
+	s, database := newTestServer(t)
+	for _, number := range []int{1, 2, 3} {
+		dbtest.AddPR(t, database, db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: number, LastCommitSHA: "abc1234", Status: "completed"})
+	}
+	merged := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	if _, err := database.ArchivePR("github.com", "acme", "api", 2, merged, &merged); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+	if _, err := database.ArchivePR("github.com", "acme", "api", 3, merged.Add(time.Hour), nil); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+
+	get := func(target string) (int, []PRResponse) {
+		rec := httptest.NewRecorder()
+		s.handleGetPRs(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var prs []PRResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&prs); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return rec.Code, prs
+	}
+
+	if _, prs := get("/api/prs"); len(prs) != 1 || prs[0].Number != 1 || prs[0].ClosedAt != nil {
+		t.Errorf("Expected only open PR 1, got %+v", prs)
+	}
+	_, prs := get("/api/prs?state=archived")
+	if len(prs) != 2 || prs[0].Number != 3 || prs[1].Number != 2 {
+		t.Fatalf("Expected PRs 3 and 2, most recently closed first, got %+v", prs)
+	}
+	if prs[0].MergedAt != nil || prs[1].MergedAt == nil || *prs[1].MergedAt != "2024-03-02T10:00:00Z" || prs[1].ClosedAt == nil {
+		t.Errorf("Expected PR 3 closed and PR 2 merged, got %+v", prs)
+	}
+	if code, _ := get("/api/prs?state=bogus"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown state, got %d", code)
+	}
+}
+
+// TestRetryPR tests that only errored or failed reviews can be retried, and that a retry resets the
+// PR to pending and asks the poller to pick it up
+func TestRetryPR(t *testing.T) {
+	s, database := newTestServer(t)
+	var refreshed []int
+	s.SetPRRefresh(func(host, owner, repo string, number int) { refreshed = append(refreshed, number) })
+
+	dbtest.AddPR(t, database, db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 1, LastCommitSHA: "abc1234", Status: "pending"})
+	dbtest.AddPR(t, database, db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 2, LastCommitSHA: "def5678", Status: "completed"})
+	if err := database.RecordReviewFailure("github.com", "acme", "api", 1, "abc1234", "failed", "exit status 3", 5, nil); err != nil {
+		t.Fatalf("RecordReviewFailure failed: %v", err)
+	}
+
+	retry := func(number int) int {
+		body := strings.NewReader(`{"owner": "acme", "repo": "api", "number": ` + strconv.Itoa(number) + `}`)
+		rec := httptest.NewRecorder()
+		s.handleRetryPR(rec, httptest.NewRequest(http.MethodPost, "/api/prs/retry", body))
+		return rec.Code
+	}
+
+	if code := retry(2); code != http.StatusConflict {
+		t.Errorf("Expected 409 retrying a completed review, got %d", code)
+	}
+	if code := retry(3); code != http.StatusNotFound {
+		t.Errorf("Expected 404 retrying an unknown PR, got %d", code)
+	}
+	if code := retry(1); code != http.StatusOK {
+		t.Fatalf("Expected 200 retrying a failed review, got %d", code)
+	}
+
+	pr, err := database.GetPR("github.com", "acme", "api", 1)
+	if err != nil || pr == nil {
+		t.Fatalf("Expected PR: %v", err)
+	}
+	if pr.Status != "pending" {
+		t.Errorf("Expected pending after retry, got %s", pr.Status)
+	}
+	if len(refreshed) != 1 || refreshed[0] != 1 {
+		t.Errorf("Expected a refresh of PR 1, got %v", refreshed)
+	}
+}
+
+// TestRegenerateAndCancelPR tests that the regenerate and cancel endpoints hand off to the poller
+func TestRegenerateAndCancelPR(t *testing.T) {
+	s, database := newTestServer(t)
+	stub := &stubPoller{}
+	s.SetPoller(stub)
+	for _, number := range []int{1, 2} {
+		dbtest.AddPR(t, database, db.PR{Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: number, LastCommitSHA: "abc1234", Status: "completed"})
+	}
+
+	post := func(handler http.HandlerFunc, path string, number int) int {
+		body := strings.NewReader(`{"owner": "acme", "repo": "api", "number": ` + strconv.Itoa(number) + `}`)
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, path, body))
+		return rec.Code
+	}
+
+	if code := post(s.handleRegeneratePR, "/api/prs/regenerate", 2); code != http.StatusOK {
+		t.Errorf("Expected 200 regenerating, got %d", code)
+	}
+	if len(stub.regenerated) != 1 || stub.regenerated[0] != 2 {
+		t.Errorf("Expected PR 2 to be regenerated, got %v", stub.regenerated)
+	}
+
+	if code := post(s.handleCancelPR, "/api/prs/cancel", 1); code != http.StatusOK {
+		t.Errorf("Expected 200 cancelling a running review, got %d", code)
+	}
+	if code := post(s.handleCancelPR, "/api/prs/cancel", 2); code != http.StatusConflict {
+		t.Errorf("Expected 409 when there is nothing to cancel, got %d", code)
+	}
+	if code := post(s.handleCancelPR, "/api/prs/cancel", 3); code != http.StatusNotFound {
+		t.Errorf("Expected 404 cancelling an unknown PR, got %d", code)
+	}
+}
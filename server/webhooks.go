@@ -0,0 +1,232 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"pr-review-server/db"
+)
+
+// maxWebhookPayload matches GitHub's 25 MB cap on webhook payloads
+const maxWebhookPayload = 25 << 20
+
+// webhookRepository is the repository object included in every webhook payload
+type webhookRepository struct {
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+	Owner   struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// host returns the GitHub host the repository lives on, e.g. "github.com" or a GHES hostname
+func (r webhookRepository) host() string {
+	u, err := url.Parse(r.HTMLURL)
+	if err != nil || u.Host == "" {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// webhookPullRequest is the subset of a pull request object the receiver uses
+type webhookPullRequest struct {
+	Number   int        `json:"number"`
+	Title    string     `json:"title"`
+	Draft    bool       `json:"draft"`
+	ClosedAt *time.Time `json:"closed_at"`
+	MergedAt *time.Time `json:"merged_at"`
+	User     struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// webhookPayload covers the fields used from pull_request, pull_request_review, check_suite,
+// check_run and status events
+type webhookPayload struct {
+	Action      string             `json:"action"`
+	Repository  webhookRepository  `json:"repository"`
+	PullRequest webhookPullRequest `json:"pull_request"`
+	CheckSuite  struct {
+		HeadSHA string `json:"head_sha"`
+		Status  string `json:"status"`
+	} `json:"check_suite"`
+	CheckRun struct {
+		HeadSHA string `json:"head_sha"`
+		Status  string `json:"status"`
+	} `json:"check_run"`
+	SHA   string `json:"sha"`   // status events
+	State string `json:"state"` // status events
+}
+
+// verifyWebhookSignature checks the X-Hub-Signature-256 header ("sha256=<hex HMAC of the body>")
+func verifyWebhookSignature(secret string, body []byte, signature string) bool {
+	hexSig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// handleGitHubWebhook applies PR events from GitHub as they happen. Fields the payload fully
+// determines are written to the prs table directly; everything else (approval counts, CI rollups,
+// new commits, newly requested reviews) is handed to the poller as a refresh of just that PR.
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.cfg.WebhookSecret == "" {
+		http.Error(w, "Webhooks are not configured (set GITHUB_WEBHOOK_SECRET)", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+	if !verifyWebhookSignature(s.cfg.WebhookSecret, body, r.Header.Get("X-Hub-Signature-256")) {
+		log.Printf("[WEBHOOK] Rejected delivery %s: invalid signature", r.Header.Get("X-GitHub-Delivery"))
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	if event == "ping" {
+		log.Printf("[WEBHOOK] Received ping")
+		writeWebhookResponse(w, "pong")
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	host := payload.Repository.host()
+	owner, repo := payload.Repository.Owner.Login, payload.Repository.Name
+	log.Printf("[WEBHOOK] %s %s on %s/%s/%s", event, payload.Action, host, owner, repo)
+
+	switch event {
+	case "pull_request":
+		s.applyPullRequestEvent(host, owner, repo, payload)
+	case "pull_request_review":
+		s.refreshPR(host, owner, repo, payload.PullRequest.Number)
+	case "check_suite":
+		s.applyCommitEvent(host, owner, repo, payload.CheckSuite.HeadSHA, payload.CheckSuite.Status != "completed")
+	case "check_run":
+		s.applyCommitEvent(host, owner, repo, payload.CheckRun.HeadSHA, payload.CheckRun.Status != "completed")
+	case "status":
+		s.applyCommitEvent(host, owner, repo, payload.SHA, payload.State == "pending")
+	default:
+		writeWebhookResponse(w, "ignored")
+		return
+	}
+
+	writeWebhookResponse(w, "accepted")
+}
+
+// applyPullRequestEvent updates title, author and draft state from the payload, archives closed PRs,
+// and refreshes the PR for everything else (new commits, review requests). Archived PRs are only
+// refreshed, which reopens them if GitHub reports them open again.
+func (s *Server) applyPullRequestEvent(host, owner, repo string, payload webhookPayload) {
+	pr := payload.PullRequest
+	existing, err := s.db.GetPR(host, owner, repo, pr.Number)
+	if err != nil {
+		log.Printf("[WEBHOOK] ERROR: Failed to get %s/%s#%d: %v", owner, repo, pr.Number, err)
+		return
+	}
+
+	if existing != nil && !existing.Archived() {
+		if payload.Action == "closed" {
+			s.archivePR(existing, pr)
+			return
+		}
+		if err := s.db.UpdateGitHubMetadata(host, owner, repo, pr.Number, pr.Title, pr.User.Login, pr.Draft); err != nil {
+			log.Printf("[WEBHOOK] ERROR: Failed to update metadata for %s/%s#%d: %v", owner, repo, pr.Number, err)
+		}
+		// A new head commit has no check results yet
+		if payload.Action == "synchronize" {
+			if err := s.db.UpdateCIStatus(host, owner, repo, pr.Number, "pending", "[]"); err != nil {
+				log.Printf("[WEBHOOK] ERROR: Failed to update CI state for %s/%s#%d: %v", owner, repo, pr.Number, err)
+			}
+		}
+	} else if payload.Action == "closed" {
+		return
+	}
+
+	s.refreshPR(host, owner, repo, pr.Number)
+}
+
+// applyCommitEvent marks tracked PRs at sha as pending while checks run, then refreshes them for the CI rollup.
+// Check and status events carry the commit rather than the PR, and fork PRs aren't listed, so PRs are matched by head SHA.
+func (s *Server) applyCommitEvent(host, owner, repo, sha string, running bool) {
+	if sha == "" {
+		return
+	}
+	prs, err := s.db.GetAllPRs()
+	if err != nil {
+		log.Printf("[WEBHOOK] ERROR: Failed to get PRs: %v", err)
+		return
+	}
+
+	for _, pr := range prs {
+		if pr.Host != host || pr.RepoOwner != owner || pr.RepoName != repo || pr.LastCommitSHA != sha {
+			continue
+		}
+		if running {
+			if err := s.db.UpdateCIStatus(host, owner, repo, pr.PRNumber, "pending", "[]"); err != nil {
+				log.Printf("[WEBHOOK] ERROR: Failed to update CI state for %s/%s#%d: %v", owner, repo, pr.PRNumber, err)
+			}
+		}
+		s.refreshPR(host, owner, repo, pr.PRNumber)
+	}
+}
+
+// archivePR moves a closed or merged PR to the archive, keeping its reviews. The poller also drops
+// its queued review and kills a running one; without a poller no review can be running.
+func (s *Server) archivePR(pr *db.PR, payload webhookPullRequest) {
+	closedAt := time.Now()
+	if payload.ClosedAt != nil {
+		closedAt = *payload.ClosedAt
+	}
+	archive := s.db.ArchivePR
+	if s.poller != nil {
+		archive = s.poller.ArchivePR
+	}
+	archived, err := archive(pr.Host, pr.RepoOwner, pr.RepoName, pr.PRNumber, closedAt, payload.MergedAt)
+	if err != nil {
+		log.Printf("[WEBHOOK] ERROR: Failed to archive %s/%s#%d: %v", pr.RepoOwner, pr.RepoName, pr.PRNumber, err)
+		return
+	}
+	if archived {
+		log.Printf("[WEBHOOK] Archived closed PR %s/%s#%d", pr.RepoOwner, pr.RepoName, pr.PRNumber)
+	}
+}
+
+// refreshPR asks the poller to sync one PR with GitHub
+func (s *Server) refreshPR(host, owner, repo string, number int) {
+	if s.prRefreshFunc != nil && number > 0 {
+		s.prRefreshFunc(host, owner, repo, number)
+	}
+}
+
+func writeWebhookResponse(w http.ResponseWriter, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-review-server/db"
+	"pr-review-server/db/dbtest"
+)
+
+const testWebhookSecret = "s3cret"
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func deliver(s *Server, event, body, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/github", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", event)
+	if signature != "" {
+		req.Header.Set("X-Hub-Signature-256", signature)
+	}
+	rec := httptest.NewRecorder()
+	s.handleGitHubWebhook(rec, req)
+	return rec
+}
+
+// TestWebhook_Signature tests that deliveries are only accepted with a valid HMAC signature
+func TestWebhook_Signature(t *testing.T) {
+	s, _ := newTestServer(t)
+	body := `{"zen":"Keep it logically awesome."}`
+
+	tests := []struct {
+		name      string
+		signature string
+		want      int
+	}{
+		{"valid", sign(testWebhookSecret, body), http.StatusOK},
+		{"wrong secret", sign("other", body), http.StatusUnauthorized},
+		{"malformed", "sha256=not-hex", http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := deliver(s, "ping", body, tt.signature); rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+// TestWebhook_Disabled tests that the endpoint is off when no secret is configured
+func TestWebhook_Disabled(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.cfg.WebhookSecret = ""
+
+	if rec := deliver(s, "ping", "{}", sign("", "{}")); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a secret, got %d", rec.Code)
+	}
+}
+
+// TestWebhook_PullRequest tests that pull_request events update the stored PR and request a refresh
+func TestWebhook_PullRequest(t *testing.T) {
+	s, database := newTestServer(t)
+	dbtest.AddPR(t, database, db.PR{
+		Host: "github.com", RepoOwner: "acme", RepoName: "api", PRNumber: 7,
+		LastCommitSHA: "abc1234", Status: "completed", Title: "Old title", Author: "alice", Draft: true,
+	})
+
+	var refreshed []string
+	s.SetPRRefresh(func(host, owner, repo string, number int) {
+		refreshed = append(refreshed, host+"/"+owner+"/"+repo)
+	})
+
+	body := `{"action":"ready_for_review",
+		"repository":{"name":"api","html_url":"https://github.com/acme/api","owner":{"login":"acme"}},
+		"pull_request":{"number":7,"title":"New title","draft":false,"user":{"login":"alice"}}}`
+	if rec := deliver(s, "pull_request", body, sign(testWebhookSecret, body)); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	pr, err := database.GetPR("github.com", "acme", "api", 7)
+	if err != nil || pr == nil {
+		t.Fatalf("GetPR failed: %v", err)
+	}
+	if pr.Title != "New title" || pr.Draft {
+		t.Errorf("Expected title and draft state from the payload, got title=%q draft=%v", pr.Title, pr.Draft)
+	}
+	if len(refreshed) != 1 || refreshed[0] != "github.com/acme/api" {
+		t.Errorf("Expected one refresh of github.com/acme/api, got %v", refreshed)
+	}
+
+	// Merging archives the PR with its review without waiting for a poll
+	body = strings.Replace(body, "ready_for_review", "closed", 1)
+	body = strings.Replace(body, `"draft":false`, `"draft":false,"closed_at":"2024-03-02T10:00:00Z","merged_at":"2024-03-02T10:00:00Z"`, 1)
+	deliver(s, "pull_request", body, sign(testWebhookSecret, body))
+	pr, _ = database.GetPR("github.com", "acme", "api", 7)
+	if pr == nil || pr.ClosedAt == nil || pr.MergedAt == nil || !pr.MergedAt.Equal(time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected closed PR to be archived as merged at the payload's time, got %+v", pr)
+	}
+	if pr.Status != "completed" {
+		t.Errorf("Expected the review to be kept, got status %s", pr.Status)
+	}
+}
+
+// TestWebhook_ClosedGoesThroughPoller tests that closing a PR is handed to the poller, which also
+// stops its queued or running review
+func TestWebhook_ClosedGoesThroughPoller(t *testing.T) {
+	s, database := newTestServer(t)
+	stub := &stubPoller{}
+	s.SetPoller(stub)
+	dbtest.AddPR(t, database, db.PR{RepoOwner: "acme", RepoName: "api", PRNumber: 7, LastCommitSHA: "abc1234", Status: "generating"})
+
+	body := `{"action":"closed",
+		"repository":{"name":"api","html_url":"https://github.com/acme/api","owner":{"login":"acme"}},
+		"pull_request":{"number":7,"title":"Add widgets","draft":false,"user":{"login":"alice"},"closed_at":"2024-03-02T10:00:00Z"}}`
+	if rec := deliver(s, "pull_request", body, sign(testWebhookSecret, body)); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(stub.archived) != 1 || stub.archived[0] != 7 {
+		t.Errorf("Expected the poller to archive PR 7, got %v", stub.archived)
+	}
+}
+
+// TestWebhook_ArchivedPROnlyRefreshed tests that deliveries for an archived PR leave the row alone
+// and only request a refresh, which reopens it once GitHub reports it open
+func TestWebhook_ArchivedPROnlyRefreshed(t *testing.T) {
+	s, database := newTestServer(t)
+	dbtest.AddPR(t, database, db.PR{RepoOwner: "acme", RepoName: "api", PRNumber: 7, LastCommitSHA: "abc1234", Status: "completed", Title: "Old title", Author: "alice", CIState: "success"})
+	closed := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	if _, err := database.ArchivePR("github.com", "acme", "api", 7, closed, nil); err != nil {
+		t.Fatalf("ArchivePR failed: %v", err)
+	}
+
+	var refreshed []int
+	s.SetPRRefresh(func(host, owner, repo string, number int) {
+		refreshed = append(refreshed, number)
+	})
+
+	for _, action := range []string{"edited", "synchronize", "reopened"} {
+		body := `{"action":"` + action + `",
+			"repository":{"name":"api","html_url":"https://github.com/acme/api","owner":{"login":"acme"}},
+			"pull_request":{"number":7,"title":"New title","draft":true,"user":{"login":"alice"}}}`
+		if rec := deliver(s, "pull_request", body, sign(testWebhookSecret, body)); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	pr, err := database.GetPR("github.com", "acme", "api", 7)
+	if err != nil || pr == nil {
+		t.Fatalf("GetPR failed: %v", err)
+	}
+	if !pr.Archived() || pr.Title != "Old title" || pr.Draft || pr.CIState != "success" {
+		t.Errorf("Expected the archived PR to be left alone, got %+v", pr)
+	}
+	if len(refreshed) != 3 {
+		t.Errorf("Expected each delivery to refresh PR 7, got %v", refreshed)
+	}
+}